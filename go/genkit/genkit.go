@@ -209,6 +209,16 @@ func Init(ctx context.Context, opts ...GenkitOption) (*Genkit, error) {
 	ai.DefineGenerateAction(ctx, r)
 	ai.LoadPromptDir(r, gOpts.PromptDir, "")
 
+	if gOpts.DefaultModel == "" {
+		// No default configured via WithDefaultModel; a plugin may supply
+		// one (see DefaultModelProvider).
+		for _, plugin := range gOpts.Plugins {
+			if dmp, ok := plugin.(DefaultModelProvider); ok && dmp.DefaultModelName() != "" {
+				gOpts.DefaultModel = dmp.DefaultModelName()
+				break
+			}
+		}
+	}
 	r.RegisterValue("genkit/defaultModel", gOpts.DefaultModel)
 	r.RegisterValue("genkit/promptDir", gOpts.PromptDir)
 
@@ -60,3 +60,40 @@ func count(ctx context.Context, n int, cb func(context.Context, int) error) (int
 	}
 	return n, nil
 }
+
+// defaultModelPlugin supplies a default model via DefaultModelProvider.
+type defaultModelPlugin struct{ model string }
+
+func (p *defaultModelPlugin) Name() string                              { return "dmp" }
+func (p *defaultModelPlugin) Init(ctx context.Context, g *Genkit) error { return nil }
+func (p *defaultModelPlugin) DefaultModelName() string                  { return p.model }
+
+func TestPluginDefaultModel(t *testing.T) {
+	g, err := Init(context.Background(), WithPlugins(&defaultModelPlugin{model: "dmp/small"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.reg.LookupValue("genkit/defaultModel"); got != "dmp/small" {
+		t.Errorf("got default model %q, want %q", got, "dmp/small")
+	}
+
+	// An app-level default takes precedence over the plugin's.
+	g, err = Init(context.Background(),
+		WithPlugins(&defaultModelPlugin{model: "dmp/small"}),
+		WithDefaultModel("other/model"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.reg.LookupValue("genkit/defaultModel"); got != "other/model" {
+		t.Errorf("got default model %q, want %q", got, "other/model")
+	}
+
+	// A plugin with no default leaves the value empty.
+	g, err = Init(context.Background(), WithPlugins(&defaultModelPlugin{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.reg.LookupValue("genkit/defaultModel"); got != "" {
+		t.Errorf("got default model %q, want empty", got)
+	}
+}
@@ -44,3 +44,14 @@ type DynamicPlugin interface {
 	// ResolveAction resolves an action type and name by defining and registering it in the registry.
 	ResolveAction(g *Genkit, atype core.ActionType, name string) error
 }
+
+// DefaultModelProvider is a [Plugin] that can supply a default model for
+// generation. It is consulted during [Init] when no default was configured
+// via [WithDefaultModel]; the first plugin returning a non-empty name wins.
+type DefaultModelProvider interface {
+	Plugin
+	// DefaultModelName returns the full "provider/model" name of the model to
+	// use when a generate call specifies none, or "" if the plugin has no
+	// default configured.
+	DefaultModelName() string
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package chunker splits text into token-bounded chunks ready for an
+// embedder and a vector indexer. It offers sentence, paragraph and semantic
+// (heading-aware) splitting strategies with configurable overlap, and
+// produces [ai.Document] values that carry the source document's metadata
+// plus the chunk index.
+package chunker
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Strategy selects how text is split into units before packing.
+type Strategy string
+
+const (
+	// StrategySentence splits on sentence boundaries.
+	StrategySentence Strategy = "sentence"
+	// StrategyParagraph splits on blank lines. This is the default.
+	StrategyParagraph Strategy = "paragraph"
+	// StrategySemantic splits on markdown headings first and paragraphs
+	// within each section, keeping related content together.
+	StrategySemantic Strategy = "semantic"
+)
+
+// Options configures chunking. The zero value splits by paragraph into
+// chunks of at most 512 tokens with no overlap.
+type Options struct {
+	// Strategy selects the splitting strategy.
+	Strategy Strategy
+	// MaxTokens caps the token count of each chunk. The default is 512.
+	MaxTokens int
+	// OverlapTokens repeats roughly this many trailing tokens of each chunk
+	// at the start of the next, preserving context across boundaries.
+	OverlapTokens int
+	// TokenCounter estimates the token count of a string. The default
+	// approximates one token per four characters, which tracks the BGE and
+	// Llama tokenizers closely enough for sizing chunks.
+	TokenCounter func(string) int
+}
+
+// Chunk splits text into documents according to opts.
+func Chunk(text string, opts *Options) []*ai.Document {
+	return ChunkDocument(ai.DocumentFromText(text, nil), opts)
+}
+
+// ChunkDocument splits a document's text into chunk documents. Each chunk
+// carries the source metadata plus a "chunkIndex" entry.
+func ChunkDocument(doc *ai.Document, opts *Options) []*ai.Document {
+	cfg := applyDefaults(opts)
+
+	var sb strings.Builder
+	for _, part := range doc.Content {
+		sb.WriteString(part.Text)
+	}
+	units := split(sb.String(), cfg.Strategy)
+	chunks := pack(units, cfg)
+
+	docs := make([]*ai.Document, 0, len(chunks))
+	for i, chunk := range chunks {
+		metadata := map[string]any{}
+		for k, v := range doc.Metadata {
+			metadata[k] = v
+		}
+		metadata["chunkIndex"] = i
+		docs = append(docs, ai.DocumentFromText(chunk, metadata))
+	}
+	return docs
+}
+
+func applyDefaults(opts *Options) *Options {
+	cfg := Options{}
+	if opts != nil {
+		cfg = *opts
+	}
+	if cfg.Strategy == "" {
+		cfg.Strategy = StrategyParagraph
+	}
+	if cfg.MaxTokens <= 0 {
+		cfg.MaxTokens = 512
+	}
+	if cfg.TokenCounter == nil {
+		cfg.TokenCounter = ApproxTokenCount
+	}
+	return &cfg
+}
+
+// ApproxTokenCount estimates the token count of text as one token per four
+// characters, rounding up.
+func ApproxTokenCount(text string) int {
+	return (len(text) + 3) / 4
+}
+
+var (
+	sentenceBoundary  = regexp.MustCompile(`([.!?])\s+`)
+	paragraphBoundary = regexp.MustCompile(`\n\s*\n`)
+	headingBoundary   = regexp.MustCompile(`(?m)^#{1,6}\s`)
+)
+
+// split breaks text into strategy-sized units, dropping empty ones.
+func split(text string, strategy Strategy) []string {
+	var raw []string
+	switch strategy {
+	case StrategySentence:
+		raw = sentenceBoundary.Split(text, -1)
+		// Split drops the boundary punctuation; reattach it.
+		marks := sentenceBoundary.FindAllStringSubmatch(text, -1)
+		for i := range raw {
+			if i < len(marks) {
+				raw[i] += marks[i][1]
+			}
+		}
+	case StrategySemantic:
+		for _, section := range splitSections(text) {
+			raw = append(raw, paragraphBoundary.Split(section, -1)...)
+		}
+	default: // StrategyParagraph
+		raw = paragraphBoundary.Split(text, -1)
+	}
+
+	units := make([]string, 0, len(raw))
+	for _, u := range raw {
+		if u = strings.TrimSpace(u); u != "" {
+			units = append(units, u)
+		}
+	}
+	return units
+}
+
+// splitSections splits text at markdown headings, keeping each heading with
+// the content that follows it.
+func splitSections(text string) []string {
+	starts := headingBoundary.FindAllStringIndex(text, -1)
+	if len(starts) == 0 {
+		return []string{text}
+	}
+	var sections []string
+	if starts[0][0] > 0 {
+		sections = append(sections, text[:starts[0][0]])
+	}
+	for i, loc := range starts {
+		end := len(text)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		sections = append(sections, text[loc[0]:end])
+	}
+	return sections
+}
+
+// pack greedily packs units into chunks of at most MaxTokens, carrying
+// trailing units worth roughly OverlapTokens into the next chunk. Units
+// larger than MaxTokens are hard-split on whitespace.
+func pack(units []string, cfg *Options) []string {
+	var chunks []string
+	var current []string
+	currentTokens := 0
+
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunks = append(chunks, strings.Join(current, "\n\n"))
+		if cfg.OverlapTokens > 0 {
+			current, currentTokens = overlapTail(current, cfg)
+		} else {
+			current, currentTokens = nil, 0
+		}
+	}
+
+	for _, unit := range units {
+		for _, piece := range hardSplit(unit, cfg) {
+			tokens := cfg.TokenCounter(piece)
+			if currentTokens+tokens > cfg.MaxTokens && len(current) > 0 {
+				flush()
+			}
+			current = append(current, piece)
+			currentTokens += tokens
+		}
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, strings.Join(current, "\n\n"))
+	}
+	return chunks
+}
+
+// overlapTail returns the trailing units of a flushed chunk worth roughly
+// OverlapTokens, to seed the next chunk.
+func overlapTail(units []string, cfg *Options) ([]string, int) {
+	var tail []string
+	tokens := 0
+	for i := len(units) - 1; i >= 0; i-- {
+		t := cfg.TokenCounter(units[i])
+		if tokens+t > cfg.OverlapTokens {
+			break
+		}
+		tail = append([]string{units[i]}, tail...)
+		tokens += t
+	}
+	return tail, tokens
+}
+
+// hardSplit splits a single oversized unit on whitespace into pieces that
+// fit MaxTokens. Units that already fit are returned unchanged.
+func hardSplit(unit string, cfg *Options) []string {
+	if cfg.TokenCounter(unit) <= cfg.MaxTokens {
+		return []string{unit}
+	}
+	words := strings.Fields(unit)
+	var pieces []string
+	var current []string
+	tokens := 0
+	for _, w := range words {
+		t := cfg.TokenCounter(w + " ")
+		if tokens+t > cfg.MaxTokens && len(current) > 0 {
+			pieces = append(pieces, strings.Join(current, " "))
+			current, tokens = nil, 0
+		}
+		current = append(current, w)
+		tokens += t
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, strings.Join(current, " "))
+	}
+	return pieces
+}
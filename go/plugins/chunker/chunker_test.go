@@ -0,0 +1,87 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wordCounter counts whitespace-separated words, making test sizes exact.
+func wordCounter(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestChunkParagraphs(t *testing.T) {
+	text := "first paragraph here\n\nsecond paragraph here\n\nthird paragraph here"
+	docs := Chunk(text, &Options{MaxTokens: 6, TokenCounter: wordCounter})
+
+	require.Len(t, docs, 2)
+	assert.Equal(t, "first paragraph here\n\nsecond paragraph here", docs[0].Content[0].Text)
+	assert.Equal(t, "third paragraph here", docs[1].Content[0].Text)
+	assert.Equal(t, 0, docs[0].Metadata["chunkIndex"])
+	assert.Equal(t, 1, docs[1].Metadata["chunkIndex"])
+}
+
+func TestChunkSentences(t *testing.T) {
+	text := "One two. Three four! Five six?"
+	docs := Chunk(text, &Options{
+		Strategy:     StrategySentence,
+		MaxTokens:    4,
+		TokenCounter: wordCounter,
+	})
+
+	require.Len(t, docs, 2)
+	assert.Equal(t, "One two.\n\nThree four!", docs[0].Content[0].Text)
+	assert.Equal(t, "Five six?", docs[1].Content[0].Text)
+}
+
+func TestChunkSemanticKeepsHeadings(t *testing.T) {
+	text := "# Intro\n\nintro text\n\n# Details\n\ndetail text"
+	docs := Chunk(text, &Options{
+		Strategy:     StrategySemantic,
+		MaxTokens:    4,
+		TokenCounter: wordCounter,
+	})
+
+	require.Len(t, docs, 2)
+	assert.Contains(t, docs[0].Content[0].Text, "# Intro")
+	assert.Contains(t, docs[1].Content[0].Text, "# Details")
+}
+
+func TestChunkOverlap(t *testing.T) {
+	text := "alpha beta\n\ngamma delta\n\nepsilon zeta"
+	docs := Chunk(text, &Options{
+		MaxTokens:     4,
+		OverlapTokens: 2,
+		TokenCounter:  wordCounter,
+	})
+
+	require.Len(t, docs, 2)
+	// The second chunk starts with the tail of the first.
+	assert.Equal(t, "alpha beta\n\ngamma delta", docs[0].Content[0].Text)
+	assert.Equal(t, "gamma delta\n\nepsilon zeta", docs[1].Content[0].Text)
+}
+
+func TestChunkHardSplitsOversizedUnits(t *testing.T) {
+	text := "one two three four five six seven eight"
+	docs := Chunk(text, &Options{MaxTokens: 3, TokenCounter: wordCounter})
+
+	require.Len(t, docs, 3)
+	for _, doc := range docs {
+		assert.LessOrEqual(t, wordCounter(doc.Content[0].Text), 3)
+	}
+}
+
+func TestChunkDocumentCarriesMetadata(t *testing.T) {
+	doc := ai.DocumentFromText("a\n\nb", map[string]any{"source": "kb"})
+	docs := ChunkDocument(doc, &Options{MaxTokens: 1, TokenCounter: wordCounter})
+
+	require.Len(t, docs, 2)
+	for i, d := range docs {
+		assert.Equal(t, "kb", d.Metadata["source"])
+		assert.Equal(t, i, d.Metadata["chunkIndex"])
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// MemoryStoreConfig configures [Cloudflare.NewMemoryStore].
+type MemoryStoreConfig struct {
+	// IndexName is the Vectorize index dedicated to memories. Required,
+	// and should not be shared with a RAG corpus: memories are namespaced
+	// per user, not per document collection.
+	IndexName string
+	// Embedder converts facts and recall queries to vectors. Required.
+	Embedder ai.Embedder
+	// EmbedderOptions are passed to the embedder on every call.
+	EmbedderOptions any
+	// RecallK is how many memories [MemoryStore.Recall] returns. The
+	// default is 5.
+	RecallK int
+	// MinScore drops recalled memories below the similarity threshold, so
+	// barely related facts don't pollute prompts.
+	MinScore float64
+}
+
+// MemoryStore is long-term agent memory: salient conversation facts are
+// embedded into a dedicated Vectorize index under the user's namespace, and
+// later sessions recall the ones relevant to the conversation at hand. What
+// one user tells an agent is never recalled for another.
+type MemoryStore struct {
+	ds       *VectorizeDocstore
+	recallK  int
+	minScore float64
+}
+
+// NewMemoryStore creates a memory store over the configured index.
+func (c *Cloudflare) NewMemoryStore(cfg MemoryStoreConfig) (*MemoryStore, error) {
+	if cfg.IndexName == "" {
+		return nil, errors.New("cloudflare: memory store requires an IndexName")
+	}
+	if cfg.Embedder == nil {
+		return nil, errors.New("cloudflare: memory store requires an Embedder")
+	}
+	if cfg.RecallK <= 0 {
+		cfg.RecallK = 5
+	}
+	return &MemoryStore{
+		ds: &VectorizeDocstore{
+			client: &vectorizeClient{
+				baseURL:   vectorizeBaseURL,
+				accountID: c.AccountID,
+				apiToken:  c.APIToken,
+			},
+			index:           cfg.IndexName,
+			embedder:        cfg.Embedder,
+			embedderOptions: cfg.EmbedderOptions,
+			textKey:         defaultTextKey,
+		},
+		recallK:  cfg.RecallK,
+		minScore: cfg.MinScore,
+	}, nil
+}
+
+// Remember stores facts about a user. Facts should be short, self-contained
+// statements ("prefers metric units", "works at Acme"), the shape embedding
+// similarity retrieves best.
+func (s *MemoryStore) Remember(ctx context.Context, userID string, facts ...string) error {
+	if userID == "" {
+		return errors.New("cloudflare: memory store requires a user ID")
+	}
+	docs := make([]*ai.Document, 0, len(facts))
+	for _, fact := range facts {
+		if strings.TrimSpace(fact) == "" {
+			continue
+		}
+		docs = append(docs, ai.DocumentFromText(fact, map[string]any{
+			"userId":   userID,
+			"storedAt": time.Now().Unix(),
+		}))
+	}
+	return s.ds.Index(ctx, docs, userID)
+}
+
+// Recall returns the stored facts most relevant to the query, most relevant
+// first, scoped to the user.
+func (s *MemoryStore) Recall(ctx context.Context, userID, query string) ([]string, error) {
+	if userID == "" {
+		return nil, errors.New("cloudflare: memory store requires a user ID")
+	}
+	resp, err := s.ds.Retrieve(ctx, &ai.RetrieverRequest{
+		Query: ai.DocumentFromText(query, nil),
+		Options: &VectorizeRetrieverOptions{
+			K:         s.recallK,
+			Namespace: userID,
+			MinScore:  s.minScore,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	facts := make([]string, 0, len(resp.Documents))
+	for _, doc := range resp.Documents {
+		facts = append(facts, doc.Content[0].Text)
+	}
+	return facts, nil
+}
+
+// PromptMessage recalls the facts relevant to the query and renders them as
+// a context message to prepend to the prompt. It returns nil when nothing
+// relevant is remembered, so callers can append the result unconditionally.
+func (s *MemoryStore) PromptMessage(ctx context.Context, userID, query string) (*ai.Message, error) {
+	facts, err := s.Recall(ctx, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(facts) == 0 {
+		return nil, nil
+	}
+	var sb strings.Builder
+	sb.WriteString("Relevant facts remembered from earlier sessions with this user:\n")
+	for _, fact := range facts {
+		sb.WriteString("- ")
+		sb.WriteString(fact)
+		sb.WriteString("\n")
+	}
+	return ai.NewUserTextMessage(strings.TrimRight(sb.String(), "\n")), nil
+}
@@ -0,0 +1,63 @@
+package cloudflare
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreRememberAndRecall(t *testing.T) {
+	var upserted []vectorizeVector
+	var queryBody map[string]any
+	ds := testDocstore(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/upsert"):
+			body, _ := io.ReadAll(r.Body)
+			scanner := bufio.NewScanner(bytes.NewReader(body))
+			for scanner.Scan() {
+				var v vectorizeVector
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &v))
+				upserted = append(upserted, v)
+			}
+			w.Write([]byte(`{"success":true,"errors":[],"result":{}}`))
+		case strings.HasSuffix(r.URL.Path, "/query"):
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &queryBody))
+			w.Write([]byte(`{"success":true,"errors":[],"result":{"matches":[
+				{"id":"a","score":0.9,"metadata":{"_content":"prefers metric units"}},
+				{"id":"b","score":0.7,"metadata":{"_content":"works at Acme"}}
+			]}}`))
+		}
+	})
+	store := &MemoryStore{ds: ds, recallK: 5}
+
+	require.NoError(t, store.Remember(context.Background(), "user-1",
+		"prefers metric units", "", "works at Acme"))
+	require.Len(t, upserted, 2)
+	assert.Equal(t, "user-1", upserted[0].Namespace)
+	assert.Equal(t, "user-1", upserted[0].Metadata["userId"])
+	assert.Equal(t, "prefers metric units", upserted[0].Metadata["_content"])
+
+	facts, err := store.Recall(context.Background(), "user-1", "what units?")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prefers metric units", "works at Acme"}, facts)
+	assert.Equal(t, "user-1", queryBody["namespace"])
+
+	msg, err := store.PromptMessage(context.Background(), "user-1", "what units?")
+	require.NoError(t, err)
+	assert.Contains(t, msg.Text(), "remembered from earlier sessions")
+	assert.Contains(t, msg.Text(), "- prefers metric units")
+
+	// A user ID is not optional: memory is always scoped.
+	require.Error(t, store.Remember(context.Background(), "", "fact"))
+	_, err = store.Recall(context.Background(), "", "query")
+	require.Error(t, err)
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/firebase/genkit/go/genkit"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// AnalyticsEngine is a Genkit telemetry plugin that exports trace spans and
+// generation metrics to Cloudflare Analytics Engine, giving Cloudflare-native
+// observability without running an OTel collector.
+type AnalyticsEngine struct {
+	// AccountID is the Cloudflare account. If empty, the
+	// CLOUDFLARE_ACCOUNT_ID environment variable is used.
+	AccountID string
+	// APIToken authenticates ingestion. If empty, the CLOUDFLARE_API_TOKEN
+	// environment variable is used.
+	APIToken string
+	// Dataset is the Analytics Engine dataset data points are written to.
+	// Required.
+	Dataset string
+	// ForceExport exports even in the dev environment.
+	ForceExport bool
+	// MetricInterval is the interval for exporting metric data. The
+	// default is 60 seconds.
+	MetricInterval time.Duration
+	// HTTPClient overrides the client used for ingestion. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// Name returns the name of the plugin.
+func (a *AnalyticsEngine) Name() string {
+	return "cloudflare-analytics-engine"
+}
+
+// Init registers the span processor and meter provider.
+// In the dev environment, this does nothing unless ForceExport is true.
+func (a *AnalyticsEngine) Init(ctx context.Context, g *genkit.Genkit) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("cloudflare.AnalyticsEngine.Init: %w", err)
+		}
+	}()
+
+	if a.AccountID == "" {
+		a.AccountID = os.Getenv("CLOUDFLARE_ACCOUNT_ID")
+	}
+	if a.APIToken == "" {
+		a.APIToken = os.Getenv("CLOUDFLARE_API_TOKEN")
+	}
+	if a.AccountID == "" || a.APIToken == "" {
+		return fmt.Errorf("config missing AccountID or APIToken")
+	}
+	if a.Dataset == "" {
+		return fmt.Errorf("config missing Dataset")
+	}
+
+	shouldExport := a.ForceExport || os.Getenv("GENKIT_ENV") != "dev"
+	if !shouldExport {
+		return nil
+	}
+
+	writer := &analyticsEngineWriter{
+		endpoint: fmt.Sprintf("https://api.cloudflare.com/client/v4/accounts/%s/analytics_engine/dataset/%s/event",
+			a.AccountID, a.Dataset),
+		token:      a.APIToken,
+		httpClient: a.HTTPClient,
+	}
+
+	genkit.RegisterSpanProcessor(g, sdktrace.NewBatchSpanProcessor(&analyticsEngineSpanExporter{writer: writer}))
+
+	interval := a.MetricInterval
+	if interval == 0 {
+		interval = 60 * time.Second
+	}
+	r := sdkmetric.NewPeriodicReader(&analyticsEngineMetricExporter{writer: writer}, sdkmetric.WithInterval(interval))
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(r)))
+	return nil
+}
+
+// dataPoint is one Analytics Engine data point in its native write format.
+type dataPoint struct {
+	Blobs   []string  `json:"blobs,omitempty"`
+	Doubles []float64 `json:"doubles,omitempty"`
+	Indexes []string  `json:"indexes,omitempty"`
+}
+
+// analyticsEngineWriter posts data points to the dataset's ingest endpoint
+// as newline-delimited JSON.
+type analyticsEngineWriter struct {
+	endpoint   string
+	token      string
+	httpClient *http.Client
+}
+
+func (w *analyticsEngineWriter) write(ctx context.Context, points []dataPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, p := range points {
+		if err := enc.Encode(p); err != nil {
+			return fmt.Errorf("failed to encode data point: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.endpoint, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+w.token)
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	httpClient := w.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("analytics engine ingestion returned status %d: %s", resp.StatusCode, body)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// analyticsEngineSpanExporter converts trace spans to data points. Span
+// inputs and outputs are deliberately not exported since they may contain
+// PII; only names, identifiers, status and timing go out.
+type analyticsEngineSpanExporter struct {
+	writer *analyticsEngineWriter
+}
+
+func (e *analyticsEngineSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	points := make([]dataPoint, 0, len(spans))
+	for _, s := range spans {
+		sc := s.SpanContext()
+		points = append(points, dataPoint{
+			Blobs: []string{
+				"span",
+				s.Name(),
+				sc.SpanID().String(),
+				s.Status().Code.String(),
+			},
+			Doubles: []float64{
+				float64(s.EndTime().Sub(s.StartTime())) / float64(time.Millisecond),
+			},
+			Indexes: []string{sc.TraceID().String()},
+		})
+	}
+	return e.writer.write(ctx, points)
+}
+
+func (e *analyticsEngineSpanExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// analyticsEngineMetricExporter converts metric data to data points, one per
+// series sample.
+type analyticsEngineMetricExporter struct {
+	writer *analyticsEngineWriter
+}
+
+func (e *analyticsEngineMetricExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return sdkmetric.DefaultTemporalitySelector(kind)
+}
+
+func (e *analyticsEngineMetricExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+func (e *analyticsEngineMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	var points []dataPoint
+	for _, scope := range rm.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					points = append(points, metricPoint(m.Name, float64(dp.Value)))
+				}
+			case metricdata.Sum[float64]:
+				for _, dp := range data.DataPoints {
+					points = append(points, metricPoint(m.Name, dp.Value))
+				}
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					points = append(points, metricPoint(m.Name, float64(dp.Value)))
+				}
+			case metricdata.Gauge[float64]:
+				for _, dp := range data.DataPoints {
+					points = append(points, metricPoint(m.Name, dp.Value))
+				}
+			case metricdata.Histogram[int64]:
+				for _, dp := range data.DataPoints {
+					points = append(points, histogramPoint(m.Name, float64(dp.Sum), dp.Count))
+				}
+			case metricdata.Histogram[float64]:
+				for _, dp := range data.DataPoints {
+					points = append(points, histogramPoint(m.Name, dp.Sum, dp.Count))
+				}
+			}
+		}
+	}
+	return e.writer.write(ctx, points)
+}
+
+func (e *analyticsEngineMetricExporter) ForceFlush(ctx context.Context) error {
+	return nil
+}
+
+func (e *analyticsEngineMetricExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func metricPoint(name string, value float64) dataPoint {
+	return dataPoint{
+		Blobs:   []string{"metric", name},
+		Doubles: []float64{value},
+		Indexes: []string{name},
+	}
+}
+
+func histogramPoint(name string, sum float64, count uint64) dataPoint {
+	return dataPoint{
+		Blobs:   []string{"metric", name},
+		Doubles: []float64{sum, float64(count)},
+		Indexes: []string{name},
+	}
+}
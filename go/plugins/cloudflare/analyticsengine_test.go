@@ -0,0 +1,136 @@
+package cloudflare
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestWriter returns a writer posting to an httptest server and a pointer
+// to the NDJSON bodies it received.
+func newTestWriter(t *testing.T) (*analyticsEngineWriter, *[]string) {
+	t.Helper()
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, string(body))
+	}))
+	t.Cleanup(srv.Close)
+	return &analyticsEngineWriter{endpoint: srv.URL, token: "tok"}, &bodies
+}
+
+func TestAnalyticsEngineWriter(t *testing.T) {
+	writer, bodies := newTestWriter(t)
+
+	// No points means no request at all.
+	require.NoError(t, writer.write(context.Background(), nil))
+	assert.Empty(t, *bodies)
+
+	points := []dataPoint{
+		{Blobs: []string{"metric", "a"}, Doubles: []float64{1}, Indexes: []string{"a"}},
+		{Blobs: []string{"metric", "b"}, Doubles: []float64{2}, Indexes: []string{"b"}},
+	}
+	require.NoError(t, writer.write(context.Background(), points))
+	require.Len(t, *bodies, 1)
+
+	// One JSON object per line, in order.
+	lines := strings.Split(strings.TrimSpace((*bodies)[0]), "\n")
+	require.Len(t, lines, 2)
+	assert.JSONEq(t, `{"blobs":["metric","a"],"doubles":[1],"indexes":["a"]}`, lines[0])
+	assert.JSONEq(t, `{"blobs":["metric","b"],"doubles":[2],"indexes":["b"]}`, lines[1])
+}
+
+func TestAnalyticsEngineWriterSurfacesIngestionErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("bad token"))
+	}))
+	defer srv.Close()
+
+	writer := &analyticsEngineWriter{endpoint: srv.URL, token: "tok"}
+	err := writer.write(context.Background(), []dataPoint{{Blobs: []string{"metric", "a"}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 403")
+	assert.Contains(t, err.Error(), "bad token")
+}
+
+func TestAnalyticsEngineSpanExporter(t *testing.T) {
+	writer, bodies := newTestWriter(t)
+	exporter := &analyticsEngineSpanExporter{writer: writer}
+
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	stub := tracetest.SpanStub{
+		Name: "generate",
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: trace.TraceID{1, 2},
+			SpanID:  trace.SpanID{3, 4},
+		}),
+		StartTime: start,
+		EndTime:   start.Add(250 * time.Millisecond),
+		Status:    sdktrace.Status{Code: codes.Ok},
+	}
+	require.NoError(t, exporter.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{stub.Snapshot()}))
+
+	require.Len(t, *bodies, 1)
+	line := strings.TrimSpace((*bodies)[0])
+	sc := stub.SpanContext
+	assert.JSONEq(t, `{
+		"blobs":["span","generate","`+sc.SpanID().String()+`","Ok"],
+		"doubles":[250],
+		"indexes":["`+sc.TraceID().String()+`"]
+	}`, line)
+}
+
+func TestAnalyticsEngineMetricExporter(t *testing.T) {
+	writer, bodies := newTestWriter(t)
+	exporter := &analyticsEngineMetricExporter{writer: writer}
+
+	rm := &metricdata.ResourceMetrics{
+		ScopeMetrics: []metricdata.ScopeMetrics{{
+			Metrics: []metricdata.Metrics{
+				{
+					Name: "genkit/ai/generate/requests",
+					Data: metricdata.Sum[int64]{
+						DataPoints: []metricdata.DataPoint[int64]{{Value: 7}},
+					},
+				},
+				{
+					Name: "genkit/flow/active",
+					Data: metricdata.Gauge[float64]{
+						DataPoints: []metricdata.DataPoint[float64]{{Value: 1.5}},
+					},
+				},
+				{
+					Name: "genkit/ai/generate/latency",
+					Data: metricdata.Histogram[float64]{
+						DataPoints: []metricdata.HistogramDataPoint[float64]{{Sum: 42.5, Count: 3}},
+					},
+				},
+			},
+		}},
+	}
+	require.NoError(t, exporter.Export(context.Background(), rm))
+
+	require.Len(t, *bodies, 1)
+	lines := strings.Split(strings.TrimSpace((*bodies)[0]), "\n")
+	require.Len(t, lines, 3)
+	assert.JSONEq(t, `{"blobs":["metric","genkit/ai/generate/requests"],"doubles":[7],"indexes":["genkit/ai/generate/requests"]}`, lines[0])
+	assert.JSONEq(t, `{"blobs":["metric","genkit/flow/active"],"doubles":[1.5],"indexes":["genkit/flow/active"]}`, lines[1])
+	// Histograms carry the sum and the sample count.
+	assert.JSONEq(t, `{"blobs":["metric","genkit/ai/generate/latency"],"doubles":[42.5,3],"indexes":["genkit/ai/generate/latency"]}`, lines[2])
+}
@@ -0,0 +1,443 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// AuditRecord is one model call as persisted for audit: the full request and
+// response payloads plus timing, so a regulated deployment can reconstruct
+// exactly what was sent to and received from Workers AI.
+type AuditRecord struct {
+	ID            string            `json:"id"`
+	Time          time.Time         `json:"time"`
+	LatencyMillis int64             `json:"latencyMillis"`
+	Request       *ai.ModelRequest  `json:"request"`
+	Response      *ai.ModelResponse `json:"response,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// AuditSink persists audit records. Implementations must be safe for
+// concurrent use; [NewR2AuditSink] and [NewD1AuditSink] provide
+// Cloudflare-backed implementations.
+type AuditSink interface {
+	Record(ctx context.Context, rec *AuditRecord) error
+}
+
+// AuditMiddleware returns a model middleware that persists every call —
+// request, response and error — to the sink. Unlike caching, sink failures on
+// a successful call are fatal: a regulated deployment must not return a
+// response it could not record. When the model call itself fails, the failure
+// is recorded best-effort and the model's error is returned either way.
+func AuditMiddleware(sink AuditSink) ai.ModelMiddleware {
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			start := time.Now()
+			resp, err := next(ctx, input, cb)
+
+			rec := &AuditRecord{
+				ID:            newAuditID(),
+				Time:          start.UTC(),
+				LatencyMillis: time.Since(start).Milliseconds(),
+				Request:       input,
+				Response:      resp,
+			}
+			if err != nil {
+				rec.Error = err.Error()
+				sink.Record(ctx, rec)
+				return nil, err
+			}
+			if recErr := sink.Record(ctx, rec); recErr != nil {
+				return nil, errors.Wrap(recErr, "cloudflare: failed to persist audit record")
+			}
+			return resp, nil
+		}
+	}
+}
+
+// newAuditID returns a random 128-bit hex identifier.
+func newAuditID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// encryptAuditPayload seals a marshaled record with AES-GCM, prepending the
+// nonce so [DecryptAuditPayload] can open it with only the key.
+func encryptAuditPayload(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: invalid audit encryption key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// DecryptAuditPayload opens a payload stored by an encrypting audit sink and
+// returns the marshaled [AuditRecord]. It is the offline counterpart for
+// inspecting exported audit data.
+func DecryptAuditPayload(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: invalid audit encryption key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("cloudflare: audit payload too short to decrypt")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to decrypt audit payload")
+	}
+	return plaintext, nil
+}
+
+// validAuditKey reports whether key is empty or a valid AES key length
+// (16, 24 or 32 bytes for AES-128/192/256).
+func validAuditKey(key []byte) bool {
+	switch len(key) {
+	case 0, 16, 24, 32:
+		return true
+	}
+	return false
+}
+
+// R2AuditConfig configures [NewR2AuditSink].
+type R2AuditConfig struct {
+	// AccountID is the Cloudflare account. Required.
+	AccountID string
+	// APIToken authenticates R2 object writes. Required.
+	APIToken string
+	// Bucket is the R2 bucket records are written to. Required.
+	Bucket string
+	// Prefix namespaces the records inside the bucket. The default is
+	// "genkit-audit".
+	Prefix string
+	// Retention is how long records are kept; [R2AuditSink.Prune] deletes
+	// older objects. Zero keeps records forever.
+	Retention time.Duration
+	// EncryptionKey, when set, encrypts each record with AES-GCM before
+	// upload (16, 24 or 32 bytes for AES-128/192/256). Records at rest can
+	// then only be read with [DecryptAuditPayload] and the key.
+	EncryptionKey []byte
+	// HTTPClient overrides the client used for R2 calls. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// R2AuditSink is an [AuditSink] that writes one object per model call,
+// date-prefixed (prefix/2006/01/02/<id>.json) so exports and retention sweeps
+// can operate on whole days.
+type R2AuditSink struct {
+	cfg     R2AuditConfig
+	baseURL string
+}
+
+// NewR2AuditSink creates the sink. The bucket must already exist.
+func NewR2AuditSink(cfg R2AuditConfig) (*R2AuditSink, error) {
+	if cfg.AccountID == "" || cfg.APIToken == "" || cfg.Bucket == "" {
+		return nil, errors.New("cloudflare: R2 audit sink requires AccountID, APIToken and Bucket")
+	}
+	if !validAuditKey(cfg.EncryptionKey) {
+		return nil, errors.New("cloudflare: audit encryption key must be 16, 24 or 32 bytes")
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "genkit-audit"
+	}
+	return &R2AuditSink{cfg: cfg, baseURL: vectorizeBaseURL}, nil
+}
+
+// Record implements [AuditSink].
+func (s *R2AuditSink) Record(ctx context.Context, rec *AuditRecord) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "cloudflare: failed to encode audit record")
+	}
+	key := fmt.Sprintf("%s/%s/%s.json", s.cfg.Prefix, rec.Time.UTC().Format("2006/01/02"), rec.ID)
+	contentType := "application/json"
+	if len(s.cfg.EncryptionKey) > 0 {
+		if payload, err = encryptAuditPayload(s.cfg.EncryptionKey, payload); err != nil {
+			return err
+		}
+		key += ".enc"
+		contentType = "application/octet-stream"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.APIToken)
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cloudflare: failed to write audit object")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cloudflare: writing audit object returned status %d: %s", resp.StatusCode, body)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Prune deletes records older than the configured retention. The date in
+// each object key decides expiry, so payloads are never fetched or decrypted.
+// It is a no-op without a retention policy; callers typically run it on a
+// schedule.
+func (s *R2AuditSink) Prune(ctx context.Context) error {
+	if s.cfg.Retention <= 0 {
+		return nil
+	}
+	// Whole days expire at once: a key is expired when its date prefix
+	// sorts before the cutoff day.
+	cutoff := s.cfg.Prefix + "/" + time.Now().UTC().Add(-s.cfg.Retention).Format("2006/01/02")
+	keys, err := s.listObjects(ctx, s.cfg.Prefix+"/")
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if key >= cutoff {
+			continue
+		}
+		if err := s.deleteObject(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listObjects returns all keys under a prefix, following list pagination.
+func (s *R2AuditSink) listObjects(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	cursor := ""
+	for {
+		u := fmt.Sprintf("%s/accounts/%s/r2/buckets/%s/objects?prefix=%s",
+			s.baseURL, s.cfg.AccountID, s.cfg.Bucket, url.QueryEscape(prefix))
+		if cursor != "" {
+			u += "&cursor=" + url.QueryEscape(cursor)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+s.cfg.APIToken)
+
+		resp, err := s.httpClient().Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "cloudflare: failed to list audit objects")
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("cloudflare: listing audit objects returned status %d: %s", resp.StatusCode, body)
+		}
+
+		var envelope struct {
+			Success bool `json:"success"`
+			Result  []struct {
+				Key string `json:"key"`
+			} `json:"result"`
+			ResultInfo struct {
+				Cursor      string `json:"cursor"`
+				IsTruncated bool   `json:"is_truncated"`
+			} `json:"result_info"`
+		}
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return nil, errors.Wrap(err, "cloudflare: failed to parse audit object listing")
+		}
+		if !envelope.Success {
+			return nil, errors.New("cloudflare: listing audit objects returned an error")
+		}
+		for _, obj := range envelope.Result {
+			keys = append(keys, obj.Key)
+		}
+		if !envelope.ResultInfo.IsTruncated || envelope.ResultInfo.Cursor == "" {
+			break
+		}
+		cursor = envelope.ResultInfo.Cursor
+	}
+	return keys, nil
+}
+
+func (s *R2AuditSink) deleteObject(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.APIToken)
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cloudflare: failed to delete audit object")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cloudflare: deleting audit object returned status %d: %s", resp.StatusCode, body)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (s *R2AuditSink) objectURL(key string) string {
+	return fmt.Sprintf("%s/accounts/%s/r2/buckets/%s/objects/%s",
+		s.baseURL, s.cfg.AccountID, s.cfg.Bucket, url.PathEscape(key))
+}
+
+func (s *R2AuditSink) httpClient() *http.Client {
+	if s.cfg.HTTPClient != nil {
+		return s.cfg.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// d1AuditTable is the table D1AuditSink stores records in.
+const d1AuditTable = "genkit_audit_log"
+
+// D1AuditConfig configures [NewD1AuditSink].
+type D1AuditConfig struct {
+	// AccountID is the Cloudflare account. Required.
+	AccountID string
+	// APIToken authenticates D1 queries. Required.
+	APIToken string
+	// DatabaseID is the D1 database UUID. Required.
+	DatabaseID string
+	// Table overrides the audit table name. The default is
+	// "genkit_audit_log".
+	Table string
+	// Retention is how long records are kept; expired rows are deleted on
+	// write. Zero keeps records forever.
+	Retention time.Duration
+	// EncryptionKey, when set, encrypts each record's payload with AES-GCM
+	// before storage (16, 24 or 32 bytes). The row's timestamp stays in the
+	// clear so retention still works; the payload can only be read with
+	// [DecryptAuditPayload] and the key.
+	EncryptionKey []byte
+	// HTTPClient overrides the client used for queries. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// D1AuditSink is an [AuditSink] backed by a D1 table
+// (id, created_at, encrypted, payload), so audits can be answered with plain
+// SQL instead of exporting objects.
+type D1AuditSink struct {
+	cfg     D1AuditConfig
+	baseURL string
+}
+
+// NewD1AuditSink creates the sink and its backing table if it doesn't exist.
+func NewD1AuditSink(ctx context.Context, cfg D1AuditConfig) (*D1AuditSink, error) {
+	if cfg.AccountID == "" || cfg.APIToken == "" || cfg.DatabaseID == "" {
+		return nil, errors.New("cloudflare: D1 audit sink requires AccountID, APIToken and DatabaseID")
+	}
+	if !validAuditKey(cfg.EncryptionKey) {
+		return nil, errors.New("cloudflare: audit encryption key must be 16, 24 or 32 bytes")
+	}
+	if cfg.Table == "" {
+		cfg.Table = d1AuditTable
+	}
+	s := &D1AuditSink{cfg: cfg, baseURL: vectorizeBaseURL}
+	_, err := s.query(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id TEXT PRIMARY KEY,
+		created_at INTEGER NOT NULL,
+		encrypted INTEGER NOT NULL DEFAULT 0,
+		payload TEXT NOT NULL
+	)`, cfg.Table))
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to create audit table")
+	}
+	return s, nil
+}
+
+// Record implements [AuditSink]. Writes also delete rows past the retention
+// window, so a steadily written table never needs a separate sweep.
+func (s *D1AuditSink) Record(ctx context.Context, rec *AuditRecord) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "cloudflare: failed to encode audit record")
+	}
+	encrypted := 0
+	stored := string(payload)
+	if len(s.cfg.EncryptionKey) > 0 {
+		sealed, err := encryptAuditPayload(s.cfg.EncryptionKey, payload)
+		if err != nil {
+			return err
+		}
+		encrypted = 1
+		stored = base64.StdEncoding.EncodeToString(sealed)
+	}
+
+	_, err = s.query(ctx,
+		fmt.Sprintf("INSERT INTO %s (id, created_at, encrypted, payload) VALUES (?, ?, ?, ?)", s.cfg.Table),
+		rec.ID, rec.Time.Unix(), encrypted, stored)
+	if err != nil {
+		return err
+	}
+
+	if s.cfg.Retention > 0 {
+		cutoff := time.Now().Add(-s.cfg.Retention).Unix()
+		if _, err := s.query(ctx,
+			fmt.Sprintf("DELETE FROM %s WHERE created_at < ?", s.cfg.Table), cutoff); err != nil {
+			return errors.Wrap(err, "cloudflare: failed to prune expired audit records")
+		}
+	}
+	return nil
+}
+
+// query runs one parameterized SQL statement against the audit database.
+func (s *D1AuditSink) query(ctx context.Context, sql string, params ...any) ([]map[string]any, error) {
+	return d1Query(ctx, s.httpClient(), s.baseURL, s.cfg.AccountID, s.cfg.APIToken, s.cfg.DatabaseID, sql, params...)
+}
+
+func (s *D1AuditSink) httpClient() *http.Client {
+	if s.cfg.HTTPClient != nil {
+		return s.cfg.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,193 @@
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySink is a trivial AuditSink for exercising the middleware.
+type memorySink struct {
+	mu      sync.Mutex
+	records []*AuditRecord
+	err     error
+}
+
+func (s *memorySink) Record(ctx context.Context, rec *AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, rec)
+	return s.err
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	req := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("hello")}}
+
+	t.Run("records successful calls", func(t *testing.T) {
+		sink := &memorySink{}
+		next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return &ai.ModelResponse{Message: ai.NewModelTextMessage("hi")}, nil
+		}
+		resp, err := AuditMiddleware(sink)(next)(context.Background(), req, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hi", resp.Message.Text())
+
+		require.Len(t, sink.records, 1)
+		rec := sink.records[0]
+		assert.NotEmpty(t, rec.ID)
+		assert.Equal(t, "hello", rec.Request.Messages[0].Text())
+		assert.Equal(t, "hi", rec.Response.Message.Text())
+		assert.Empty(t, rec.Error)
+	})
+
+	t.Run("records failed calls and keeps the model error", func(t *testing.T) {
+		sink := &memorySink{err: errors.New("sink down")}
+		next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return nil, errors.New("model exploded")
+		}
+		_, err := AuditMiddleware(sink)(next)(context.Background(), req, nil)
+		require.ErrorContains(t, err, "model exploded")
+
+		require.Len(t, sink.records, 1)
+		assert.Equal(t, "model exploded", sink.records[0].Error)
+	})
+
+	t.Run("sink failure on success is fatal", func(t *testing.T) {
+		sink := &memorySink{err: errors.New("sink down")}
+		next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return &ai.ModelResponse{Message: ai.NewModelTextMessage("hi")}, nil
+		}
+		_, err := AuditMiddleware(sink)(next)(context.Background(), req, nil)
+		require.ErrorContains(t, err, "failed to persist audit record")
+	})
+}
+
+func TestR2AuditSinkRecord(t *testing.T) {
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		objects[r.URL.EscapedPath()] = body
+		mu.Unlock()
+		w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	key := bytes.Repeat([]byte("k"), 32)
+	sink, err := NewR2AuditSink(R2AuditConfig{
+		AccountID:     "acct",
+		APIToken:      "token",
+		Bucket:        "audit",
+		EncryptionKey: key,
+	})
+	require.NoError(t, err)
+	sink.baseURL = srv.URL
+
+	rec := &AuditRecord{
+		ID:      "abc123",
+		Time:    time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC),
+		Request: &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("hello")}},
+	}
+	require.NoError(t, sink.Record(context.Background(), rec))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, objects, 1)
+	sealed, ok := objects["/accounts/acct/r2/buckets/audit/objects/genkit-audit%2F2025%2F06%2F01%2Fabc123.json.enc"]
+	require.True(t, ok, "object stored under the expected date-prefixed key")
+
+	// The payload only opens with the key, and round-trips the record.
+	plaintext, err := DecryptAuditPayload(key, sealed)
+	require.NoError(t, err)
+	var got AuditRecord
+	require.NoError(t, json.Unmarshal(plaintext, &got))
+	assert.Equal(t, "hello", got.Request.Messages[0].Text())
+
+	_, err = DecryptAuditPayload(bytes.Repeat([]byte("x"), 32), sealed)
+	require.Error(t, err)
+}
+
+func TestR2AuditSinkPrune(t *testing.T) {
+	var mu sync.Mutex
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"success":true,"result":[
+				{"key":"genkit-audit/2020/01/01/old.json"},
+				{"key":"genkit-audit/2999/01/01/new.json"}
+			]}`))
+		case http.MethodDelete:
+			mu.Lock()
+			deleted = append(deleted, r.URL.EscapedPath())
+			mu.Unlock()
+			w.Write([]byte(`{"success":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	sink, err := NewR2AuditSink(R2AuditConfig{
+		AccountID: "acct",
+		APIToken:  "token",
+		Bucket:    "audit",
+		Retention: 30 * 24 * time.Hour,
+	})
+	require.NoError(t, err)
+	sink.baseURL = srv.URL
+
+	require.NoError(t, sink.Prune(context.Background()))
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, deleted, 1)
+	assert.Contains(t, deleted[0], "2020%2F01%2F01%2Fold.json")
+}
+
+func TestD1AuditSinkRecord(t *testing.T) {
+	var mu sync.Mutex
+	var statements []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q struct {
+			SQL string `json:"sql"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &q))
+		mu.Lock()
+		statements = append(statements, q.SQL)
+		mu.Unlock()
+		w.Write([]byte(`{"success":true,"result":[{"success":true,"results":[]}]}`))
+	}))
+	defer srv.Close()
+
+	sink := &D1AuditSink{
+		cfg: D1AuditConfig{
+			AccountID:  "acct",
+			APIToken:   "token",
+			DatabaseID: "db",
+			Table:      d1AuditTable,
+			Retention:  time.Hour,
+		},
+		baseURL: srv.URL,
+	}
+	rec := &AuditRecord{ID: "abc123", Time: time.Now()}
+	require.NoError(t, sink.Record(context.Background(), rec))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, statements, 2)
+	assert.Contains(t, statements[0], "INSERT INTO genkit_audit_log")
+	assert.Contains(t, statements[1], "DELETE FROM genkit_audit_log WHERE created_at <")
+}
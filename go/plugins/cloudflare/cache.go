@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ResponseCache stores model responses keyed by a hash of the request, so
+// identical prompts can be answered without a model call. Implementations
+// must be safe for concurrent use.
+type ResponseCache interface {
+	// Get returns the cached response for key, or false when absent or
+	// expired.
+	Get(ctx context.Context, key string) (*ai.ModelResponse, bool, error)
+	// Put stores a response under key for the given TTL. A zero TTL caches
+	// without expiry.
+	Put(ctx context.Context, key string, resp *ai.ModelResponse, ttl time.Duration) error
+}
+
+// CacheKey returns the cache key for a model request: a hash over its
+// messages, tools, config and output settings.
+func CacheKey(req *ai.ModelRequest) (string, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("cloudflare: failed to hash model request: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// CachingMiddleware returns a model middleware that serves repeated requests
+// from cache and stores fresh responses with the given TTL. Cache failures
+// are deliberately non-fatal: the request falls through to the model.
+func CachingMiddleware(cache ResponseCache, ttl time.Duration) ai.ModelMiddleware {
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			key, err := CacheKey(input)
+			if err != nil {
+				return next(ctx, input, cb)
+			}
+			if cached, ok, err := cache.Get(ctx, key); err == nil && ok {
+				return cached, nil
+			}
+
+			resp, err := next(ctx, input, cb)
+			if err != nil {
+				return resp, err
+			}
+			cache.Put(ctx, key, resp, ttl)
+			return resp, nil
+		}
+	}
+}
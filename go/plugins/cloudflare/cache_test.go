@@ -0,0 +1,77 @@
+package cloudflare
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCache is a trivial ResponseCache for exercising the middleware.
+type memoryCache struct {
+	mu      sync.Mutex
+	entries map[string]*ai.ModelResponse
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) (*ai.ModelResponse, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, ok := c.entries[key]
+	return resp, ok, nil
+}
+
+func (c *memoryCache) Put(ctx context.Context, key string, resp *ai.ModelResponse, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*ai.ModelResponse{}
+	}
+	c.entries[key] = resp
+	return nil
+}
+
+func TestCachingMiddleware(t *testing.T) {
+	cache := &memoryCache{}
+	calls := 0
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		calls++
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage("fresh")}, nil
+	}
+	mw := CachingMiddleware(cache, time.Minute)
+
+	req := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("hello")}}
+	resp, err := mw(next)(context.Background(), req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", resp.Message.Text())
+	assert.Equal(t, 1, calls)
+
+	// The identical request is served from cache.
+	resp, err = mw(next)(context.Background(), req, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh", resp.Message.Text())
+	assert.Equal(t, 1, calls)
+
+	// A different request misses.
+	other := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("different")}}
+	_, err = mw(next)(context.Background(), other, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestCacheKeyIsStable(t *testing.T) {
+	req := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("hello")}}
+	k1, err := CacheKey(req)
+	require.NoError(t, err)
+	k2, err := CacheKey(req)
+	require.NoError(t, err)
+	assert.Equal(t, k1, k2)
+
+	other := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("bye")}}
+	k3, err := CacheKey(other)
+	require.NoError(t, err)
+	assert.NotEqual(t, k1, k3)
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// defaultCitationMinSimilarity is the cosine similarity below which a
+// sentence is considered unsupported by any injected document.
+const defaultCitationMinSimilarity = 0.6
+
+// Citation links one sentence of a response to the grounding document that
+// best supports it.
+type Citation struct {
+	// Sentence is the cited answer sentence.
+	Sentence string `json:"sentence"`
+	// DocIndex is the position of the supporting document in the request's
+	// Docs.
+	DocIndex int `json:"docIndex"`
+	// Score is the cosine similarity between sentence and document.
+	Score float64 `json:"score"`
+}
+
+// CitationOptions configures [CitationMiddleware].
+type CitationOptions struct {
+	// MinSimilarity is the similarity threshold for attributing a sentence
+	// to a document. The default is 0.6; sentences below it carry no
+	// citation.
+	MinSimilarity float64
+	// EmbedderOptions are passed to the embedder on every call.
+	EmbedderOptions any
+}
+
+// CitationMiddleware returns a model middleware that attributes each sentence
+// of a grounded response to the injected document most similar to it, by
+// embedding sentences and documents with the same embedder and comparing
+// cosine similarity. Citations land on the response's custom metadata (read
+// them back with [Citations]) so UIs can render per-sentence sources.
+// Attribution is best-effort: embedding failures leave the response without
+// citations rather than failing the request.
+func CitationMiddleware(embedder ai.Embedder, opts *CitationOptions) ai.ModelMiddleware {
+	cfg := CitationOptions{}
+	if opts != nil {
+		cfg = *opts
+	}
+	if cfg.MinSimilarity <= 0 {
+		cfg.MinSimilarity = defaultCitationMinSimilarity
+	}
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			resp, err := next(ctx, input, cb)
+			if err != nil || len(input.Docs) == 0 || resp.Message == nil {
+				return resp, err
+			}
+			citations, citeErr := citeSentences(ctx, embedder, input.Docs, resp.Message.Text(), cfg)
+			if citeErr != nil {
+				slog.Warn("cloudflare: citation attribution failed", "err", citeErr)
+				return resp, nil
+			}
+			if len(citations) > 0 {
+				custom, _ := resp.Custom.(map[string]any)
+				if custom == nil {
+					custom = map[string]any{}
+				}
+				custom["citations"] = citations
+				resp.Custom = custom
+			}
+			return resp, nil
+		}
+	}
+}
+
+// Citations returns the citations attached by [CitationMiddleware], or nil.
+func Citations(resp *ai.ModelResponse) []Citation {
+	custom, _ := resp.Custom.(map[string]any)
+	citations, _ := custom["citations"].([]Citation)
+	return citations
+}
+
+// citeSentences embeds the answer's sentences and the grounding documents in
+// one call and attributes each sentence to its most similar document.
+func citeSentences(ctx context.Context, embedder ai.Embedder, docs []*ai.Document, answer string, cfg CitationOptions) ([]Citation, error) {
+	sentences := sentencesOf(answer)
+	if len(sentences) == 0 {
+		return nil, nil
+	}
+
+	input := make([]*ai.Document, 0, len(sentences)+len(docs))
+	for _, s := range sentences {
+		input = append(input, ai.DocumentFromText(s, nil))
+	}
+	input = append(input, docs...)
+	eres, err := embedder.Embed(ctx, &ai.EmbedRequest{Input: input, Options: cfg.EmbedderOptions})
+	if err != nil {
+		return nil, err
+	}
+
+	var citations []Citation
+	for i, sentence := range sentences {
+		sentenceVec := eres.Embeddings[i].Embedding
+		best, bestScore := -1, 0.0
+		for d := range docs {
+			score := cosineSim(sentenceVec, eres.Embeddings[len(sentences)+d].Embedding)
+			if score > bestScore {
+				best, bestScore = d, score
+			}
+		}
+		if best >= 0 && bestScore >= cfg.MinSimilarity {
+			citations = append(citations, Citation{Sentence: sentence, DocIndex: best, Score: bestScore})
+		}
+	}
+	return citations, nil
+}
+
+// sentencesOf splits text into trimmed sentences at sentence-ending
+// punctuation followed by whitespace.
+func sentencesOf(text string) []string {
+	var sentences []string
+	start := 0
+	afterEnd := false
+	for i, r := range text {
+		switch {
+		case r == '.' || r == '!' || r == '?':
+			afterEnd = true
+		case unicode.IsSpace(r):
+			if afterEnd {
+				if s := strings.TrimSpace(text[start:i]); s != "" {
+					sentences = append(sentences, s)
+				}
+				start = i
+				afterEnd = false
+			}
+		default:
+			afterEnd = false
+		}
+	}
+	if s := strings.TrimSpace(text[start:]); s != "" {
+		sentences = append(sentences, s)
+	}
+	return sentences
+}
+
+// cosineSim returns the cosine similarity of two vectors, or 0 when either
+// has no magnitude.
+func cosineSim(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
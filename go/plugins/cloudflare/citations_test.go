@@ -0,0 +1,78 @@
+package cloudflare
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topicEmbedder embeds each text onto an axis per known topic word, so
+// sentences and documents about the same topic are similar and others are
+// orthogonal.
+type topicEmbedder struct {
+	topics []string
+}
+
+func (e *topicEmbedder) Name() string { return "fake/topics" }
+
+func (e *topicEmbedder) Embed(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+	resp := &ai.EmbedResponse{}
+	for _, doc := range req.Input {
+		var text strings.Builder
+		for _, p := range doc.Content {
+			text.WriteString(p.Text)
+		}
+		vec := make([]float32, len(e.topics))
+		for i, topic := range e.topics {
+			if strings.Contains(strings.ToLower(text.String()), topic) {
+				vec[i] = 1
+			}
+		}
+		resp.Embeddings = append(resp.Embeddings, &ai.Embedding{Embedding: vec})
+	}
+	return resp, nil
+}
+
+func TestCitationMiddleware(t *testing.T) {
+	embedder := &topicEmbedder{topics: []string{"paris", "tokyo"}}
+	mw := CitationMiddleware(embedder, nil)
+
+	req := &ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage("capitals?")},
+		Docs: []*ai.Document{
+			ai.DocumentFromText("Paris is the capital of France.", nil),
+			ai.DocumentFromText("Tokyo is the capital of Japan.", nil),
+		},
+	}
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{
+			Message: ai.NewModelTextMessage("Paris is in France. Tokyo is in Japan. Water is wet."),
+		}, nil
+	}
+
+	resp, err := mw(next)(context.Background(), req, nil)
+	require.NoError(t, err)
+
+	citations := Citations(resp)
+	require.Len(t, citations, 2)
+	assert.Equal(t, "Paris is in France.", citations[0].Sentence)
+	assert.Equal(t, 0, citations[0].DocIndex)
+	assert.Equal(t, "Tokyo is in Japan.", citations[1].Sentence)
+	assert.Equal(t, 1, citations[1].DocIndex)
+	assert.InDelta(t, 1.0, citations[0].Score, 1e-9)
+}
+
+func TestCitationMiddlewareWithoutDocsIsPassthrough(t *testing.T) {
+	mw := CitationMiddleware(&topicEmbedder{}, nil)
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage("hello")}, nil
+	}
+	resp, err := mw(next)(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, resp.Custom)
+	assert.Empty(t, Citations(resp))
+}
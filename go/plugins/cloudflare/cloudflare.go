@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package cloudflare provides a meta-plugin that wires the full Cloudflare
+// stack (Workers AI models and embedders, plus gateway-routed providers)
+// from a single configuration block, so one WithPlugins entry is enough.
+package cloudflare
+
+import (
+	"context"
+	"os"
+
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/firebase/genkit/go/plugins/workersai"
+	"github.com/pkg/errors"
+)
+
+const provider = "cloudflare"
+
+// Cloudflare wires Workers AI models, the bge embedders and gateway-routed
+// providers together from one configuration block.
+type Cloudflare struct {
+	// AccountID is the Cloudflare account. If empty, the
+	// CLOUDFLARE_ACCOUNT_ID environment variable is used.
+	AccountID string
+	// APIToken authenticates all requests. If empty, the
+	// CLOUDFLARE_API_TOKEN environment variable is used.
+	APIToken string
+	// Gateway optionally enables gateway provider mode for upstream BYOK
+	// models (OpenAI, Anthropic, Groq, ...).
+	Gateway *workersai.GatewayConfig
+
+	workersAI *workersai.WorkersAI
+}
+
+// Name returns the name of the plugin.
+func (c *Cloudflare) Name() string {
+	return provider
+}
+
+// Init initializes every component of the Cloudflare stack.
+func (c *Cloudflare) Init(ctx context.Context, g *genkit.Genkit) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "cloudflare.Init")
+		}
+	}()
+
+	// The workersai plugin reads its credentials from the environment, so
+	// propagate explicit configuration there before initializing it; resolve
+	// the other direction too so components like the Vectorize retriever can
+	// use the fields directly.
+	if c.AccountID != "" {
+		os.Setenv("CLOUDFLARE_ACCOUNT_ID", c.AccountID)
+	} else {
+		c.AccountID = os.Getenv("CLOUDFLARE_ACCOUNT_ID")
+	}
+	if c.APIToken != "" {
+		os.Setenv("CLOUDFLARE_API_TOKEN", c.APIToken)
+	} else {
+		c.APIToken = os.Getenv("CLOUDFLARE_API_TOKEN")
+	}
+
+	c.workersAI = &workersai.WorkersAI{
+		Gateway: c.Gateway,
+	}
+	return c.workersAI.Init(ctx, g)
+}
+
+// WorkersAI returns the underlying Workers AI plugin, for access to helpers
+// like usage polling and rate-limit state.
+func (c *Cloudflare) WorkersAI() *workersai.WorkersAI {
+	return c.workersAI
+}
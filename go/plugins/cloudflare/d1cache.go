@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// d1DefaultTable is the table D1Cache stores responses in.
+const d1DefaultTable = "genkit_response_cache"
+
+// D1CacheConfig configures [NewD1Cache].
+type D1CacheConfig struct {
+	// AccountID is the Cloudflare account. Required.
+	AccountID string
+	// APIToken authenticates D1 queries. Required.
+	APIToken string
+	// DatabaseID is the D1 database UUID. Required.
+	DatabaseID string
+	// Table overrides the cache table name. The default is
+	// "genkit_response_cache".
+	Table string
+	// HTTPClient overrides the client used for queries. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// D1Cache is a [ResponseCache] backed by a D1 database. Unlike KV-style
+// caches, entries and their hit statistics live in a SQL table
+// (key, response, created_at, expires_at, hits, last_hit_at), so teams can
+// introspect hit rates and implement eviction policies with plain SQL.
+type D1Cache struct {
+	cfg D1CacheConfig
+}
+
+// NewD1Cache creates the cache and its backing table if it doesn't exist.
+func NewD1Cache(ctx context.Context, cfg D1CacheConfig) (*D1Cache, error) {
+	if cfg.AccountID == "" || cfg.APIToken == "" || cfg.DatabaseID == "" {
+		return nil, errors.New("cloudflare: D1 cache requires AccountID, APIToken and DatabaseID")
+	}
+	if cfg.Table == "" {
+		cfg.Table = d1DefaultTable
+	}
+	c := &D1Cache{cfg: cfg}
+	_, err := c.query(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		key TEXT PRIMARY KEY,
+		response TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER,
+		hits INTEGER NOT NULL DEFAULT 0,
+		last_hit_at INTEGER
+	)`, cfg.Table))
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to create D1 cache table")
+	}
+	return c, nil
+}
+
+// Get implements [ResponseCache]. Hits increment the row's statistics.
+func (c *D1Cache) Get(ctx context.Context, key string) (*ai.ModelResponse, bool, error) {
+	now := time.Now().Unix()
+	rows, err := c.query(ctx,
+		fmt.Sprintf("SELECT response FROM %s WHERE key = ? AND (expires_at IS NULL OR expires_at > ?)", c.cfg.Table),
+		key, now)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rows) == 0 {
+		return nil, false, nil
+	}
+	encoded, _ := rows[0]["response"].(string)
+
+	var resp ai.ModelResponse
+	if err := json.Unmarshal([]byte(encoded), &resp); err != nil {
+		return nil, false, errors.Wrap(err, "cloudflare: failed to decode cached response")
+	}
+
+	if _, err := c.query(ctx,
+		fmt.Sprintf("UPDATE %s SET hits = hits + 1, last_hit_at = ? WHERE key = ?", c.cfg.Table),
+		now, key); err != nil {
+		return nil, false, err
+	}
+	return &resp, true, nil
+}
+
+// Put implements [ResponseCache].
+func (c *D1Cache) Put(ctx context.Context, key string, resp *ai.ModelResponse, ttl time.Duration) error {
+	encoded, err := json.Marshal(resp)
+	if err != nil {
+		return errors.Wrap(err, "cloudflare: failed to encode response for caching")
+	}
+	now := time.Now().Unix()
+	var expires any
+	if ttl > 0 {
+		expires = now + int64(ttl.Seconds())
+	}
+	_, err = c.query(ctx,
+		fmt.Sprintf("INSERT OR REPLACE INTO %s (key, response, created_at, expires_at, hits, last_hit_at) VALUES (?, ?, ?, ?, 0, NULL)", c.cfg.Table),
+		key, string(encoded), now, expires)
+	return err
+}
+
+// query runs one parameterized SQL statement against the database and
+// returns the result rows.
+func (c *D1Cache) query(ctx context.Context, sql string, params ...any) ([]map[string]any, error) {
+	httpClient := c.cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return d1Query(ctx, httpClient, vectorizeBaseURL, c.cfg.AccountID, c.cfg.APIToken, c.cfg.DatabaseID, sql, params...)
+}
+
+// d1Query runs one parameterized SQL statement against a D1 database and
+// returns the result rows. It is shared by every D1-backed feature in the
+// package.
+func d1Query(ctx context.Context, httpClient *http.Client, baseURL, accountID, apiToken, databaseID, sql string, params ...any) ([]map[string]any, error) {
+	body, err := json.Marshal(map[string]any{"sql": sql, "params": params})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/accounts/%s/d1/database/%s/query", baseURL, accountID, databaseID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: D1 query failed")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to read D1 response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cloudflare: D1 query returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var envelope struct {
+		Success bool `json:"success"`
+		Errors  []any
+		Result  []struct {
+			Success bool             `json:"success"`
+			Results []map[string]any `json:"results"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to parse D1 response")
+	}
+	if !envelope.Success || len(envelope.Result) == 0 || !envelope.Result[0].Success {
+		return nil, fmt.Errorf("cloudflare: D1 query returned an error: %v", envelope.Errors)
+	}
+	return envelope.Result[0].Results, nil
+}
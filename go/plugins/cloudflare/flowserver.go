@@ -0,0 +1,325 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/plugins/server"
+)
+
+// AccessConfig configures Cloudflare Access JWT validation for a flow
+// server exposed behind Access.
+type AccessConfig struct {
+	// TeamDomain is the Access team domain, e.g. "myteam" for
+	// myteam.cloudflareaccess.com.
+	TeamDomain string
+	// Audience is the Access application's audience (AUD) tag.
+	Audience string
+	// HTTPClient overrides the client used to fetch the team's signing
+	// keys. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// TunnelConfig configures Cloudflare Tunnel registration for a flow server.
+// The helper shells out to cloudflared, which must be installed.
+type TunnelConfig struct {
+	// Token is the connector token of a named tunnel (from
+	// `cloudflared tunnel token <name>`). Required.
+	Token string
+	// CloudflaredPath overrides the cloudflared binary path. Defaults to
+	// "cloudflared" on PATH.
+	CloudflaredPath string
+}
+
+// Start starts the Genkit flow HTTP server with optional Cloudflare Tunnel
+// registration and Access JWT validation, so Go flow servers can be exposed
+// securely on a Cloudflare hostname with a few lines of code. Either config
+// may be nil to skip that piece.
+func Start(ctx context.Context, addr string, mux *http.ServeMux, access *AccessConfig, tunnel *TunnelConfig) error {
+	if tunnel != nil {
+		stop, err := startTunnel(ctx, tunnel)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	wrapped := mux
+	if access != nil {
+		validator, err := newAccessValidator(access)
+		if err != nil {
+			return err
+		}
+		wrapped = http.NewServeMux()
+		wrapped.Handle("/", validator.middleware(mux))
+	}
+	return server.Start(ctx, addr, wrapped)
+}
+
+// startTunnel launches cloudflared for the configured tunnel and returns a
+// function that stops it.
+func startTunnel(ctx context.Context, cfg *TunnelConfig) (func(), error) {
+	if cfg.Token == "" {
+		return nil, errors.New("cloudflare: tunnel registration requires a connector token")
+	}
+	bin := cfg.CloudflaredPath
+	if bin == "" {
+		bin = "cloudflared"
+	}
+	cmd := exec.CommandContext(ctx, bin, "tunnel", "run", "--token", cfg.Token)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("cloudflare: failed to start cloudflared: %w", err)
+	}
+	return func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}, nil
+}
+
+// accessValidator validates Cloudflare Access JWTs against the team's
+// published signing keys.
+type accessValidator struct {
+	audience   string
+	issuer     string
+	certsURL   string
+	httpClient *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PublicKey
+	lastRefresh time.Time
+}
+
+// keyRefreshInterval is the minimum time between JWKS fetches, so
+// unauthenticated requests carrying bogus kids cannot hammer the certs
+// endpoint.
+const keyRefreshInterval = time.Minute
+
+func newAccessValidator(cfg *AccessConfig) (*accessValidator, error) {
+	if cfg.TeamDomain == "" || cfg.Audience == "" {
+		return nil, errors.New("cloudflare: Access validation requires TeamDomain and Audience")
+	}
+	issuer := fmt.Sprintf("https://%s.cloudflareaccess.com", cfg.TeamDomain)
+	return &accessValidator{
+		audience:   cfg.Audience,
+		issuer:     issuer,
+		certsURL:   issuer + "/cdn-cgi/access/certs",
+		httpClient: cfg.HTTPClient,
+	}, nil
+}
+
+// middleware rejects requests without a valid Access JWT.
+func (v *accessValidator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("Cf-Access-Jwt-Assertion")
+		if token == "" {
+			if cookie, err := r.Cookie("CF_Authorization"); err == nil {
+				token = cookie.Value
+			}
+		}
+		if token == "" {
+			http.Error(w, "missing Cloudflare Access token", http.StatusUnauthorized)
+			return
+		}
+		if err := v.validate(r.Context(), token); err != nil {
+			http.Error(w, "invalid Cloudflare Access token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validate checks the token's signature and claims.
+func (v *accessValidator) validate(ctx context.Context, token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed JWT")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return fmt.Errorf("malformed JWT header: %w", err)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return fmt.Errorf("malformed JWT header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+
+	key, err := v.signingKey(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed JWT signature: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("JWT signature verification failed: %w", err)
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return fmt.Errorf("malformed JWT claims: %w", err)
+	}
+	var claims struct {
+		Aud any    `json:"aud"`
+		Exp int64  `json:"exp"`
+		Iss string `json:"iss"`
+	}
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return fmt.Errorf("malformed JWT claims: %w", err)
+	}
+
+	// Access always issues an expiry; a token without one never expires
+	// and is rejected outright.
+	if claims.Exp == 0 {
+		return errors.New("JWT has no expiry claim")
+	}
+	if time.Now().Unix() > claims.Exp {
+		return errors.New("JWT is expired")
+	}
+	if claims.Iss != v.issuer {
+		return fmt.Errorf("JWT issuer %q does not match team domain", claims.Iss)
+	}
+	if !audienceMatches(claims.Aud, v.audience) {
+		return errors.New("JWT audience does not match the Access application")
+	}
+	return nil
+}
+
+func audienceMatches(aud any, want string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == want
+	case []any:
+		for _, v := range a {
+			if s, ok := v.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// signingKey returns the team's RSA public key for kid, fetching the key set
+// when the kid is unknown — at most once per [keyRefreshInterval], so unknown
+// kids are effectively cached as negative entries between fetches.
+func (v *accessValidator) signingKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	key, ok := v.keys[kid]
+	refreshable := time.Since(v.lastRefresh) >= keyRefreshInterval
+	if !ok && refreshable {
+		// Claim the refresh slot before unlocking, so a burst of unknown
+		// kids triggers one fetch, not one each.
+		v.lastRefresh = time.Now()
+	}
+	v.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !refreshable {
+		return nil, fmt.Errorf("no Access signing key with kid %q", kid)
+	}
+
+	if err := v.refreshKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("no Access signing key with kid %q", kid)
+	}
+	return key, nil
+}
+
+// refreshKeys fetches the team's JWKS and replaces the cached key set.
+func (v *accessValidator) refreshKeys(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.certsURL, nil)
+	if err != nil {
+		return err
+	}
+	httpClient := v.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch Access signing keys: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching Access signing keys returned status %d", resp.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("failed to parse Access signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			continue
+		}
+		eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(nBytes),
+			E: int(new(big.Int).SetBytes(eBytes).Int64()),
+		}
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.mu.Unlock()
+	return nil
+}
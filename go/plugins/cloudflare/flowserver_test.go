@@ -0,0 +1,191 @@
+package cloudflare
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signAccessJWT mints an RS256 JWT over the given header and claims.
+func signAccessJWT(t *testing.T, key *rsa.PrivateKey, header, claims map[string]any) string {
+	t.Helper()
+	encode := func(v map[string]any) string {
+		raw, err := json.Marshal(v)
+		require.NoError(t, err)
+		return base64.RawURLEncoding.EncodeToString(raw)
+	}
+	signingInput := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// newTestValidator returns a validator whose certs endpoint serves the given
+// key under kid "key1", along with a counter of JWKS fetches.
+func newTestValidator(t *testing.T, key *rsa.PrivateKey) (*accessValidator, *int) {
+	t.Helper()
+	fetches := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]any{{
+				"kid": "key1",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	validator, err := newAccessValidator(&AccessConfig{TeamDomain: "myteam", Audience: "aud-tag"})
+	require.NoError(t, err)
+	validator.certsURL = srv.URL
+	return validator, &fetches
+}
+
+func TestAccessValidatorValidate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	header := map[string]any{"alg": "RS256", "kid": "key1"}
+	claims := func(mutate func(map[string]any)) map[string]any {
+		c := map[string]any{
+			"iss": "https://myteam.cloudflareaccess.com",
+			"aud": "aud-tag",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		if mutate != nil {
+			mutate(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T, key *rsa.PrivateKey) string
+		wantErr string
+	}{
+		{
+			name: "valid token",
+			token: func(t *testing.T, key *rsa.PrivateKey) string {
+				return signAccessJWT(t, key, header, claims(nil))
+			},
+		},
+		{
+			name: "malformed token",
+			token: func(t *testing.T, key *rsa.PrivateKey) string {
+				return "not-a-jwt"
+			},
+			wantErr: "malformed JWT",
+		},
+		{
+			name: "unsupported algorithm",
+			token: func(t *testing.T, key *rsa.PrivateKey) string {
+				return signAccessJWT(t, key, map[string]any{"alg": "none", "kid": "key1"}, claims(nil))
+			},
+			wantErr: `unsupported JWT algorithm "none"`,
+		},
+		{
+			name: "signature from the wrong key",
+			token: func(t *testing.T, key *rsa.PrivateKey) string {
+				return signAccessJWT(t, otherKey, header, claims(nil))
+			},
+			wantErr: "signature verification failed",
+		},
+		{
+			name: "missing expiry rejected",
+			token: func(t *testing.T, key *rsa.PrivateKey) string {
+				return signAccessJWT(t, key, header, claims(func(c map[string]any) {
+					delete(c, "exp")
+				}))
+			},
+			wantErr: "no expiry claim",
+		},
+		{
+			name: "expired token",
+			token: func(t *testing.T, key *rsa.PrivateKey) string {
+				return signAccessJWT(t, key, header, claims(func(c map[string]any) {
+					c["exp"] = time.Now().Add(-time.Hour).Unix()
+				}))
+			},
+			wantErr: "expired",
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T, key *rsa.PrivateKey) string {
+				return signAccessJWT(t, key, header, claims(func(c map[string]any) {
+					c["iss"] = "https://otherteam.cloudflareaccess.com"
+				}))
+			},
+			wantErr: "issuer",
+		},
+		{
+			name: "wrong audience",
+			token: func(t *testing.T, key *rsa.PrivateKey) string {
+				return signAccessJWT(t, key, header, claims(func(c map[string]any) {
+					c["aud"] = "other-app"
+				}))
+			},
+			wantErr: "audience",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, _ := newTestValidator(t, key)
+			err := validator.validate(context.Background(), tt.token(t, key))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestAccessValidatorAudienceMatches(t *testing.T) {
+	assert.True(t, audienceMatches("aud-tag", "aud-tag"))
+	assert.True(t, audienceMatches([]any{"other", "aud-tag"}, "aud-tag"))
+	assert.False(t, audienceMatches("other", "aud-tag"))
+	assert.False(t, audienceMatches([]any{"other"}, "aud-tag"))
+	assert.False(t, audienceMatches(nil, "aud-tag"))
+}
+
+func TestAccessValidatorRateLimitsKeyFetches(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	validator, fetches := newTestValidator(t, key)
+
+	// The first unknown kid triggers a JWKS fetch and still fails.
+	_, err = validator.signingKey(context.Background(), "bogus-1")
+	require.Error(t, err)
+	assert.Equal(t, 1, *fetches)
+
+	// Further unknown kids within the refresh interval fail without
+	// touching the certs endpoint again.
+	_, err = validator.signingKey(context.Background(), "bogus-2")
+	require.Error(t, err)
+	assert.Equal(t, 1, *fetches)
+
+	// Known kids keep resolving from the cache.
+	got, err := validator.signingKey(context.Background(), "key1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, got.N.Cmp(key.N))
+	assert.Equal(t, 1, *fetches)
+}
@@ -0,0 +1,209 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// IndexProgress reports bulk-indexing progress after each completed batch.
+type IndexProgress struct {
+	// IndexedDocuments is how many documents have been upserted so far,
+	// including ones skipped via the checkpoint.
+	IndexedDocuments int
+	// TotalDocuments is the size of the corpus being indexed.
+	TotalDocuments int
+	// Batch is the zero-based index of the batch that just completed.
+	Batch int
+}
+
+// IndexCheckpoint records which batches of a bulk-indexing run completed, so
+// an interrupted run over the same corpus and options can resume without
+// re-upserting. It is JSON-serializable for persisting between runs.
+type IndexCheckpoint struct {
+	mu sync.Mutex
+	// CompletedBatches holds the zero-based indexes of completed batches.
+	CompletedBatches map[int]bool `json:"completedBatches"`
+}
+
+func (cp *IndexCheckpoint) done(batch int) bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.CompletedBatches[batch]
+}
+
+func (cp *IndexCheckpoint) complete(batch int) {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	if cp.CompletedBatches == nil {
+		cp.CompletedBatches = map[int]bool{}
+	}
+	cp.CompletedBatches[batch] = true
+}
+
+// IndexAllOptions configures [VectorizeDocstore.IndexAll].
+type IndexAllOptions struct {
+	// Namespace to upsert into.
+	Namespace string
+	// BatchSize is how many documents go into one upsert. The default is
+	// 100.
+	BatchSize int
+	// Concurrency is how many batches are in flight at once. The default
+	// is 4.
+	Concurrency int
+	// MaxRetries is how many times a failed batch is retried with
+	// exponential backoff before the run fails. The default is 3.
+	MaxRetries int
+	// MinBatchInterval, when positive, spaces out batch submissions to stay
+	// under API rate limits.
+	MinBatchInterval time.Duration
+	// Progress, when set, is called after every completed batch.
+	Progress func(IndexProgress)
+	// Checkpoint, when set, skips batches recorded as completed and records
+	// new completions, making the run resumable.
+	Checkpoint *IndexCheckpoint
+}
+
+// IndexAll embeds and upserts a large corpus in batches with bounded
+// concurrency, per-batch retries, optional rate limiting, progress reporting
+// and resumable checkpoints.
+func (ds *VectorizeDocstore) IndexAll(ctx context.Context, docs []*ai.Document, opts *IndexAllOptions) error {
+	cfg := IndexAllOptions{}
+	if opts != nil {
+		cfg = *opts
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 3
+	}
+
+	type batch struct {
+		index int
+		docs  []*ai.Document
+	}
+	var batches []batch
+	for i := 0; i < len(docs); i += cfg.BatchSize {
+		end := min(i+cfg.BatchSize, len(docs))
+		batches = append(batches, batch{index: i / cfg.BatchSize, docs: docs[i:end]})
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		indexed  int
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sem := make(chan struct{}, cfg.Concurrency)
+	var lastSubmit time.Time
+
+	for _, b := range batches {
+		if cfg.Checkpoint != nil && cfg.Checkpoint.done(b.index) {
+			mu.Lock()
+			indexed += len(b.docs)
+			mu.Unlock()
+			continue
+		}
+
+		// Space out submissions when rate limiting is configured.
+		if cfg.MinBatchInterval > 0 {
+			if wait := cfg.MinBatchInterval - time.Since(lastSubmit); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+				}
+			}
+			lastSubmit = time.Now()
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(b batch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := ds.indexBatchWithRetry(ctx, b.docs, &cfg)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errors.Wrapf(err, "cloudflare: indexing batch %d failed", b.index)
+					cancel()
+				}
+				return
+			}
+			if cfg.Checkpoint != nil {
+				cfg.Checkpoint.complete(b.index)
+			}
+			indexed += len(b.docs)
+			if cfg.Progress != nil {
+				cfg.Progress(IndexProgress{
+					IndexedDocuments: indexed,
+					TotalDocuments:   len(docs),
+					Batch:            b.index,
+				})
+			}
+		}(b)
+	}
+
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// indexBatchWithRetry upserts one batch, retrying transient failures with
+// exponential backoff.
+func (ds *VectorizeDocstore) indexBatchWithRetry(ctx context.Context, docs []*ai.Document, cfg *IndexAllOptions) error {
+	backoff := 500 * time.Millisecond
+	var err error
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+				backoff *= 2
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = ds.Index(ctx, docs, cfg.Namespace); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
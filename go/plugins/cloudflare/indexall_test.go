@@ -0,0 +1,113 @@
+package cloudflare
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEmbedder returns a fixed vector per input document.
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) Name() string { return "fake" }
+
+func (fakeEmbedder) Embed(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+	resp := &ai.EmbedResponse{}
+	for range req.Input {
+		resp.Embeddings = append(resp.Embeddings, &ai.Embedding{Embedding: []float32{1, 2, 3}})
+	}
+	return resp, nil
+}
+
+func testDocstore(t *testing.T, handler http.HandlerFunc) *VectorizeDocstore {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &VectorizeDocstore{
+		client: &vectorizeClient{
+			baseURL:   srv.URL,
+			accountID: "acct",
+			apiToken:  "token",
+		},
+		index:           "idx",
+		embedder:        fakeEmbedder{},
+		embedderOptions: nil,
+		textKey:         defaultTextKey,
+	}
+}
+
+func makeDocs(n int) []*ai.Document {
+	docs := make([]*ai.Document, n)
+	for i := range docs {
+		docs[i] = ai.DocumentFromText("doc", map[string]any{"i": i})
+	}
+	return docs
+}
+
+func TestIndexAllBatchesAndReportsProgress(t *testing.T) {
+	var upserts atomic.Int32
+	ds := testDocstore(t, func(w http.ResponseWriter, r *http.Request) {
+		upserts.Add(1)
+		w.Write([]byte(`{"success":true,"errors":[],"result":{"mutationId":"m"}}`))
+	})
+
+	var progress []IndexProgress
+	err := ds.IndexAll(context.Background(), makeDocs(5), &IndexAllOptions{
+		BatchSize:   2,
+		Concurrency: 1,
+		Progress:    func(p IndexProgress) { progress = append(progress, p) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), upserts.Load())
+	require.Len(t, progress, 3)
+	assert.Equal(t, 5, progress[2].IndexedDocuments)
+	assert.Equal(t, 5, progress[2].TotalDocuments)
+}
+
+func TestIndexAllResumesFromCheckpoint(t *testing.T) {
+	var upserts atomic.Int32
+	ds := testDocstore(t, func(w http.ResponseWriter, r *http.Request) {
+		upserts.Add(1)
+		w.Write([]byte(`{"success":true,"errors":[],"result":{}}`))
+	})
+
+	cp := &IndexCheckpoint{CompletedBatches: map[int]bool{0: true, 1: true}}
+	err := ds.IndexAll(context.Background(), makeDocs(5), &IndexAllOptions{
+		BatchSize:  2,
+		Checkpoint: cp,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), upserts.Load())
+	assert.True(t, cp.CompletedBatches[2])
+}
+
+func TestIndexAllRetriesFailedBatches(t *testing.T) {
+	var calls atomic.Int32
+	ds := testDocstore(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"success":true,"errors":[],"result":{}}`))
+	})
+
+	err := ds.IndexAll(context.Background(), makeDocs(1), &IndexAllOptions{MaxRetries: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIndexAllSurfacesPersistentFailure(t *testing.T) {
+	ds := testDocstore(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	err := ds.IndexAll(context.Background(), makeDocs(1), &IndexAllOptions{MaxRetries: 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "batch 0 failed")
+}
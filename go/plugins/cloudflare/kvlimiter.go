@@ -0,0 +1,239 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// KVRateLimiterConfig configures [NewKVRateLimiter].
+type KVRateLimiterConfig struct {
+	// AccountID is the Cloudflare account. Required.
+	AccountID string
+	// APIToken authenticates KV access. Required.
+	APIToken string
+	// NamespaceID is the Workers KV namespace holding the bucket. Required.
+	NamespaceID string
+	// Key is the KV key of the shared bucket. The default is
+	// "genkit_rate_limit".
+	Key string
+	// RequestsPerMinute is the account-wide budget the fleet shares.
+	// Required.
+	RequestsPerMinute int
+	// LeaseSize is how many tokens an instance leases per KV round-trip.
+	// Larger leases mean fewer KV reads but coarser fairness. The default
+	// is 10.
+	LeaseSize int
+	// HTTPClient overrides the client used for KV calls. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// KVRateLimiter coordinates a token bucket across instances through Workers
+// KV. Each instance leases batches of tokens from the shared bucket and
+// spends them locally, so a fleet sharing one Cloudflare account collectively
+// stays near the configured budget. KV offers no compare-and-swap, so
+// enforcement is approximate under races; lease sizes trade precision for KV
+// traffic.
+type KVRateLimiter struct {
+	cfg     KVRateLimiterConfig
+	baseURL string
+
+	mu     sync.Mutex
+	leased int
+}
+
+// kvBucketState is the shared bucket as stored in KV.
+type kvBucketState struct {
+	Tokens    float64 `json:"tokens"`
+	UpdatedAt int64   `json:"updatedAt"`
+}
+
+// NewKVRateLimiter creates a limiter over the configured namespace.
+func NewKVRateLimiter(cfg KVRateLimiterConfig) (*KVRateLimiter, error) {
+	if cfg.AccountID == "" || cfg.APIToken == "" || cfg.NamespaceID == "" {
+		return nil, errors.New("cloudflare: KV rate limiter requires AccountID, APIToken and NamespaceID")
+	}
+	if cfg.RequestsPerMinute <= 0 {
+		return nil, errors.New("cloudflare: KV rate limiter requires RequestsPerMinute")
+	}
+	if cfg.Key == "" {
+		cfg.Key = "genkit_rate_limit"
+	}
+	if cfg.LeaseSize <= 0 {
+		cfg.LeaseSize = 10
+	}
+	return &KVRateLimiter{cfg: cfg, baseURL: vectorizeBaseURL}, nil
+}
+
+// Acquire blocks until a token is available, returning early only when ctx
+// is done.
+func (l *KVRateLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.leased > 0 {
+			l.leased--
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		granted, wait, err := l.lease(ctx)
+		if err != nil {
+			return err
+		}
+		if granted > 0 {
+			l.mu.Lock()
+			l.leased += granted - 1
+			l.mu.Unlock()
+			return nil
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Middleware returns a model middleware that acquires a token before each
+// model call.
+func (l *KVRateLimiter) Middleware() ai.ModelMiddleware {
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			if err := l.Acquire(ctx); err != nil {
+				return nil, errors.Wrap(err, "cloudflare: rate limit wait aborted")
+			}
+			return next(ctx, input, cb)
+		}
+	}
+}
+
+// lease refills the shared bucket for elapsed time and takes up to LeaseSize
+// tokens. When the bucket is empty it returns how long to wait for the next
+// token.
+func (l *KVRateLimiter) lease(ctx context.Context) (granted int, wait time.Duration, err error) {
+	state, err := l.readState(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	now := time.Now()
+	capacity := float64(l.cfg.RequestsPerMinute)
+	perSecond := capacity / 60
+
+	tokens := capacity
+	if state != nil {
+		elapsed := now.Unix() - state.UpdatedAt
+		tokens = state.Tokens + float64(elapsed)*perSecond
+		if tokens > capacity {
+			tokens = capacity
+		}
+	}
+
+	granted = min(l.cfg.LeaseSize, int(tokens))
+	if granted == 0 {
+		return 0, time.Duration(float64(time.Second) / perSecond), nil
+	}
+
+	if err := l.writeState(ctx, &kvBucketState{
+		Tokens:    tokens - float64(granted),
+		UpdatedAt: now.Unix(),
+	}); err != nil {
+		return 0, 0, err
+	}
+	return granted, 0, nil
+}
+
+func (l *KVRateLimiter) kvURL() string {
+	return fmt.Sprintf("%s/accounts/%s/storage/kv/namespaces/%s/values/%s",
+		l.baseURL, l.cfg.AccountID, l.cfg.NamespaceID, l.cfg.Key)
+}
+
+// readState fetches the bucket, returning nil when the key doesn't exist.
+func (l *KVRateLimiter) readState(ctx context.Context) (*kvBucketState, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.kvURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+l.cfg.APIToken)
+
+	resp, err := l.httpClient().Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to read rate-limit state")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cloudflare: reading rate-limit state returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var state kvBucketState
+	if err := json.Unmarshal(body, &state); err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to parse rate-limit state")
+	}
+	return &state, nil
+}
+
+func (l *KVRateLimiter) writeState(ctx context.Context, state *kvBucketState) error {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, l.kvURL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+l.cfg.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := l.httpClient().Do(req)
+	if err != nil {
+		return errors.Wrap(err, "cloudflare: failed to write rate-limit state")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cloudflare: writing rate-limit state returned status %d: %s", resp.StatusCode, respBody)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (l *KVRateLimiter) httpClient() *http.Client {
+	if l.cfg.HTTPClient != nil {
+		return l.cfg.HTTPClient
+	}
+	return http.DefaultClient
+}
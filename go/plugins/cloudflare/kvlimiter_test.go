@@ -0,0 +1,100 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKV serves one KV key over the REST value endpoints.
+type fakeKV struct {
+	mu    sync.Mutex
+	value []byte
+	gets  int
+	puts  int
+}
+
+func (kv *fakeKV) handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		kv.mu.Lock()
+		defer kv.mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			kv.gets++
+			if kv.value == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(kv.value)
+		case http.MethodPut:
+			kv.puts++
+			kv.value, _ = io.ReadAll(r.Body)
+			w.Write([]byte(`{"success":true}`))
+		}
+	}
+}
+
+func testLimiter(t *testing.T, kv *fakeKV, budget, leaseSize int) *KVRateLimiter {
+	t.Helper()
+	srv := httptest.NewServer(kv.handler())
+	t.Cleanup(srv.Close)
+
+	l, err := NewKVRateLimiter(KVRateLimiterConfig{
+		AccountID:         "acct",
+		APIToken:          "token",
+		NamespaceID:       "ns",
+		RequestsPerMinute: budget,
+		LeaseSize:         leaseSize,
+	})
+	require.NoError(t, err)
+	l.baseURL = srv.URL
+	return l
+}
+
+func TestKVRateLimiterLeasesBatches(t *testing.T) {
+	kv := &fakeKV{}
+	l := testLimiter(t, kv, 60, 5)
+
+	// Five acquisitions should need only one KV lease round-trip.
+	for range 5 {
+		require.NoError(t, l.Acquire(context.Background()))
+	}
+	assert.Equal(t, 1, kv.gets)
+	assert.Equal(t, 1, kv.puts)
+
+	// The sixth token needs another lease.
+	require.NoError(t, l.Acquire(context.Background()))
+	assert.Equal(t, 2, kv.gets)
+}
+
+func TestKVRateLimiterDeductsSharedTokens(t *testing.T) {
+	kv := &fakeKV{}
+	l := testLimiter(t, kv, 60, 10)
+
+	require.NoError(t, l.Acquire(context.Background()))
+
+	var state kvBucketState
+	require.NoError(t, json.Unmarshal(kv.value, &state))
+	assert.Equal(t, float64(50), state.Tokens)
+}
+
+func TestKVRateLimiterBlocksWhenExhausted(t *testing.T) {
+	kv := &fakeKV{}
+	// A depleted bucket written just now.
+	state, _ := json.Marshal(kvBucketState{Tokens: 0, UpdatedAt: time.Now().Unix()})
+	kv.value = state
+	l := testLimiter(t, kv, 60, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := l.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jba/slog/withsupport"
+)
+
+// LogpushConfig configures a [LogpushHandler].
+type LogpushConfig struct {
+	// Endpoint is the Logpush HTTP destination (or Workers Logs ingest
+	// URL) log batches are posted to. Required.
+	Endpoint string
+	// Token, when set, is sent as a bearer token.
+	Token string
+	// LogLevel is the minimum level at which logs are shipped. Defaults
+	// to [slog.LevelInfo].
+	LogLevel slog.Leveler
+	// FlushInterval is how often buffered records are shipped. The
+	// default is 5 seconds.
+	FlushInterval time.Duration
+	// BatchSize flushes early once this many records are buffered. The
+	// default is 100.
+	BatchSize int
+	// HTTPClient overrides the client used for shipping. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// LogpushHandler is a slog.Handler that ships structured Genkit logs (flow,
+// model, latency, tokens) to a Cloudflare Logpush HTTP destination, so
+// Workers-centric teams can keep all logs in their existing pipeline.
+// Records are buffered and posted as newline-delimited JSON batches.
+type LogpushHandler struct {
+	cfg LogpushConfig
+	goa *withsupport.GroupOrAttrs
+
+	mu      *sync.Mutex
+	pending *[]map[string]any
+	stop    chan struct{}
+}
+
+// NewLogpushHandler creates a handler shipping to cfg.Endpoint and starts
+// its background flusher. Call [LogpushHandler.Close] to flush and stop it.
+func NewLogpushHandler(cfg LogpushConfig) (*LogpushHandler, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("cloudflare: Logpush handler requires an Endpoint")
+	}
+	if cfg.LogLevel == nil {
+		cfg.LogLevel = slog.LevelInfo
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	pending := []map[string]any{}
+	h := &LogpushHandler{
+		cfg:     cfg,
+		mu:      &sync.Mutex{},
+		pending: &pending,
+		stop:    make(chan struct{}),
+	}
+	go h.flushLoop()
+	return h, nil
+}
+
+func (h *LogpushHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.cfg.LogLevel.Level()
+}
+
+func (h *LogpushHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.goa = h2.goa.WithAttrs(as)
+	return &h2
+}
+
+func (h *LogpushHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.goa = h2.goa.WithGroup(name)
+	return &h2
+}
+
+func (h *LogpushHandler) Handle(ctx context.Context, r slog.Record) error {
+	entry := map[string]any{
+		"timestamp": r.Time.UTC().Format(time.RFC3339Nano),
+		"level":     r.Level.String(),
+		"message":   r.Message,
+		"module":    "genkit",
+	}
+	attrs := map[string]any{}
+	groups := h.goa.Apply(func(groups []string, a slog.Attr) {
+		attrs[attrKey(groups, a.Key)] = a.Value.Resolve().Any()
+	})
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[attrKey(groups, a.Key)] = a.Value.Resolve().Any()
+		return true
+	})
+	if len(attrs) > 0 {
+		entry["attributes"] = attrs
+	}
+
+	h.mu.Lock()
+	*h.pending = append(*h.pending, entry)
+	full := len(*h.pending) >= h.cfg.BatchSize
+	h.mu.Unlock()
+
+	if full {
+		return h.Flush(ctx)
+	}
+	return nil
+}
+
+// attrKey flattens an attribute key, prefixing it with the groups open at the
+// point it was added.
+func attrKey(groups []string, key string) string {
+	if len(groups) == 0 {
+		return key
+	}
+	return strings.Join(groups, ".") + "." + key
+}
+
+// Flush ships all buffered records immediately.
+func (h *LogpushHandler) Flush(ctx context.Context) error {
+	h.mu.Lock()
+	batch := *h.pending
+	*h.pending = nil
+	h.mu.Unlock()
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range batch {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("cloudflare: failed to encode log entry: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.Endpoint, &buf)
+	if err != nil {
+		return err
+	}
+	if h.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.cfg.Token)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	httpClient := h.cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cloudflare: log shipping returned status %d: %s", resp.StatusCode, body)
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Close flushes any buffered records and stops the background flusher.
+func (h *LogpushHandler) Close() error {
+	close(h.stop)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return h.Flush(ctx)
+}
+
+func (h *LogpushHandler) flushLoop() {
+	ticker := time.NewTicker(h.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := h.Flush(ctx); err != nil {
+				slog.Default().Error("cloudflare: failed to ship logs", "error", err)
+			}
+			cancel()
+		case <-h.stop:
+			return
+		}
+	}
+}
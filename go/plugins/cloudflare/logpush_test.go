@@ -0,0 +1,148 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLogpushHandler returns a handler shipping to an httptest server and
+// a function draining the NDJSON entries received so far.
+func newTestLogpushHandler(t *testing.T, cfg LogpushConfig) (*LogpushHandler, func() []map[string]any) {
+	t.Helper()
+	var mu sync.Mutex
+	var entries []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/x-ndjson", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			entries = append(entries, entry)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Endpoint = srv.URL
+	cfg.Token = "tok"
+	if cfg.FlushInterval == 0 {
+		// Keep the background flusher out of the way; tests flush directly.
+		cfg.FlushInterval = time.Hour
+	}
+	h, err := NewLogpushHandler(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { h.Close() })
+
+	return h, func() []map[string]any {
+		mu.Lock()
+		defer mu.Unlock()
+		drained := entries
+		entries = nil
+		return drained
+	}
+}
+
+func TestNewLogpushHandlerRequiresEndpoint(t *testing.T) {
+	_, err := NewLogpushHandler(LogpushConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires an Endpoint")
+}
+
+func TestLogpushHandlerShipsRecords(t *testing.T) {
+	h, drain := newTestLogpushHandler(t, LogpushConfig{})
+
+	at := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	r := slog.NewRecord(at, slog.LevelInfo, "flow completed", 0)
+	r.AddAttrs(slog.String("flow", "greet"), slog.Int("tokens", 42))
+	require.NoError(t, h.Handle(context.Background(), r))
+
+	// Nothing ships until the batch fills or Flush is called.
+	assert.Empty(t, drain())
+	require.NoError(t, h.Flush(context.Background()))
+
+	entries := drain()
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	assert.Equal(t, at.Format(time.RFC3339Nano), entry["timestamp"])
+	assert.Equal(t, "INFO", entry["level"])
+	assert.Equal(t, "flow completed", entry["message"])
+	assert.Equal(t, "genkit", entry["module"])
+	attrs := entry["attributes"].(map[string]any)
+	assert.Equal(t, "greet", attrs["flow"])
+	assert.Equal(t, 42.0, attrs["tokens"])
+
+	// A drained buffer doesn't re-ship.
+	require.NoError(t, h.Flush(context.Background()))
+	assert.Empty(t, drain())
+}
+
+func TestLogpushHandlerFlushesFullBatches(t *testing.T) {
+	h, drain := newTestLogpushHandler(t, LogpushConfig{BatchSize: 2})
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "one", 0)
+	require.NoError(t, h.Handle(context.Background(), r))
+	assert.Empty(t, drain())
+
+	// The second record fills the batch and ships both.
+	r = slog.NewRecord(time.Now(), slog.LevelInfo, "two", 0)
+	require.NoError(t, h.Handle(context.Background(), r))
+	entries := drain()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "one", entries[0]["message"])
+	assert.Equal(t, "two", entries[1]["message"])
+}
+
+func TestLogpushHandlerFlattensGroups(t *testing.T) {
+	h, drain := newTestLogpushHandler(t, LogpushConfig{})
+
+	grouped := h.WithAttrs([]slog.Attr{slog.String("env", "prod")}).
+		WithGroup("model").
+		WithAttrs([]slog.Attr{slog.String("name", "llama")})
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "generate", 0)
+	require.NoError(t, grouped.Handle(context.Background(), r))
+	require.NoError(t, h.Flush(context.Background()))
+
+	entries := drain()
+	require.Len(t, entries, 1)
+	attrs := entries[0]["attributes"].(map[string]any)
+	assert.Equal(t, "prod", attrs["env"])
+	assert.Equal(t, "llama", attrs["model.name"])
+}
+
+func TestLogpushHandlerLevelFilter(t *testing.T) {
+	h, _ := newTestLogpushHandler(t, LogpushConfig{LogLevel: slog.LevelWarn})
+	assert.False(t, h.Enabled(context.Background(), slog.LevelInfo))
+	assert.True(t, h.Enabled(context.Background(), slog.LevelWarn))
+}
+
+func TestLogpushHandlerSurfacesShippingErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("over capacity"))
+	}))
+	defer srv.Close()
+
+	h, err := NewLogpushHandler(LogpushConfig{Endpoint: srv.URL, FlushInterval: time.Hour})
+	require.NoError(t, err)
+	defer h.Close()
+
+	require.NoError(t, h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "msg", 0)))
+	err = h.Flush(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 503")
+	assert.Contains(t, err.Error(), "over capacity")
+}
@@ -0,0 +1,334 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/pkg/errors"
+)
+
+// OpsToolsConfig configures [Cloudflare.DefineOpsTools].
+type OpsToolsConfig struct {
+	// APIToken authenticates the ops tools. Use a token scoped to exactly
+	// the permissions the tools need (cache purge, Workers read, KV read,
+	// analytics read) rather than the plugin's main token, so an agent
+	// acting on model output cannot exceed that grant. Defaults to the
+	// plugin's token.
+	APIToken string
+	// HTTPClient overrides the client used for API calls. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// opsClient issues the REST calls behind the ops tools.
+type opsClient struct {
+	baseURL    string
+	accountID  string
+	apiToken   string
+	httpClient *http.Client
+}
+
+// DefineOpsTools registers Genkit tools for common Cloudflare account
+// operations — purging zone caches, listing Workers, reading KV keys and
+// querying zone analytics — so ops agents built on Workers AI models can act
+// on the Cloudflare platform. The returned tools are also registered under
+// their names for lookup.
+func (c *Cloudflare) DefineOpsTools(g *genkit.Genkit, cfg *OpsToolsConfig) []ai.Tool {
+	if cfg == nil {
+		cfg = &OpsToolsConfig{}
+	}
+	client := &opsClient{
+		baseURL:    vectorizeBaseURL,
+		accountID:  c.AccountID,
+		apiToken:   cfg.APIToken,
+		httpClient: cfg.HTTPClient,
+	}
+	if client.apiToken == "" {
+		client.apiToken = c.APIToken
+	}
+	if client.httpClient == nil {
+		client.httpClient = http.DefaultClient
+	}
+
+	return []ai.Tool{
+		genkit.DefineTool(g, "cloudflarePurgeCache",
+			"Purges the Cloudflare cache for a zone. Purges only the given URLs when provided, or the entire zone cache when the list is empty.",
+			func(ctx *ai.ToolContext, input purgeCacheInput) (*purgeCacheOutput, error) {
+				return client.purgeCache(ctx.Context, input)
+			}),
+		genkit.DefineTool(g, "cloudflareListWorkers",
+			"Lists the Workers scripts deployed on the Cloudflare account with their last-modified times.",
+			func(ctx *ai.ToolContext, _ struct{}) ([]workerScript, error) {
+				return client.listWorkers(ctx.Context)
+			}),
+		genkit.DefineTool(g, "cloudflareReadKVKey",
+			"Reads the value of a key from a Workers KV namespace.",
+			func(ctx *ai.ToolContext, input readKVInput) (*readKVOutput, error) {
+				return client.readKVKey(ctx.Context, input)
+			}),
+		genkit.DefineTool(g, "cloudflareQueryAnalytics",
+			"Returns aggregate HTTP traffic analytics (requests, bytes, cached requests, threats) for a zone over the past hours.",
+			func(ctx *ai.ToolContext, input queryAnalyticsInput) (*analyticsTotals, error) {
+				return client.queryAnalytics(ctx.Context, input)
+			}),
+	}
+}
+
+type purgeCacheInput struct {
+	ZoneID string   `json:"zoneId" jsonschema_description:"The zone to purge."`
+	URLs   []string `json:"urls,omitempty" jsonschema_description:"Specific URLs to purge; purges everything when empty."`
+}
+
+type purgeCacheOutput struct {
+	PurgeID string `json:"purgeId,omitempty"`
+	Purged  bool   `json:"purged"`
+}
+
+func (c *opsClient) purgeCache(ctx context.Context, input purgeCacheInput) (*purgeCacheOutput, error) {
+	if input.ZoneID == "" {
+		return nil, errors.New("cloudflare: purge cache requires a zone ID")
+	}
+	body := map[string]any{"purge_everything": true}
+	if len(input.URLs) > 0 {
+		body = map[string]any{"files": input.URLs}
+	}
+	result, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/zones/%s/purge_cache", input.ZoneID), body)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(result, &parsed); err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to parse purge response")
+	}
+	return &purgeCacheOutput{PurgeID: parsed.ID, Purged: true}, nil
+}
+
+type workerScript struct {
+	ID         string    `json:"id"`
+	ModifiedOn time.Time `json:"modifiedOn"`
+}
+
+func (c *opsClient) listWorkers(ctx context.Context) ([]workerScript, error) {
+	result, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/accounts/%s/workers/scripts", c.accountID), nil)
+	if err != nil {
+		return nil, err
+	}
+	var parsed []struct {
+		ID         string    `json:"id"`
+		ModifiedOn time.Time `json:"modified_on"`
+	}
+	if err := json.Unmarshal(result, &parsed); err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to parse workers list")
+	}
+	scripts := make([]workerScript, len(parsed))
+	for i, s := range parsed {
+		scripts[i] = workerScript{ID: s.ID, ModifiedOn: s.ModifiedOn}
+	}
+	return scripts, nil
+}
+
+type readKVInput struct {
+	NamespaceID string `json:"namespaceId" jsonschema_description:"The Workers KV namespace to read from."`
+	Key         string `json:"key" jsonschema_description:"The key to read."`
+}
+
+type readKVOutput struct {
+	Value string `json:"value"`
+	Found bool   `json:"found"`
+}
+
+func (c *opsClient) readKVKey(ctx context.Context, input readKVInput) (*readKVOutput, error) {
+	if input.NamespaceID == "" || input.Key == "" {
+		return nil, errors.New("cloudflare: reading a KV key requires a namespace ID and key")
+	}
+	// KV values are returned raw, without the usual response envelope.
+	kvURL := fmt.Sprintf("%s/accounts/%s/storage/kv/namespaces/%s/values/%s",
+		c.baseURL, c.accountID, input.NamespaceID, url.PathEscape(input.Key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, kvURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: KV read failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return &readKVOutput{}, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cloudflare: KV read returned status %d: %s", resp.StatusCode, body)
+	}
+	return &readKVOutput{Value: string(body), Found: true}, nil
+}
+
+type queryAnalyticsInput struct {
+	ZoneID string `json:"zoneId" jsonschema_description:"The zone to query."`
+	Hours  int    `json:"hours,omitempty" jsonschema_description:"How many past hours to aggregate over; defaults to 24."`
+}
+
+// analyticsTotals is the aggregate HTTP traffic for the queried window.
+type analyticsTotals struct {
+	Requests       int64 `json:"requests"`
+	Bytes          int64 `json:"bytes"`
+	CachedRequests int64 `json:"cachedRequests"`
+	Threats        int64 `json:"threats"`
+}
+
+// analyticsQuery aggregates hourly HTTP request groups for one zone via the
+// GraphQL Analytics API.
+const analyticsQuery = `query ($zone: String!, $since: Time!) {
+  viewer {
+    zones(filter: {zoneTag: $zone}) {
+      httpRequests1hGroups(limit: 100, filter: {datetime_geq: $since}) {
+        sum { requests bytes cachedRequests threats }
+      }
+    }
+  }
+}`
+
+func (c *opsClient) queryAnalytics(ctx context.Context, input queryAnalyticsInput) (*analyticsTotals, error) {
+	if input.ZoneID == "" {
+		return nil, errors.New("cloudflare: analytics queries require a zone ID")
+	}
+	hours := input.Hours
+	if hours <= 0 {
+		hours = 24
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"query": analyticsQuery,
+		"variables": map[string]any{
+			"zone":  input.ZoneID,
+			"since": time.Now().Add(-time.Duration(hours) * time.Hour).UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: analytics query failed")
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cloudflare: analytics query returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var parsed struct {
+		Data struct {
+			Viewer struct {
+				Zones []struct {
+					Groups []struct {
+						Sum analyticsTotals `json:"sum"`
+					} `json:"httpRequests1hGroups"`
+				} `json:"zones"`
+			} `json:"viewer"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, errors.Wrap(err, "cloudflare: failed to parse analytics response")
+	}
+	if len(parsed.Errors) > 0 {
+		return nil, fmt.Errorf("cloudflare: analytics query failed: %s", parsed.Errors[0].Message)
+	}
+
+	totals := &analyticsTotals{}
+	for _, zone := range parsed.Data.Viewer.Zones {
+		for _, group := range zone.Groups {
+			totals.Requests += group.Sum.Requests
+			totals.Bytes += group.Sum.Bytes
+			totals.CachedRequests += group.Sum.CachedRequests
+			totals.Threats += group.Sum.Threats
+		}
+	}
+	return totals, nil
+}
+
+// do issues an enveloped API request and returns the raw result payload.
+func (c *opsClient) do(ctx context.Context, method, path string, body any) (json.RawMessage, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: API request failed")
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope struct {
+		Success bool            `json:"success"`
+		Errors  []any           `json:"errors"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return nil, fmt.Errorf("cloudflare: unexpected API response (status %d): %s", resp.StatusCode, respBody)
+	}
+	if !envelope.Success {
+		return nil, fmt.Errorf("cloudflare: API request failed: %v", envelope.Errors)
+	}
+	return envelope.Result, nil
+}
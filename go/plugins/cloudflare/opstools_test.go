@@ -0,0 +1,101 @@
+package cloudflare
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOpsClient(t *testing.T, handler http.HandlerFunc) *opsClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &opsClient{
+		baseURL:    srv.URL,
+		accountID:  "acct",
+		apiToken:   "scoped-token",
+		httpClient: srv.Client(),
+	}
+}
+
+func TestOpsClientPurgeCache(t *testing.T) {
+	var gotBody map[string]any
+	client := testOpsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/zones/zone1/purge_cache", r.URL.Path)
+		assert.Equal(t, "Bearer scoped-token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&gotBody))
+		w.Write([]byte(`{"success":true,"errors":[],"result":{"id":"purge-1"}}`))
+	})
+
+	out, err := client.purgeCache(context.Background(), purgeCacheInput{
+		ZoneID: "zone1",
+		URLs:   []string{"https://example.com/a.css"},
+	})
+	require.NoError(t, err)
+	assert.True(t, out.Purged)
+	assert.Equal(t, "purge-1", out.PurgeID)
+	assert.Equal(t, []any{"https://example.com/a.css"}, gotBody["files"])
+
+	// An empty URL list purges everything.
+	_, err = client.purgeCache(context.Background(), purgeCacheInput{ZoneID: "zone1"})
+	require.NoError(t, err)
+	assert.Equal(t, true, gotBody["purge_everything"])
+}
+
+func TestOpsClientListWorkers(t *testing.T) {
+	client := testOpsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/accounts/acct/workers/scripts", r.URL.Path)
+		w.Write([]byte(`{"success":true,"errors":[],"result":[
+			{"id":"api-proxy","modified_on":"2025-03-01T12:00:00Z"},
+			{"id":"image-resizer","modified_on":"2025-02-01T09:30:00Z"}
+		]}`))
+	})
+
+	scripts, err := client.listWorkers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, scripts, 2)
+	assert.Equal(t, "api-proxy", scripts[0].ID)
+	assert.Equal(t, 2025, scripts[0].ModifiedOn.Year())
+}
+
+func TestOpsClientReadKVKey(t *testing.T) {
+	client := testOpsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/accounts/acct/storage/kv/namespaces/ns1/values/present":
+			w.Write([]byte("the value"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	out, err := client.readKVKey(context.Background(), readKVInput{NamespaceID: "ns1", Key: "present"})
+	require.NoError(t, err)
+	assert.True(t, out.Found)
+	assert.Equal(t, "the value", out.Value)
+
+	out, err = client.readKVKey(context.Background(), readKVInput{NamespaceID: "ns1", Key: "missing"})
+	require.NoError(t, err)
+	assert.False(t, out.Found)
+}
+
+func TestOpsClientQueryAnalytics(t *testing.T) {
+	client := testOpsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		w.Write([]byte(`{"data":{"viewer":{"zones":[{"httpRequests1hGroups":[
+			{"sum":{"requests":100,"bytes":2048,"cachedRequests":60,"threats":1}},
+			{"sum":{"requests":50,"bytes":1024,"cachedRequests":30,"threats":0}}
+		]}]}}}`))
+	})
+
+	totals, err := client.queryAnalytics(context.Background(), queryAnalyticsInput{ZoneID: "zone1"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(150), totals.Requests)
+	assert.Equal(t, int64(3072), totals.Bytes)
+	assert.Equal(t, int64(90), totals.CachedRequests)
+	assert.Equal(t, int64(1), totals.Threats)
+}
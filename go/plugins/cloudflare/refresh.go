@@ -0,0 +1,453 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// SourceFile identifies one file in a document source.
+type SourceFile struct {
+	// Key is the file's path inside the source.
+	Key string
+	// Version is an opaque change marker — an etag, content hash or
+	// modification time. A file is re-indexed when its version differs
+	// from the last indexed one.
+	Version string
+}
+
+// DocumentSource enumerates and fetches the files of a RAG corpus, so the
+// refresh pipeline can watch any backing store. [NewR2Source] provides the
+// R2-backed implementation.
+type DocumentSource interface {
+	// List returns every file currently in the source.
+	List(ctx context.Context) ([]SourceFile, error)
+	// Fetch returns a file's content and its MIME type.
+	Fetch(ctx context.Context, key string) (content []byte, contentType string, err error)
+}
+
+// IndexRefreshConfig configures [Cloudflare.NewIndexRefresher].
+type IndexRefreshConfig struct {
+	// Source supplies the corpus files. Required.
+	Source DocumentSource
+	// Docstore is the Vectorize index the corpus lands in. Required.
+	Docstore *VectorizeDocstore
+	// Namespace to upsert into.
+	Namespace string
+	// Interval is how often [IndexRefresher.Run] re-scans the source. The
+	// default is 15 minutes.
+	Interval time.Duration
+	// ChunkChars is the approximate chunk size in characters. The default
+	// is 2000.
+	ChunkChars int
+	// OnError, when set, receives errors from scheduled refreshes, which
+	// otherwise only surface in the next run's behavior.
+	OnError func(error)
+}
+
+// RefreshStats summarizes one refresh pass.
+type RefreshStats struct {
+	ScannedFiles   int
+	ChangedFiles   int
+	RemovedFiles   int
+	UpsertedChunks int
+	DeletedChunks  int
+}
+
+// indexedFile is what the refresher remembers about an indexed source file.
+type indexedFile struct {
+	version  string
+	chunkIDs []string
+}
+
+// IndexRefresher keeps a Vectorize index in sync with a document source:
+// changed files are re-converted to markdown, re-chunked, re-embedded and
+// upserted; vectors of deleted files are removed. State is held in memory,
+// so a restarted process re-indexes the corpus on its first pass.
+type IndexRefresher struct {
+	cfg       IndexRefreshConfig
+	converter *markdownConverter
+	state     map[string]indexedFile
+}
+
+// NewIndexRefresher creates a refresher. Non-text files are converted with
+// the account's Markdown Conversion endpoint before chunking.
+func (c *Cloudflare) NewIndexRefresher(cfg IndexRefreshConfig) (*IndexRefresher, error) {
+	if cfg.Source == nil || cfg.Docstore == nil {
+		return nil, errors.New("cloudflare: index refresher requires a Source and a Docstore")
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Minute
+	}
+	if cfg.ChunkChars <= 0 {
+		cfg.ChunkChars = 2000
+	}
+	return &IndexRefresher{
+		cfg: cfg,
+		converter: &markdownConverter{
+			baseURL:   vectorizeBaseURL,
+			accountID: c.AccountID,
+			apiToken:  c.APIToken,
+		},
+		state: map[string]indexedFile{},
+	}, nil
+}
+
+// Run refreshes immediately and then on every interval tick until ctx is
+// done. Refresh errors are reported to OnError and do not stop the loop.
+func (r *IndexRefresher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RefreshOnce(ctx); err != nil && r.cfg.OnError != nil {
+			r.cfg.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RefreshOnce runs one refresh pass: unchanged files are skipped by version,
+// changed files are re-indexed, and files gone from the source have their
+// vectors deleted.
+func (r *IndexRefresher) RefreshOnce(ctx context.Context) (*RefreshStats, error) {
+	files, err := r.cfg.Source.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: index refresh listing failed")
+	}
+
+	stats := &RefreshStats{ScannedFiles: len(files)}
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		seen[file.Key] = true
+		prev, indexed := r.state[file.Key]
+		if indexed && prev.version == file.Version {
+			continue
+		}
+		chunkIDs, err := r.indexFile(ctx, file)
+		if err != nil {
+			return stats, errors.Wrapf(err, "cloudflare: failed to refresh %q", file.Key)
+		}
+		stats.ChangedFiles++
+		stats.UpsertedChunks += len(chunkIDs)
+
+		// Drop chunks of the previous version that the new one no longer
+		// produces, then remember the new shape.
+		current := make(map[string]bool, len(chunkIDs))
+		for _, id := range chunkIDs {
+			current[id] = true
+		}
+		var stale []string
+		for _, id := range prev.chunkIDs {
+			if !current[id] {
+				stale = append(stale, id)
+			}
+		}
+		if err := r.cfg.Docstore.client.deleteByIDs(ctx, r.cfg.Docstore.index, stale); err != nil {
+			return stats, err
+		}
+		stats.DeletedChunks += len(stale)
+		r.state[file.Key] = indexedFile{version: file.Version, chunkIDs: chunkIDs}
+	}
+
+	for key, prev := range r.state {
+		if seen[key] {
+			continue
+		}
+		if err := r.cfg.Docstore.client.deleteByIDs(ctx, r.cfg.Docstore.index, prev.chunkIDs); err != nil {
+			return stats, err
+		}
+		stats.RemovedFiles++
+		stats.DeletedChunks += len(prev.chunkIDs)
+		delete(r.state, key)
+	}
+	return stats, nil
+}
+
+// indexFile fetches, converts, chunks and upserts one file, returning the
+// IDs of the upserted chunks.
+func (r *IndexRefresher) indexFile(ctx context.Context, file SourceFile) ([]string, error) {
+	content, contentType, err := r.cfg.Source.Fetch(ctx, file.Key)
+	if err != nil {
+		return nil, err
+	}
+	text := string(content)
+	if !isTextContentType(contentType) {
+		if text, err = r.converter.toMarkdown(ctx, file.Key, content, contentType); err != nil {
+			return nil, err
+		}
+	}
+
+	chunks := chunkText(text, r.cfg.ChunkChars)
+	docs := make([]*ai.Document, 0, len(chunks))
+	ids := make([]string, 0, len(chunks))
+	for i, chunk := range chunks {
+		doc := ai.DocumentFromText(chunk, map[string]any{
+			"sourceKey":  file.Key,
+			"chunkIndex": i,
+		})
+		// The same hashing Index uses, so the refresher can delete exactly
+		// the vectors it wrote.
+		id, err := vectorizeDocID(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+		ids = append(ids, id)
+	}
+	if err := r.cfg.Docstore.Index(ctx, docs, r.cfg.Namespace); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// isTextContentType reports whether content can be chunked as-is, without a
+// markdown conversion pass.
+func isTextContentType(contentType string) bool {
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		mediaType == "application/xml" ||
+		mediaType == ""
+}
+
+// chunkText splits text into chunks of roughly maxChars characters along
+// paragraph boundaries, falling back to hard splits for oversized paragraphs.
+func chunkText(text string, maxChars int) []string {
+	var chunks []string
+	var current strings.Builder
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+	for _, para := range strings.Split(text, "\n\n") {
+		if current.Len() > 0 && current.Len()+len(para) > maxChars {
+			flush()
+		}
+		for len(para) > maxChars {
+			flush()
+			chunks = append(chunks, strings.TrimSpace(para[:maxChars]))
+			para = para[maxChars:]
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(para)
+	}
+	flush()
+	return chunks
+}
+
+// markdownConverter converts binary documents (PDF, DOCX, images, ...) to
+// markdown through the Workers AI Markdown Conversion endpoint.
+type markdownConverter struct {
+	baseURL    string
+	accountID  string
+	apiToken   string
+	httpClient *http.Client
+}
+
+// toMarkdown converts one file's content to markdown.
+func (m *markdownConverter) toMarkdown(ctx context.Context, name string, content []byte, contentType string) (string, error) {
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	part, err := form.CreateFormFile("files", name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := part.Write(content); err != nil {
+		return "", err
+	}
+	if err := form.Close(); err != nil {
+		return "", err
+	}
+
+	u := fmt.Sprintf("%s/accounts/%s/ai/tomarkdown", m.baseURL, m.accountID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, &buf)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.apiToken)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	httpClient := m.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "cloudflare: markdown conversion failed")
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cloudflare: markdown conversion returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var envelope struct {
+		Success bool `json:"success"`
+		Result  []struct {
+			Data string `json:"data"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return "", errors.Wrap(err, "cloudflare: failed to parse markdown conversion response")
+	}
+	if !envelope.Success || len(envelope.Result) == 0 {
+		return "", errors.New("cloudflare: markdown conversion returned no result")
+	}
+	return envelope.Result[0].Data, nil
+}
+
+// R2SourceConfig configures [NewR2Source].
+type R2SourceConfig struct {
+	// AccountID is the Cloudflare account. Required.
+	AccountID string
+	// APIToken authenticates R2 reads. Required.
+	APIToken string
+	// Bucket is the R2 bucket holding the corpus. Required.
+	Bucket string
+	// Prefix restricts the source to keys under it; empty means the whole
+	// bucket.
+	Prefix string
+	// HTTPClient overrides the client used for R2 calls. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// R2Source is a [DocumentSource] over an R2 prefix, using object etags as
+// versions.
+type R2Source struct {
+	cfg     R2SourceConfig
+	baseURL string
+}
+
+// NewR2Source creates the source.
+func NewR2Source(cfg R2SourceConfig) (*R2Source, error) {
+	if cfg.AccountID == "" || cfg.APIToken == "" || cfg.Bucket == "" {
+		return nil, errors.New("cloudflare: R2 source requires AccountID, APIToken and Bucket")
+	}
+	return &R2Source{cfg: cfg, baseURL: vectorizeBaseURL}, nil
+}
+
+// List implements [DocumentSource], following list pagination.
+func (s *R2Source) List(ctx context.Context) ([]SourceFile, error) {
+	var files []SourceFile
+	cursor := ""
+	for {
+		u := fmt.Sprintf("%s/accounts/%s/r2/buckets/%s/objects?prefix=%s",
+			s.baseURL, s.cfg.AccountID, s.cfg.Bucket, url.QueryEscape(s.cfg.Prefix))
+		if cursor != "" {
+			u += "&cursor=" + url.QueryEscape(cursor)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+s.cfg.APIToken)
+
+		resp, err := s.httpClient().Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "cloudflare: failed to list R2 source objects")
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("cloudflare: listing R2 source objects returned status %d: %s", resp.StatusCode, body)
+		}
+
+		var envelope struct {
+			Success bool `json:"success"`
+			Result  []struct {
+				Key  string `json:"key"`
+				Etag string `json:"etag"`
+			} `json:"result"`
+			ResultInfo struct {
+				Cursor      string `json:"cursor"`
+				IsTruncated bool   `json:"is_truncated"`
+			} `json:"result_info"`
+		}
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return nil, errors.Wrap(err, "cloudflare: failed to parse R2 source listing")
+		}
+		if !envelope.Success {
+			return nil, errors.New("cloudflare: listing R2 source objects returned an error")
+		}
+		for _, obj := range envelope.Result {
+			files = append(files, SourceFile{Key: obj.Key, Version: obj.Etag})
+		}
+		if !envelope.ResultInfo.IsTruncated || envelope.ResultInfo.Cursor == "" {
+			break
+		}
+		cursor = envelope.ResultInfo.Cursor
+	}
+	return files, nil
+}
+
+// Fetch implements [DocumentSource].
+func (s *R2Source) Fetch(ctx context.Context, key string) ([]byte, string, error) {
+	u := fmt.Sprintf("%s/accounts/%s/r2/buckets/%s/objects/%s",
+		s.baseURL, s.cfg.AccountID, s.cfg.Bucket, url.PathEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.cfg.APIToken)
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "cloudflare: failed to fetch R2 object %q", key)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("cloudflare: fetching R2 object %q returned status %d: %s", key, resp.StatusCode, body)
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+func (s *R2Source) httpClient() *http.Client {
+	if s.cfg.HTTPClient != nil {
+		return s.cfg.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,117 @@
+package cloudflare
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memorySource is an in-memory DocumentSource.
+type memorySource struct {
+	files map[string]string // key -> content; version is a hash of content
+}
+
+func (s *memorySource) List(ctx context.Context) ([]SourceFile, error) {
+	var files []SourceFile
+	for key, content := range s.files {
+		files = append(files, SourceFile{Key: key, Version: content})
+	}
+	return files, nil
+}
+
+func (s *memorySource) Fetch(ctx context.Context, key string) ([]byte, string, error) {
+	return []byte(s.files[key]), "text/markdown", nil
+}
+
+func TestIndexRefresher(t *testing.T) {
+	var upserted, deleted []string
+	ds := testDocstore(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/upsert"):
+			body, _ := io.ReadAll(r.Body)
+			scanner := bufio.NewScanner(bytes.NewReader(body))
+			for scanner.Scan() {
+				var v vectorizeVector
+				require.NoError(t, json.Unmarshal(scanner.Bytes(), &v))
+				upserted = append(upserted, v.ID)
+			}
+		case strings.HasSuffix(r.URL.Path, "/delete_by_ids"):
+			var req struct {
+				IDs []string `json:"ids"`
+			}
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &req))
+			deleted = append(deleted, req.IDs...)
+		}
+		w.Write([]byte(`{"success":true,"errors":[],"result":{}}`))
+	})
+
+	source := &memorySource{files: map[string]string{
+		"a.md": "alpha doc",
+		"b.md": "beta doc",
+	}}
+	r := &IndexRefresher{
+		cfg:   IndexRefreshConfig{Source: source, Docstore: ds, ChunkChars: 2000},
+		state: map[string]indexedFile{},
+	}
+
+	// First pass indexes everything.
+	stats, err := r.RefreshOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.ScannedFiles)
+	assert.Equal(t, 2, stats.ChangedFiles)
+	assert.Equal(t, 2, stats.UpsertedChunks)
+	assert.Len(t, upserted, 2)
+
+	// An unchanged corpus is a no-op.
+	upserted = nil
+	stats, err = r.RefreshOnce(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, stats.ChangedFiles)
+	assert.Empty(t, upserted)
+
+	// A changed file is re-indexed and its stale chunk removed; a deleted
+	// file has its chunks removed.
+	aChunks := r.state["a.md"].chunkIDs
+	source.files["a.md"] = "alpha doc, revised"
+	delete(source.files, "b.md")
+	bChunks := r.state["b.md"].chunkIDs
+
+	stats, err = r.RefreshOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.ChangedFiles)
+	assert.Equal(t, 1, stats.RemovedFiles)
+	assert.Equal(t, 2, stats.DeletedChunks)
+	assert.ElementsMatch(t, append(aChunks, bChunks...), deleted)
+	assert.NotContains(t, r.state, "b.md")
+}
+
+func TestChunkText(t *testing.T) {
+	t.Run("splits on paragraphs", func(t *testing.T) {
+		text := strings.Repeat("x", 30) + "\n\n" + strings.Repeat("y", 30) + "\n\n" + strings.Repeat("z", 30)
+		chunks := chunkText(text, 70)
+		require.Len(t, chunks, 2)
+		assert.Contains(t, chunks[0], "x")
+		assert.Contains(t, chunks[0], "y")
+		assert.Contains(t, chunks[1], "z")
+	})
+
+	t.Run("hard-splits oversized paragraphs", func(t *testing.T) {
+		chunks := chunkText(strings.Repeat("a", 250), 100)
+		require.Len(t, chunks, 3)
+		assert.Len(t, chunks[0], 100)
+		assert.Len(t, chunks[2], 50)
+	})
+
+	t.Run("empty input yields no chunks", func(t *testing.T) {
+		assert.Empty(t, chunkText("  \n\n ", 100))
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/pkg/errors"
+)
+
+// RerankConfig tunes the reranking stage of a composed retriever.
+type RerankConfig struct {
+	// Model is the cross-encoder model used for reranking. Defaults to
+	// bge-reranker-base.
+	Model string
+	// OverFetchFactor is how many times the requested K is fetched from
+	// Vectorize before reranking. The default is 3.
+	OverFetchFactor int
+}
+
+// DefineRerankingRetriever defines a retriever that over-fetches from a
+// Vectorize index, reranks the candidates with a cross-encoder and returns
+// the top K, all as one Genkit retriever action. The retriever is registered
+// under "<index>-reranked" and takes the same options as the plain Vectorize
+// retriever.
+func (c *Cloudflare) DefineRerankingRetriever(g *genkit.Genkit, cfg VectorizeConfig, rerank *RerankConfig, opts *ai.RetrieverOptions) (ai.Retriever, error) {
+	if c.workersAI == nil {
+		return nil, errors.New("cloudflare: plugin not initialized")
+	}
+	model := ""
+	overFetch := 3
+	if rerank != nil {
+		model = rerank.Model
+		if rerank.OverFetchFactor > 0 {
+			overFetch = rerank.OverFetchFactor
+		}
+	}
+
+	if cfg.TextKey == "" {
+		cfg.TextKey = defaultTextKey
+	}
+	ds := &VectorizeDocstore{
+		client: &vectorizeClient{
+			baseURL:   vectorizeBaseURL,
+			accountID: c.AccountID,
+			apiToken:  c.APIToken,
+		},
+		index:           cfg.IndexName,
+		embedder:        cfg.Embedder,
+		embedderOptions: cfg.EmbedderOptions,
+		textKey:         cfg.TextKey,
+	}
+
+	retrieve := func(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+		rerankFn := func(ctx context.Context, query string, texts []string) ([]float64, error) {
+			return c.workersAI.Rerank(ctx, model, query, texts)
+		}
+		return rerankedRetrieve(ctx, req, overFetch, ds.Retrieve, rerankFn)
+	}
+
+	return genkit.DefineRetriever(g, provider, cfg.IndexName+"-reranked", opts, retrieve), nil
+}
+
+// rerankedRetrieve over-fetches candidates with fetch, scores them with
+// rerank and returns the requested K best, best first.
+func rerankedRetrieve(ctx context.Context, req *ai.RetrieverRequest, overFetch int,
+	fetch func(context.Context, *ai.RetrieverRequest) (*ai.RetrieverResponse, error),
+	rerank func(context.Context, string, []string) ([]float64, error)) (*ai.RetrieverResponse, error) {
+	k := 0
+	fetchReq := *req
+	if req.Options != nil {
+		typed, ok := req.Options.(*VectorizeRetrieverOptions)
+		if !ok {
+			return nil, errors.Errorf("cloudflare: Vectorize retriever options have type %T, want %T", req.Options, &VectorizeRetrieverOptions{})
+		}
+		k = typed.K
+		fetched := *typed
+		if k > 0 {
+			fetched.K = k * overFetch
+		}
+		fetchReq.Options = &fetched
+	}
+
+	resp, err := fetch(ctx, &fetchReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Documents) == 0 {
+		return resp, nil
+	}
+
+	texts := make([]string, len(resp.Documents))
+	for i, doc := range resp.Documents {
+		var sb strings.Builder
+		for _, p := range doc.Content {
+			sb.WriteString(p.Text)
+		}
+		texts[i] = sb.String()
+	}
+	scores, err := rerank(ctx, queryText(req.Query), texts)
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: reranking failed")
+	}
+
+	type scored struct {
+		doc   *ai.Document
+		score float64
+	}
+	ranked := make([]scored, len(resp.Documents))
+	for i, doc := range resp.Documents {
+		ranked[i] = scored{doc: doc, score: scores[i]}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+	if k > 0 && len(ranked) > k {
+		ranked = ranked[:k]
+	}
+
+	docs := make([]*ai.Document, len(ranked))
+	for i, r := range ranked {
+		docs[i] = r.doc
+	}
+	return &ai.RetrieverResponse{Documents: docs}, nil
+}
+
+// queryText flattens the query document into plain text for the reranker.
+func queryText(doc *ai.Document) string {
+	var sb strings.Builder
+	for _, p := range doc.Content {
+		sb.WriteString(p.Text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,135 @@
+package cloudflare
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// docTexts flattens the response documents into their text contents.
+func docTexts(resp *ai.RetrieverResponse) []string {
+	texts := make([]string, len(resp.Documents))
+	for i, doc := range resp.Documents {
+		texts[i] = doc.Content[0].Text
+	}
+	return texts
+}
+
+func TestRerankedRetrieve(t *testing.T) {
+	var fetchedK int
+	fetch := func(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+		fetchedK = req.Options.(*VectorizeRetrieverOptions).K
+		return &ai.RetrieverResponse{Documents: []*ai.Document{
+			ai.DocumentFromText("apples", nil),
+			ai.DocumentFromText("bananas", nil),
+			ai.DocumentFromText("cherries", nil),
+			ai.DocumentFromText("dates", nil),
+		}}, nil
+	}
+	scores := map[string]float64{"apples": 0.2, "bananas": 0.9, "cherries": 0.7, "dates": 0.4}
+	rerank := func(ctx context.Context, query string, texts []string) ([]float64, error) {
+		assert.Equal(t, "fruit", query)
+		out := make([]float64, len(texts))
+		for i, text := range texts {
+			out[i] = scores[text]
+		}
+		return out, nil
+	}
+
+	req := &ai.RetrieverRequest{
+		Query:   ai.DocumentFromText("fruit", nil),
+		Options: &VectorizeRetrieverOptions{K: 2},
+	}
+	resp, err := rerankedRetrieve(context.Background(), req, 3, fetch, rerank)
+	require.NoError(t, err)
+
+	// The fetch stage over-fetches K*3 candidates; the caller's options are
+	// left untouched.
+	assert.Equal(t, 6, fetchedK)
+	assert.Equal(t, 2, req.Options.(*VectorizeRetrieverOptions).K)
+
+	// The top K by rerank score come back best first.
+	assert.Equal(t, []string{"bananas", "cherries"}, docTexts(resp))
+}
+
+func TestRerankedRetrieveWithoutK(t *testing.T) {
+	fetch := func(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+		assert.Nil(t, req.Options)
+		return &ai.RetrieverResponse{Documents: []*ai.Document{
+			ai.DocumentFromText("low", nil),
+			ai.DocumentFromText("high", nil),
+		}}, nil
+	}
+	rerank := func(ctx context.Context, query string, texts []string) ([]float64, error) {
+		return []float64{0.1, 0.8}, nil
+	}
+
+	req := &ai.RetrieverRequest{Query: ai.DocumentFromText("q", nil)}
+	resp, err := rerankedRetrieve(context.Background(), req, 3, fetch, rerank)
+	require.NoError(t, err)
+
+	// Without a requested K everything is reordered but nothing is dropped.
+	assert.Equal(t, []string{"high", "low"}, docTexts(resp))
+}
+
+func TestRerankedRetrieveKeepsFetchOrderOnTies(t *testing.T) {
+	fetch := func(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+		return &ai.RetrieverResponse{Documents: []*ai.Document{
+			ai.DocumentFromText("first", nil),
+			ai.DocumentFromText("second", nil),
+		}}, nil
+	}
+	rerank := func(ctx context.Context, query string, texts []string) ([]float64, error) {
+		return []float64{0.5, 0.5}, nil
+	}
+
+	req := &ai.RetrieverRequest{Query: ai.DocumentFromText("q", nil)}
+	resp, err := rerankedRetrieve(context.Background(), req, 3, fetch, rerank)
+	require.NoError(t, err)
+	// Ties fall back to the vector store's ranking.
+	assert.Equal(t, []string{"first", "second"}, docTexts(resp))
+}
+
+func TestRerankedRetrieveNoDocuments(t *testing.T) {
+	fetch := func(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+		return &ai.RetrieverResponse{}, nil
+	}
+	rerank := func(ctx context.Context, query string, texts []string) ([]float64, error) {
+		t.Fatal("rerank should not be called without candidates")
+		return nil, nil
+	}
+
+	req := &ai.RetrieverRequest{Query: ai.DocumentFromText("q", nil)}
+	resp, err := rerankedRetrieve(context.Background(), req, 3, fetch, rerank)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Documents)
+}
+
+func TestRerankedRetrieveRejectsForeignOptions(t *testing.T) {
+	req := &ai.RetrieverRequest{
+		Query:   ai.DocumentFromText("q", nil),
+		Options: "not options",
+	}
+	_, err := rerankedRetrieve(context.Background(), req, 3, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retriever options have type string")
+}
+
+func TestRerankedRetrieveSurfacesRerankErrors(t *testing.T) {
+	fetch := func(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+		return &ai.RetrieverResponse{Documents: []*ai.Document{ai.DocumentFromText("doc", nil)}}, nil
+	}
+	rerank := func(ctx context.Context, query string, texts []string) ([]float64, error) {
+		return nil, errors.New("model offline")
+	}
+
+	req := &ai.RetrieverRequest{Query: ai.DocumentFromText("q", nil)}
+	_, err := rerankedRetrieve(context.Background(), req, 3, fetch, rerank)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reranking failed")
+	assert.Contains(t, err.Error(), "model offline")
+}
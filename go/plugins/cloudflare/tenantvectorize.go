@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/pkg/errors"
+)
+
+// tenantMetadataKey is the metadata key tenant-scoped writes stamp on every
+// vector, so tenant ownership survives even when vectors are inspected or
+// exported outside their namespace.
+const tenantMetadataKey = "_tenant"
+
+// TenantDocstore is a [VectorizeDocstore] bound to one tenant. Every write
+// lands in the tenant's Vectorize namespace and every query is pinned to it,
+// so documents from different tenants sharing an index can never leak into
+// each other's retrievals.
+type TenantDocstore struct {
+	ds     *VectorizeDocstore
+	tenant string
+}
+
+// DefineTenantVectorizeRetriever defines a retriever over a shared Vectorize
+// index scoped to a single tenant, registered as "<index>/<tenant>". Per-call
+// namespace options are rejected rather than honored: the tenant boundary is
+// enforced here, not left to every call site.
+func (c *Cloudflare) DefineTenantVectorizeRetriever(g *genkit.Genkit, tenant string, cfg VectorizeConfig, opts *ai.RetrieverOptions) (*TenantDocstore, ai.Retriever, error) {
+	if tenant == "" {
+		return nil, nil, errors.New("cloudflare: tenant-scoped Vectorize retriever requires a tenant ID")
+	}
+	if cfg.IndexName == "" {
+		return nil, nil, errors.New("cloudflare: Vectorize retriever requires an IndexName")
+	}
+	if cfg.Embedder == nil {
+		return nil, nil, errors.New("cloudflare: Vectorize retriever requires an Embedder")
+	}
+	if cfg.TextKey == "" {
+		cfg.TextKey = defaultTextKey
+	}
+
+	tds := &TenantDocstore{
+		ds: &VectorizeDocstore{
+			client: &vectorizeClient{
+				baseURL:   vectorizeBaseURL,
+				accountID: c.AccountID,
+				apiToken:  c.APIToken,
+			},
+			index:           cfg.IndexName,
+			embedder:        cfg.Embedder,
+			embedderOptions: cfg.EmbedderOptions,
+			textKey:         cfg.TextKey,
+		},
+		tenant: tenant,
+	}
+	name := cfg.IndexName + "/" + tenant
+	return tds, genkit.DefineRetriever(g, provider, name, opts, tds.Retrieve), nil
+}
+
+// Retrieve implements the genkit Retriever.Retrieve method, pinned to the
+// tenant's namespace. Options naming another namespace are an error, so a
+// request assembled from untrusted input cannot query across tenants.
+func (t *TenantDocstore) Retrieve(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+	var ropts VectorizeRetrieverOptions
+	if req.Options != nil {
+		typed, ok := req.Options.(*VectorizeRetrieverOptions)
+		if !ok {
+			return nil, fmt.Errorf("cloudflare: Vectorize retriever options have type %T, want %T", req.Options, &VectorizeRetrieverOptions{})
+		}
+		ropts = *typed
+	}
+	if ropts.Namespace != "" && ropts.Namespace != t.tenant {
+		return nil, fmt.Errorf("cloudflare: retriever is scoped to tenant %q, refusing cross-tenant query for namespace %q", t.tenant, ropts.Namespace)
+	}
+	ropts.Namespace = t.tenant
+
+	scoped := *req
+	scoped.Options = &ropts
+	return t.ds.Retrieve(ctx, &scoped)
+}
+
+// Index embeds docs and upserts them into the tenant's namespace, stamping
+// each vector's metadata with the tenant ID.
+func (t *TenantDocstore) Index(ctx context.Context, docs []*ai.Document) error {
+	stamped := make([]*ai.Document, 0, len(docs))
+	for _, doc := range docs {
+		metadata := make(map[string]any, len(doc.Metadata)+1)
+		for k, v := range doc.Metadata {
+			metadata[k] = v
+		}
+		// The stamp wins over any caller-supplied value: tenant ownership
+		// is not negotiable per document.
+		metadata[tenantMetadataKey] = t.tenant
+		stamped = append(stamped, &ai.Document{Content: doc.Content, Metadata: metadata})
+	}
+	return t.ds.Index(ctx, stamped, t.tenant)
+}
+
+// Tenant returns the tenant ID the docstore is bound to.
+func (t *TenantDocstore) Tenant() string {
+	return t.tenant
+}
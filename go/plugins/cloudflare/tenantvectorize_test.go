@@ -0,0 +1,89 @@
+package cloudflare
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTenantDocstore builds a tenant-scoped docstore over a fake Vectorize
+// server.
+func testTenantDocstore(t *testing.T, tenant string, handler http.HandlerFunc) *TenantDocstore {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &TenantDocstore{
+		ds: &VectorizeDocstore{
+			client: &vectorizeClient{
+				baseURL:   srv.URL,
+				accountID: "acct",
+				apiToken:  "token",
+			},
+			index:    "idx",
+			embedder: fakeEmbedder{},
+			textKey:  defaultTextKey,
+		},
+		tenant: tenant,
+	}
+}
+
+func TestTenantDocstorePinsQueryNamespace(t *testing.T) {
+	var queryBody map[string]any
+	tds := testTenantDocstore(t, "acme", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.NoError(t, json.Unmarshal(body, &queryBody))
+		w.Write([]byte(`{"success":true,"errors":[],"result":{"matches":[
+			{"id":"a","score":0.9,"metadata":{"_content":"hit","_tenant":"acme"}}
+		]}}`))
+	})
+
+	resp, err := tds.Retrieve(context.Background(), &ai.RetrieverRequest{
+		Query:   ai.DocumentFromText("q", nil),
+		Options: &VectorizeRetrieverOptions{K: 3},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Documents, 1)
+	assert.Equal(t, "acme", queryBody["namespace"])
+
+	// Asking for another tenant's namespace is refused outright.
+	_, err = tds.Retrieve(context.Background(), &ai.RetrieverRequest{
+		Query:   ai.DocumentFromText("q", nil),
+		Options: &VectorizeRetrieverOptions{Namespace: "rival"},
+	})
+	require.ErrorContains(t, err, "refusing cross-tenant query")
+}
+
+func TestTenantDocstoreIndexStampsTenant(t *testing.T) {
+	var upserted []vectorizeVector
+	tds := testTenantDocstore(t, "acme", func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, strings.HasSuffix(r.URL.Path, "/upsert"))
+		body, _ := io.ReadAll(r.Body)
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		for scanner.Scan() {
+			var v vectorizeVector
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &v))
+			upserted = append(upserted, v)
+		}
+		w.Write([]byte(`{"success":true,"errors":[],"result":{}}`))
+	})
+
+	docs := []*ai.Document{
+		ai.DocumentFromText("hello", map[string]any{"kind": "faq", "_tenant": "spoofed"}),
+	}
+	require.NoError(t, tds.Index(context.Background(), docs))
+
+	require.Len(t, upserted, 1)
+	assert.Equal(t, "acme", upserted[0].Namespace)
+	assert.Equal(t, "acme", upserted[0].Metadata["_tenant"])
+	assert.Equal(t, "faq", upserted[0].Metadata["kind"])
+}
@@ -0,0 +1,381 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/pkg/errors"
+)
+
+// vectorizeBaseURL is the Cloudflare REST API base used for Vectorize calls.
+const vectorizeBaseURL = "https://api.cloudflare.com/client/v4"
+
+// defaultTextKey is the metadata key used to store document text in
+// Vectorize, so query matches can be mapped back to documents.
+const defaultTextKey = "_content"
+
+// vectorizeFilterOps are the operators Vectorize metadata filter expressions
+// accept.
+var vectorizeFilterOps = map[string]bool{
+	"$eq": true, "$ne": true,
+	"$in": true, "$nin": true,
+	"$lt": true, "$lte": true,
+	"$gt": true, "$gte": true,
+}
+
+// VectorizeConfig configures [Cloudflare.DefineVectorizeRetriever].
+type VectorizeConfig struct {
+	// IndexName is the Vectorize index to query. Required.
+	IndexName string
+	// Embedder converts documents and queries to vectors. Required.
+	Embedder ai.Embedder
+	// EmbedderOptions are passed to the embedder on every call.
+	EmbedderOptions any
+	// TextKey is the metadata key document text is stored under; the
+	// default is "_content".
+	TextKey string
+}
+
+// VectorizeRetrieverOptions are the per-request options of a Vectorize
+// retriever.
+type VectorizeRetrieverOptions struct {
+	// K is the number of documents to return.
+	K int `json:"k,omitempty"`
+	// Namespace scopes the query to one namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// Filter is a Vectorize metadata filter expression applied server-side.
+	// Values are matched by equality ({"tenant": "acme"}) or with operator
+	// maps ({"year": {"$gte": 2020}}, {"kind": {"$in": ["a", "b"]}}), so
+	// multi-tenant RAG can scope queries without post-filtering.
+	Filter map[string]any `json:"filter,omitempty"`
+	// MinScore drops matches whose raw similarity score is below the
+	// threshold, so weak matches don't end up as prompt context.
+	MinScore float64 `json:"minScore,omitempty"`
+}
+
+// DefineVectorizeRetriever defines a retriever backed by a Vectorize index.
+func (c *Cloudflare) DefineVectorizeRetriever(g *genkit.Genkit, cfg VectorizeConfig, opts *ai.RetrieverOptions) (*VectorizeDocstore, ai.Retriever, error) {
+	if cfg.IndexName == "" {
+		return nil, nil, errors.New("cloudflare: Vectorize retriever requires an IndexName")
+	}
+	if cfg.Embedder == nil {
+		return nil, nil, errors.New("cloudflare: Vectorize retriever requires an Embedder")
+	}
+	if cfg.TextKey == "" {
+		cfg.TextKey = defaultTextKey
+	}
+
+	ds := &VectorizeDocstore{
+		client: &vectorizeClient{
+			baseURL:   vectorizeBaseURL,
+			accountID: c.AccountID,
+			apiToken:  c.APIToken,
+		},
+		index:           cfg.IndexName,
+		embedder:        cfg.Embedder,
+		embedderOptions: cfg.EmbedderOptions,
+		textKey:         cfg.TextKey,
+	}
+	return ds, genkit.DefineRetriever(g, provider, cfg.IndexName, opts, ds.Retrieve), nil
+}
+
+// VectorizeRetriever returns the retriever for the given index name.
+func VectorizeRetriever(g *genkit.Genkit, indexName string) ai.Retriever {
+	return genkit.LookupRetriever(g, provider, indexName)
+}
+
+// VectorizeDocstore indexes documents into and retrieves them from one
+// Vectorize index.
+type VectorizeDocstore struct {
+	client          *vectorizeClient
+	index           string
+	embedder        ai.Embedder
+	embedderOptions any
+	textKey         string
+}
+
+// Retrieve implements the genkit Retriever.Retrieve method.
+func (ds *VectorizeDocstore) Retrieve(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+	var ropts VectorizeRetrieverOptions
+	if req.Options != nil {
+		typed, ok := req.Options.(*VectorizeRetrieverOptions)
+		if !ok {
+			return nil, fmt.Errorf("cloudflare: Vectorize retriever options have type %T, want %T", req.Options, &VectorizeRetrieverOptions{})
+		}
+		ropts = *typed
+	}
+	if err := validateVectorizeFilter(ropts.Filter); err != nil {
+		return nil, err
+	}
+
+	eres, err := ds.embedder.Embed(ctx, &ai.EmbedRequest{
+		Input:   []*ai.Document{req.Query},
+		Options: ds.embedderOptions,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cloudflare: Vectorize query embedding failed")
+	}
+
+	matches, err := ds.client.query(ctx, ds.index, eres.Embeddings[0].Embedding, ropts)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*ai.Document
+	for _, match := range matches {
+		if match.Score < ropts.MinScore {
+			continue
+		}
+		text, _ := match.Metadata[ds.textKey].(string)
+		if text == "" {
+			return nil, errors.New("cloudflare: Vectorize match is missing the stored document text")
+		}
+		delete(match.Metadata, ds.textKey)
+		match.Metadata["score"] = match.Score
+		docs = append(docs, ai.DocumentFromText(text, match.Metadata))
+	}
+	normalizeScores(docs, matches)
+	return &ai.RetrieverResponse{Documents: docs}, nil
+}
+
+// normalizeScores min-max scales the surviving matches' scores into [0, 1]
+// and records them under "normalizedScore" next to the raw "score" metadata,
+// so flows can threshold consistently across metrics and models.
+func normalizeScores(docs []*ai.Document, matches []vectorizeMatch) {
+	if len(docs) == 0 {
+		return
+	}
+	minScore, maxScore := matches[0].Score, matches[0].Score
+	for _, m := range matches {
+		minScore = math.Min(minScore, m.Score)
+		maxScore = math.Max(maxScore, m.Score)
+	}
+	for _, doc := range docs {
+		raw := doc.Metadata["score"].(float64)
+		if maxScore == minScore {
+			doc.Metadata["normalizedScore"] = 1.0
+			continue
+		}
+		doc.Metadata["normalizedScore"] = (raw - minScore) / (maxScore - minScore)
+	}
+}
+
+// Index embeds docs and upserts them into the index under the given
+// namespace. Document metadata is stored alongside the vectors so it can be
+// filtered on at query time.
+func (ds *VectorizeDocstore) Index(ctx context.Context, docs []*ai.Document, namespace string) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	eres, err := ds.embedder.Embed(ctx, &ai.EmbedRequest{
+		Input:   docs,
+		Options: ds.embedderOptions,
+	})
+	if err != nil {
+		return errors.Wrap(err, "cloudflare: Vectorize index embedding failed")
+	}
+
+	vecs := make([]vectorizeVector, 0, len(docs))
+	for i, emb := range eres.Embeddings {
+		doc := docs[i]
+		id, err := vectorizeDocID(doc)
+		if err != nil {
+			return err
+		}
+		metadata := map[string]any{}
+		for k, v := range doc.Metadata {
+			metadata[k] = v
+		}
+		var sb strings.Builder
+		for _, p := range doc.Content {
+			sb.WriteString(p.Text)
+		}
+		metadata[ds.textKey] = sb.String()
+
+		vecs = append(vecs, vectorizeVector{
+			ID:        id,
+			Values:    emb.Embedding,
+			Namespace: namespace,
+			Metadata:  metadata,
+		})
+	}
+	return ds.client.upsert(ctx, ds.index, vecs)
+}
+
+// validateVectorizeFilter rejects filter expressions with operators
+// Vectorize doesn't support, so typos fail locally instead of as opaque API
+// errors.
+func validateVectorizeFilter(filter map[string]any) error {
+	for field, expr := range filter {
+		ops, ok := expr.(map[string]any)
+		if !ok {
+			continue // bare value: equality match
+		}
+		for op := range ops {
+			if !vectorizeFilterOps[op] {
+				return fmt.Errorf("cloudflare: unsupported Vectorize filter operator %q on field %q", op, field)
+			}
+		}
+	}
+	return nil
+}
+
+// vectorizeDocID returns a stable ID for a document, matching the hashing
+// used by the other vector store plugins.
+func vectorizeDocID(doc *ai.Document) (string, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", errors.Wrap(err, "cloudflare: error marshaling document")
+	}
+	return fmt.Sprintf("%02x", md5.Sum(b)), nil
+}
+
+// vectorizeVector is one vector in Vectorize's write format.
+type vectorizeVector struct {
+	ID        string         `json:"id"`
+	Values    []float32      `json:"values"`
+	Namespace string         `json:"namespace,omitempty"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// vectorizeMatch is one query result.
+type vectorizeMatch struct {
+	ID       string         `json:"id"`
+	Score    float64        `json:"score"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// vectorizeClient issues Vectorize REST calls.
+type vectorizeClient struct {
+	baseURL    string
+	accountID  string
+	apiToken   string
+	httpClient *http.Client
+}
+
+func (c *vectorizeClient) query(ctx context.Context, index string, vector []float32, opts VectorizeRetrieverOptions) ([]vectorizeMatch, error) {
+	body := map[string]any{
+		"vector":         vector,
+		"returnMetadata": "all",
+	}
+	if opts.K > 0 {
+		body["topK"] = opts.K
+	}
+	if opts.Namespace != "" {
+		body["namespace"] = opts.Namespace
+	}
+	if len(opts.Filter) > 0 {
+		body["filter"] = opts.Filter
+	}
+
+	var result struct {
+		Matches []vectorizeMatch `json:"matches"`
+	}
+	if err := c.do(ctx, index, "query", "application/json", jsonBody(body), &result); err != nil {
+		return nil, err
+	}
+	return result.Matches, nil
+}
+
+func (c *vectorizeClient) upsert(ctx context.Context, index string, vecs []vectorizeVector) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, v := range vecs {
+		if err := enc.Encode(v); err != nil {
+			return errors.Wrap(err, "cloudflare: failed to encode vector")
+		}
+	}
+	return c.do(ctx, index, "upsert", "application/x-ndjson", &buf, nil)
+}
+
+func (c *vectorizeClient) deleteByIDs(ctx context.Context, index string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return c.do(ctx, index, "delete_by_ids", "application/json", jsonBody(map[string]any{"ids": ids}), nil)
+}
+
+// jsonBody marshals v for use as a request body; marshal errors surface as
+// read errors from the request.
+func jsonBody(v any) io.Reader {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return &errReader{err: err}
+	}
+	return bytes.NewReader(b)
+}
+
+type errReader struct{ err error }
+
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// do issues one Vectorize API call and decodes the result envelope.
+func (c *vectorizeClient) do(ctx context.Context, index, op, contentType string, body io.Reader, result any) error {
+	url := fmt.Sprintf("%s/accounts/%s/vectorize/v2/indexes/%s/%s", c.baseURL, c.accountID, index, op)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiToken)
+	req.Header.Set("Content-Type", contentType)
+
+	httpClient := c.httpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "cloudflare: Vectorize %s failed", op)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "cloudflare: failed to read Vectorize %s response", op)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cloudflare: Vectorize %s returned status %d: %s", op, resp.StatusCode, respBody)
+	}
+
+	var envelope struct {
+		Success bool            `json:"success"`
+		Errors  []any           `json:"errors"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &envelope); err != nil {
+		return errors.Wrapf(err, "cloudflare: failed to parse Vectorize %s response", op)
+	}
+	if !envelope.Success {
+		return fmt.Errorf("cloudflare: Vectorize %s returned an error: %v", op, envelope.Errors)
+	}
+	if result != nil {
+		if err := json.Unmarshal(envelope.Result, result); err != nil {
+			return errors.Wrapf(err, "cloudflare: failed to parse Vectorize %s result", op)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cloudflare
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateVectorizeFilter(t *testing.T) {
+	testCases := []struct {
+		name          string
+		filter        map[string]any
+		expectErr     bool
+		errorContains string
+	}{
+		{
+			name:   "nil filter is valid",
+			filter: nil,
+		},
+		{
+			name:   "equality match",
+			filter: map[string]any{"tenant": "acme"},
+		},
+		{
+			name: "range and in-list operators",
+			filter: map[string]any{
+				"year": map[string]any{"$gte": 2020, "$lt": 2025},
+				"kind": map[string]any{"$in": []any{"doc", "faq"}},
+			},
+		},
+		{
+			name:          "unknown operator is rejected",
+			filter:        map[string]any{"year": map[string]any{"$between": []any{1, 2}}},
+			expectErr:     true,
+			errorContains: `unsupported Vectorize filter operator "$between"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateVectorizeFilter(tc.filter)
+			if tc.expectErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorContains)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRetrieveScoresAndThreshold(t *testing.T) {
+	ds := testDocstore(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"success":true,"errors":[],"result":{"matches":[
+			{"id":"a","score":0.9,"metadata":{"_content":"best"}},
+			{"id":"b","score":0.5,"metadata":{"_content":"middling"}},
+			{"id":"c","score":0.2,"metadata":{"_content":"weak"}}
+		]}}`))
+	})
+
+	resp, err := ds.Retrieve(context.Background(), &ai.RetrieverRequest{
+		Query:   ai.DocumentFromText("q", nil),
+		Options: &VectorizeRetrieverOptions{MinScore: 0.4},
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Documents, 2)
+
+	best := resp.Documents[0]
+	assert.Equal(t, "best", best.Content[0].Text)
+	assert.Equal(t, 0.9, best.Metadata["score"])
+	assert.Equal(t, 1.0, best.Metadata["normalizedScore"])
+
+	mid := resp.Documents[1]
+	assert.Equal(t, 0.5, mid.Metadata["score"])
+	assert.InDelta(t, (0.5-0.2)/(0.9-0.2), mid.Metadata["normalizedScore"].(float64), 1e-9)
+}
@@ -0,0 +1,163 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// AgentOptions configures [RunAgent].
+type AgentOptions struct {
+	// Tools are the runnable tools offered to the model.
+	Tools []ai.Tool
+	// MaxSteps bounds how many model calls the loop may take before it
+	// fails. The default is 8.
+	MaxSteps int
+	// BadToolCallRetries is how many malformed tool calls — unknown tool
+	// names or inputs the tool rejects — are reported back to the model
+	// for another try before the loop fails. The default is 2.
+	BadToolCallRetries int
+	// Config is passed through as every request's config, for example a
+	// [Config].
+	Config any
+}
+
+// AgentResult is the outcome of an agent loop.
+type AgentResult struct {
+	// Response is the model's final answer.
+	Response *ai.ModelResponse
+	// Messages is the full transcript: the input messages plus every model
+	// turn and tool turn the loop added.
+	Messages []*ai.Message
+	// Steps is how many model calls were made.
+	Steps int
+	// ToolCalls is how many tool invocations succeeded.
+	ToolCalls int
+	// BadToolCalls is how many malformed tool calls were retried.
+	BadToolCalls int
+	// Usage accumulates token usage across every step.
+	Usage *ai.GenerationUsage
+}
+
+// RunAgent drives a tool-calling loop against a Workers AI model until the
+// model answers without requesting tools. It papers over the quirks the raw
+// loop trips on: verbose argument formats are already normalized by the
+// model layer, tool calls missing an ID get one synthesized so responses
+// still correlate, and a malformed call — an unknown tool or input the tool
+// rejects — is reported back to the model for another try instead of
+// aborting the conversation. Step and retry budgets keep a confused model
+// from looping forever.
+func RunAgent(ctx context.Context, model ai.Model, messages []*ai.Message, opts *AgentOptions) (*AgentResult, error) {
+	if model == nil {
+		return nil, errors.New("workersai: RunAgent requires a model")
+	}
+	cfg := AgentOptions{}
+	if opts != nil {
+		cfg = *opts
+	}
+	if cfg.MaxSteps <= 0 {
+		cfg.MaxSteps = 8
+	}
+	if cfg.BadToolCallRetries <= 0 {
+		cfg.BadToolCallRetries = 2
+	}
+
+	tools := make(map[string]ai.Tool, len(cfg.Tools))
+	defs := make([]*ai.ToolDefinition, 0, len(cfg.Tools))
+	for _, tool := range cfg.Tools {
+		tools[tool.Name()] = tool
+		defs = append(defs, tool.Definition())
+	}
+
+	result := &AgentResult{
+		Messages: append([]*ai.Message(nil), messages...),
+		Usage:    &ai.GenerationUsage{},
+	}
+	retriesLeft := cfg.BadToolCallRetries
+	for result.Steps < cfg.MaxSteps {
+		resp, err := model.Generate(ctx, &ai.ModelRequest{
+			Messages: result.Messages,
+			Tools:    defs,
+			Config:   cfg.Config,
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		result.Steps++
+		if resp.Usage != nil {
+			result.Usage.InputTokens += resp.Usage.InputTokens
+			result.Usage.OutputTokens += resp.Usage.OutputTokens
+		}
+
+		requests := toolRequestParts(resp.Message)
+		if len(requests) == 0 {
+			result.Response = resp
+			result.Messages = append(result.Messages, resp.Message)
+			return result, nil
+		}
+
+		// Some models omit tool-call IDs; fill them in before the turn
+		// enters the history so the paired responses still correlate.
+		for i, part := range requests {
+			if part.ToolRequest.Ref == "" {
+				part.ToolRequest.Ref = fmt.Sprintf("call_%d_%d", result.Steps, i)
+			}
+		}
+
+		toolMsg := &ai.Message{Role: ai.RoleTool}
+		for _, part := range requests {
+			req := part.ToolRequest
+			output, badCall := runAgentTool(ctx, tools, req)
+			if badCall != "" {
+				if retriesLeft == 0 {
+					return nil, errors.Errorf("workersai: agent gave up after %d malformed tool calls; last: %s", cfg.BadToolCallRetries+1, badCall)
+				}
+				retriesLeft--
+				result.BadToolCalls++
+				// Reporting the problem as the tool's output gives the
+				// model a chance to correct the call on its next turn.
+				output = map[string]any{"error": badCall}
+			} else {
+				result.ToolCalls++
+			}
+			toolMsg.Content = append(toolMsg.Content, ai.NewToolResponsePart(&ai.ToolResponse{
+				Name:   req.Name,
+				Ref:    req.Ref,
+				Output: output,
+			}))
+		}
+		result.Messages = append(result.Messages, resp.Message, toolMsg)
+	}
+	return nil, errors.Errorf("workersai: agent did not settle within %d steps", cfg.MaxSteps)
+}
+
+// runAgentTool executes one tool call, returning its output, or a non-empty
+// description of why the call was malformed.
+func runAgentTool(ctx context.Context, tools map[string]ai.Tool, req *ai.ToolRequest) (any, string) {
+	tool, ok := tools[req.Name]
+	if !ok {
+		return nil, fmt.Sprintf("unknown tool %q", req.Name)
+	}
+	output, err := tool.RunRaw(ctx, req.Input)
+	if err != nil {
+		return nil, fmt.Sprintf("tool %q rejected the input: %v", req.Name, err)
+	}
+	return output, ""
+}
@@ -0,0 +1,147 @@
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTool is a minimal ai.Tool for driving the agent loop.
+type fakeTool struct {
+	name string
+	fn   func(input any) (any, error)
+}
+
+func (t *fakeTool) Name() string { return t.name }
+
+func (t *fakeTool) Definition() *ai.ToolDefinition {
+	return &ai.ToolDefinition{Name: t.name, Description: t.name}
+}
+
+func (t *fakeTool) RunRaw(ctx context.Context, input any) (any, error) { return t.fn(input) }
+
+func (t *fakeTool) Register(r *registry.Registry) {}
+
+func (t *fakeTool) Respond(toolReq *ai.Part, outputData any, opts *ai.RespondOptions) *ai.Part {
+	return nil
+}
+
+func (t *fakeTool) Restart(toolReq *ai.Part, opts *ai.RestartOptions) *ai.Part { return nil }
+
+// queueModel replays a fixed sequence of responses.
+type queueModel struct {
+	responses []*ai.ModelResponse
+	requests  []*ai.ModelRequest
+}
+
+func (m *queueModel) Name() string { return "workersai/queued" }
+
+func (m *queueModel) Generate(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	m.requests = append(m.requests, req)
+	if len(m.responses) == 0 {
+		return nil, fmt.Errorf("queueModel: out of responses")
+	}
+	resp := m.responses[0]
+	m.responses = m.responses[1:]
+	return resp, nil
+}
+
+func toolCallResponse(name string, ref string, input map[string]any) *ai.ModelResponse {
+	return &ai.ModelResponse{
+		Message: &ai.Message{
+			Role: ai.RoleModel,
+			Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  name,
+				Ref:   ref,
+				Input: input,
+			})},
+		},
+		Usage: &ai.GenerationUsage{InputTokens: 10, OutputTokens: 5},
+	}
+}
+
+func TestRunAgent(t *testing.T) {
+	weather := &fakeTool{name: "getWeather", fn: func(input any) (any, error) {
+		return map[string]any{"forecast": "sunny"}, nil
+	}}
+
+	t.Run("runs tools until the model answers", func(t *testing.T) {
+		model := &queueModel{responses: []*ai.ModelResponse{
+			toolCallResponse("getWeather", "call_1", map[string]any{"city": "Lisbon"}),
+			{Message: ai.NewModelTextMessage("Sunny in Lisbon."), Usage: &ai.GenerationUsage{OutputTokens: 4}},
+		}}
+		res, err := RunAgent(context.Background(), model, []*ai.Message{
+			ai.NewUserTextMessage("Weather in Lisbon?"),
+		}, &AgentOptions{Tools: []ai.Tool{weather}})
+		require.NoError(t, err)
+
+		assert.Equal(t, "Sunny in Lisbon.", res.Response.Message.Text())
+		assert.Equal(t, 2, res.Steps)
+		assert.Equal(t, 1, res.ToolCalls)
+		assert.Zero(t, res.BadToolCalls)
+		assert.Equal(t, 9, res.Usage.OutputTokens)
+
+		// The second model call saw the tool turn in the history.
+		require.Len(t, model.requests, 2)
+		history := model.requests[1].Messages
+		require.Len(t, history, 3)
+		assert.Equal(t, ai.RoleTool, history[2].Role)
+	})
+
+	t.Run("synthesizes missing tool-call IDs", func(t *testing.T) {
+		model := &queueModel{responses: []*ai.ModelResponse{
+			toolCallResponse("getWeather", "", map[string]any{"city": "Porto"}),
+			{Message: ai.NewModelTextMessage("done")},
+		}}
+		res, err := RunAgent(context.Background(), model, nil, &AgentOptions{Tools: []ai.Tool{weather}})
+		require.NoError(t, err)
+
+		toolTurn := res.Messages[0].Content[0].ToolRequest
+		toolResp := res.Messages[1].Content[0].ToolResponse
+		assert.NotEmpty(t, toolTurn.Ref)
+		assert.Equal(t, toolTurn.Ref, toolResp.Ref)
+	})
+
+	t.Run("reports malformed calls back to the model", func(t *testing.T) {
+		model := &queueModel{responses: []*ai.ModelResponse{
+			toolCallResponse("noSuchTool", "call_1", nil),
+			{Message: ai.NewModelTextMessage("recovered")},
+		}}
+		res, err := RunAgent(context.Background(), model, nil, &AgentOptions{Tools: []ai.Tool{weather}})
+		require.NoError(t, err)
+		assert.Equal(t, 1, res.BadToolCalls)
+
+		// The model saw the problem as the tool's output.
+		output := model.requests[1].Messages[1].Content[0].ToolResponse.Output.(map[string]any)
+		assert.Contains(t, output["error"], `unknown tool "noSuchTool"`)
+	})
+
+	t.Run("fails after the retry budget", func(t *testing.T) {
+		model := &queueModel{responses: []*ai.ModelResponse{
+			toolCallResponse("noSuchTool", "c1", nil),
+			toolCallResponse("noSuchTool", "c2", nil),
+		}}
+		_, err := RunAgent(context.Background(), model, nil, &AgentOptions{
+			Tools:              []ai.Tool{weather},
+			BadToolCallRetries: 1,
+		})
+		require.ErrorContains(t, err, "malformed tool calls")
+	})
+
+	t.Run("fails after the step budget", func(t *testing.T) {
+		model := &queueModel{responses: []*ai.ModelResponse{
+			toolCallResponse("getWeather", "c1", nil),
+			toolCallResponse("getWeather", "c2", nil),
+		}}
+		_, err := RunAgent(context.Background(), model, nil, &AgentOptions{
+			Tools:    []ai.Tool{weather},
+			MaxSteps: 2,
+		})
+		require.ErrorContains(t, err, "did not settle within 2 steps")
+	})
+}
@@ -0,0 +1,55 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func respWithText(text string) *ai.ModelResponse {
+	return &ai.ModelResponse{Message: ai.NewModelTextMessage(text)}
+}
+
+func TestParseAnswerWithSources(t *testing.T) {
+	t.Run("parses a fenced response", func(t *testing.T) {
+		resp := respWithText("```json\n{\"answer\":\"Paris\",\"sources\":[1,0],\"confidence\":0.9}\n```")
+		out, err := ParseAnswerWithSources(resp, 2)
+		require.NoError(t, err)
+		assert.Equal(t, "Paris", out.Answer)
+		assert.Equal(t, []int{1, 0}, out.Sources)
+		assert.Equal(t, 0.9, out.Confidence)
+	})
+
+	t.Run("clamps confidence", func(t *testing.T) {
+		resp := respWithText(`{"answer":"Paris","sources":[],"confidence":1.7}`)
+		out, err := ParseAnswerWithSources(resp, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 1.0, out.Confidence)
+	})
+
+	t.Run("rejects out-of-range sources", func(t *testing.T) {
+		resp := respWithText(`{"answer":"Paris","sources":[3],"confidence":0.5}`)
+		_, err := ParseAnswerWithSources(resp, 2)
+		require.ErrorContains(t, err, "cites document 3")
+	})
+
+	t.Run("rejects non-JSON output", func(t *testing.T) {
+		_, err := ParseAnswerWithSources(respWithText("Paris, probably."), 0)
+		require.ErrorContains(t, err, "did not return valid JSON")
+	})
+
+	t.Run("rejects a missing answer", func(t *testing.T) {
+		resp := respWithText(`{"answer":"","sources":[],"confidence":0.2}`)
+		_, err := ParseAnswerWithSources(resp, 0)
+		require.ErrorContains(t, err, "missing the answer")
+	})
+}
+
+func TestAnswerWithSourcesOutput(t *testing.T) {
+	out := AnswerWithSourcesOutput()
+	assert.Equal(t, ai.OutputFormatJSON, out.Format)
+	required, _ := out.Schema["required"].([]string)
+	assert.ElementsMatch(t, []string{"answer", "sources", "confidence"}, required)
+}
@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Token is an API token returned by a [TokenSource], with an optional expiry.
+type Token struct {
+	// Value is the bearer token sent in the Authorization header.
+	Value string
+	// Expiry is when the token stops being valid. A zero Expiry means the
+	// source did not report one; such tokens are cached for a short default
+	// period so rotations are still picked up.
+	Expiry time.Time
+}
+
+// TokenSource supplies the API token used to authenticate requests. It is
+// consulted per request (with caching), so tokens rotated by a secret manager
+// are picked up without restarting the process or re-initializing the plugin.
+type TokenSource interface {
+	Token() (*Token, error)
+}
+
+// defaultTokenCacheTTL is how long a token without an expiry is cached
+// before the source is consulted again.
+const defaultTokenCacheTTL = time.Minute
+
+// tokenTransport is an http.RoundTripper that sets the Authorization header
+// from a TokenSource, caching the token until it expires.
+type tokenTransport struct {
+	base   http.RoundTripper
+	source TokenSource
+
+	mu      sync.Mutex
+	token   *Token
+	refresh time.Time
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	// A failed-over request already carries the secondary account's token.
+	if failedOver(req) {
+		return base.RoundTrip(req)
+	}
+
+	token, err := t.currentToken()
+	if err != nil {
+		return nil, fmt.Errorf("workersai: failed to obtain API token: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+token.Value)
+	return base.RoundTrip(req)
+}
+
+// currentToken returns the cached token, consulting the source again once the
+// token's expiry (or the default cache TTL) has passed.
+func (t *tokenTransport) currentToken() (*Token, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != nil && time.Now().Before(t.refresh) {
+		return t.token, nil
+	}
+
+	token, err := t.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil || token.Value == "" {
+		return nil, fmt.Errorf("token source returned an empty token")
+	}
+
+	t.token = token
+	if token.Expiry.IsZero() {
+		t.refresh = time.Now().Add(defaultTokenCacheTTL)
+	} else {
+		t.refresh = token.Expiry
+	}
+	return t.token, nil
+}
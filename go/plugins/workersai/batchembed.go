@@ -0,0 +1,162 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// defaultEmbedBatchSize is how many documents go into one embedding call.
+// Workers AI accepts up to 100 texts per request; a smaller default keeps
+// the blast radius of a failed call modest.
+const defaultEmbedBatchSize = 50
+
+// BatchEmbedOptions configures [EmbedBatch].
+type BatchEmbedOptions struct {
+	// BatchSize is how many documents are embedded per call. The default
+	// is 50.
+	BatchSize int
+	// Options is passed through as the embed options of every call, for
+	// example an [EmbedOptions].
+	Options any
+	// Retries re-runs just the failed items that many times before they
+	// are reported as failed. Zero means no automatic retries.
+	Retries int
+}
+
+// BatchEmbedItemError records why one document could not be embedded.
+type BatchEmbedItemError struct {
+	// Index is the document's position in the input slice.
+	Index int
+	// Err is the failure for that document.
+	Err error
+}
+
+func (e BatchEmbedItemError) Error() string {
+	return fmt.Sprintf("document %d: %v", e.Index, e.Err)
+}
+
+// BatchEmbedResult holds the outcome of a batch, keeping successes even when
+// some items fail.
+type BatchEmbedResult struct {
+	// Embeddings is index-aligned with the input documents; entries for
+	// failed items are nil.
+	Embeddings []*ai.Embedding
+	// Failed lists the items without a vector, in input order.
+	Failed []BatchEmbedItemError
+}
+
+// EmbedBatch embeds a large set of documents with partial-failure semantics:
+// instead of one bad document failing the whole call, every document that can
+// be embedded is, and the rest are reported per item in the result. A failing
+// call is bisected until the offending documents are isolated, so one poison
+// item costs log(batch) extra calls rather than one per document. Indexing
+// jobs resume by re-running only the failures, either through
+// [BatchEmbedOptions].Retries or [BatchEmbedResult.Retry].
+//
+// The returned error is reserved for conditions that end the batch as a
+// whole, such as context cancellation.
+func EmbedBatch(ctx context.Context, e ai.Embedder, docs []*ai.Document, opts *BatchEmbedOptions) (*BatchEmbedResult, error) {
+	if e == nil {
+		return nil, errors.New("workersai: EmbedBatch requires an embedder")
+	}
+	if opts == nil {
+		opts = &BatchEmbedOptions{}
+	}
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEmbedBatchSize
+	}
+
+	result := &BatchEmbedResult{Embeddings: make([]*ai.Embedding, len(docs))}
+	indices := make([]int, len(docs))
+	for i := range indices {
+		indices[i] = i
+	}
+	for start := 0; start < len(indices); start += batchSize {
+		end := min(start+batchSize, len(indices))
+		if err := embedChunk(ctx, e, docs, indices[start:end], opts.Options, result); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < opts.Retries && len(result.Failed) > 0; i++ {
+		if err := result.Retry(ctx, e, docs, opts); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// Retry re-embeds only the failed items against the same documents, replacing
+// the result's failure list with whatever still fails. It is a no-op when
+// nothing failed.
+func (r *BatchEmbedResult) Retry(ctx context.Context, e ai.Embedder, docs []*ai.Document, opts *BatchEmbedOptions) error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	if opts == nil {
+		opts = &BatchEmbedOptions{}
+	}
+	indices := make([]int, 0, len(r.Failed))
+	for _, f := range r.Failed {
+		indices = append(indices, f.Index)
+	}
+	r.Failed = nil
+	return embedChunk(ctx, e, docs, indices, opts.Options, r)
+}
+
+// embedChunk embeds the documents at the given indices in one call. On
+// failure the chunk is split in half and each half retried, down to single
+// documents, which are recorded as per-item failures. Context expiry aborts
+// the bisection instead of blaming individual documents.
+func embedChunk(ctx context.Context, e ai.Embedder, docs []*ai.Document, indices []int, embedOpts any, result *BatchEmbedResult) error {
+	if len(indices) == 0 {
+		return nil
+	}
+	input := make([]*ai.Document, 0, len(indices))
+	for _, i := range indices {
+		input = append(input, docs[i])
+	}
+
+	eres, err := e.Embed(ctx, &ai.EmbedRequest{Input: input, Options: embedOpts})
+	if err == nil && len(eres.Embeddings) != len(indices) {
+		err = fmt.Errorf("embedder returned %d vectors for %d inputs", len(eres.Embeddings), len(indices))
+	}
+	if err == nil {
+		for n, i := range indices {
+			result.Embeddings[i] = eres.Embeddings[n]
+		}
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if len(indices) == 1 {
+		result.Failed = append(result.Failed, BatchEmbedItemError{Index: indices[0], Err: err})
+		return nil
+	}
+
+	mid := len(indices) / 2
+	if err := embedChunk(ctx, e, docs, indices[:mid], embedOpts, result); err != nil {
+		return err
+	}
+	return embedChunk(ctx, e, docs, indices[mid:], embedOpts, result)
+}
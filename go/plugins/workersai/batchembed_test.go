@@ -0,0 +1,116 @@
+package workersai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// poisonEmbedder fails any call whose input includes a poison document, and
+// counts transient failures per document so retries can be exercised.
+type poisonEmbedder struct {
+	calls     int
+	remaining map[string]int // failures left per document text
+}
+
+func (e *poisonEmbedder) Name() string { return "workersai/test-embedder" }
+
+func (e *poisonEmbedder) Embed(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+	e.calls++
+	resp := &ai.EmbedResponse{}
+	for _, doc := range req.Input {
+		text := concatenateText(doc)
+		if strings.Contains(text, "poison") {
+			return nil, errors.New("model rejected input")
+		}
+		if e.remaining[text] > 0 {
+			e.remaining[text]--
+			return nil, errors.New("transient overload")
+		}
+		resp.Embeddings = append(resp.Embeddings, &ai.Embedding{Embedding: []float32{float32(len(text))}})
+	}
+	return resp, nil
+}
+
+func TestEmbedBatchPartialFailure(t *testing.T) {
+	docs := []*ai.Document{
+		ai.DocumentFromText("alpha", nil),
+		ai.DocumentFromText("poison one", nil),
+		ai.DocumentFromText("beta", nil),
+		ai.DocumentFromText("poison two", nil),
+		ai.DocumentFromText("gamma", nil),
+	}
+
+	res, err := EmbedBatch(context.Background(), &poisonEmbedder{}, docs, nil)
+	require.NoError(t, err)
+
+	require.Len(t, res.Embeddings, 5)
+	assert.NotNil(t, res.Embeddings[0])
+	assert.Nil(t, res.Embeddings[1])
+	assert.NotNil(t, res.Embeddings[2])
+	assert.Nil(t, res.Embeddings[3])
+	assert.NotNil(t, res.Embeddings[4])
+
+	require.Len(t, res.Failed, 2)
+	assert.Equal(t, 1, res.Failed[0].Index)
+	assert.Equal(t, 3, res.Failed[1].Index)
+	assert.ErrorContains(t, res.Failed[0].Err, "model rejected input")
+}
+
+func TestEmbedBatchRetriesTransientFailures(t *testing.T) {
+	docs := []*ai.Document{
+		ai.DocumentFromText("alpha", nil),
+		ai.DocumentFromText("flaky", nil),
+	}
+	// Two failures: one for the chunk call, one for the bisected retry of
+	// the document itself, so it is still failed when the batch returns.
+	e := &poisonEmbedder{remaining: map[string]int{"flaky": 2}}
+
+	// Without retries the flaky document is reported as failed.
+	res, err := EmbedBatch(context.Background(), e, docs, nil)
+	require.NoError(t, err)
+	require.Len(t, res.Failed, 1)
+	assert.Equal(t, 1, res.Failed[0].Index)
+
+	// Retrying re-embeds only the failure and clears it.
+	require.NoError(t, res.Retry(context.Background(), e, docs, nil))
+	assert.Empty(t, res.Failed)
+	assert.NotNil(t, res.Embeddings[1])
+
+	// With Retries set, EmbedBatch recovers on its own.
+	e = &poisonEmbedder{remaining: map[string]int{"flaky": 2}}
+	res, err = EmbedBatch(context.Background(), e, docs, &BatchEmbedOptions{Retries: 1})
+	require.NoError(t, err)
+	assert.Empty(t, res.Failed)
+}
+
+func TestEmbedBatchBisectsInsteadOfPerItemCalls(t *testing.T) {
+	docs := make([]*ai.Document, 16)
+	for i := range docs {
+		docs[i] = ai.DocumentFromText(strings.Repeat("x", i+1), nil)
+	}
+	docs[7] = ai.DocumentFromText("poison", nil)
+
+	e := &poisonEmbedder{}
+	res, err := EmbedBatch(context.Background(), e, docs, nil)
+	require.NoError(t, err)
+	require.Len(t, res.Failed, 1)
+	assert.Equal(t, 7, res.Failed[0].Index)
+	// Bisection isolates the poison document in O(log n) calls; embedding
+	// every item individually would take 16.
+	assert.Less(t, e.calls, 16)
+}
+
+func TestEmbedBatchStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	docs := []*ai.Document{ai.DocumentFromText("alpha", nil)}
+	e := &poisonEmbedder{remaining: map[string]int{"alpha": 1}}
+	_, err := EmbedBatch(ctx, e, docs, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// BenchmarkOptions configures [Benchmark].
+type BenchmarkOptions struct {
+	// Runs is how many times each prompt is sent to each model. The
+	// default is 1; use more runs for stable percentiles.
+	Runs int
+
+	// Config is the model config applied to every request.
+	Config any
+
+	// Cost, when set, estimates the cost of one request from its usage;
+	// per-model totals are summed into the report. Workers AI bills in
+	// neurons, whose per-model rates are not known to the plugin, so
+	// pricing knowledge stays with the caller.
+	Cost func(model string, usage *ai.GenerationUsage) float64
+}
+
+// ModelBenchmark is the measured performance of one model across all
+// benchmark prompts and runs.
+type ModelBenchmark struct {
+	// Model is the model's registry name.
+	Model string `json:"model"`
+	// Requests is how many requests were attempted.
+	Requests int `json:"requests"`
+	// Failures is how many of them returned an error.
+	Failures int `json:"failures"`
+	// SuccessRate is (Requests-Failures)/Requests.
+	SuccessRate float64 `json:"successRate"`
+
+	// Latency percentiles over the successful requests.
+	LatencyP50 time.Duration `json:"latencyP50"`
+	LatencyP95 time.Duration `json:"latencyP95"`
+	LatencyP99 time.Duration `json:"latencyP99"`
+
+	// OutputTokensPerSec is the aggregate token throughput of successful
+	// requests. Token counts come from response usage when the model
+	// reports it, falling back to a length-based estimate.
+	OutputTokensPerSec float64 `json:"outputTokensPerSec"`
+	// InputTokens and OutputTokens are summed over successful requests.
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+
+	// EstimatedCost is the summed per-request cost, when a cost function
+	// was configured.
+	EstimatedCost float64 `json:"estimatedCost,omitempty"`
+
+	// LastError describes the most recent failure, if any.
+	LastError string `json:"lastError,omitempty"`
+}
+
+// BenchmarkReport is the result of one [Benchmark] run.
+type BenchmarkReport struct {
+	// Models holds one entry per benchmarked model, in input order.
+	Models []ModelBenchmark `json:"models"`
+	// StartedAt is when the run began.
+	StartedAt time.Time `json:"startedAt"`
+	// Duration is how long the whole run took.
+	Duration time.Duration `json:"duration"`
+}
+
+// Benchmark measures latency percentiles, token throughput, estimated cost
+// and success rate of the given models across a set of prompts, so model
+// selection can be driven by data instead of anecdotes. Requests run
+// sequentially, one model at a time, to keep the latency numbers free of
+// self-inflicted contention; expect a run to take on the order of
+// models x prompts x runs model calls.
+func Benchmark(ctx context.Context, models []ai.Model, prompts []string, opts *BenchmarkOptions) (*BenchmarkReport, error) {
+	if len(models) == 0 || len(prompts) == 0 {
+		return nil, errors.New("workersai: Benchmark requires at least one model and one prompt")
+	}
+	cfg := BenchmarkOptions{}
+	if opts != nil {
+		cfg = *opts
+	}
+	if cfg.Runs <= 0 {
+		cfg.Runs = 1
+	}
+
+	report := &BenchmarkReport{StartedAt: time.Now()}
+	for _, model := range models {
+		result := ModelBenchmark{Model: model.Name()}
+		var latencies []time.Duration
+		var busy time.Duration
+
+		for _, prompt := range prompts {
+			for run := 0; run < cfg.Runs; run++ {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				start := time.Now()
+				resp, err := model.Generate(ctx, &ai.ModelRequest{
+					Messages: []*ai.Message{ai.NewUserTextMessage(prompt)},
+					Config:   cfg.Config,
+				}, nil)
+				elapsed := time.Since(start)
+
+				result.Requests++
+				if err != nil {
+					result.Failures++
+					result.LastError = err.Error()
+					continue
+				}
+				latencies = append(latencies, elapsed)
+				busy += elapsed
+
+				in, out := responseTokens(prompt, resp)
+				result.InputTokens += in
+				result.OutputTokens += out
+				if cfg.Cost != nil {
+					result.EstimatedCost += cfg.Cost(model.Name(), resp.Usage)
+				}
+			}
+		}
+
+		result.SuccessRate = float64(result.Requests-result.Failures) / float64(result.Requests)
+		result.LatencyP50 = percentile(latencies, 50)
+		result.LatencyP95 = percentile(latencies, 95)
+		result.LatencyP99 = percentile(latencies, 99)
+		if busy > 0 {
+			result.OutputTokensPerSec = float64(result.OutputTokens) / busy.Seconds()
+		}
+		report.Models = append(report.Models, result)
+	}
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// responseTokens returns the input and output token counts of a response,
+// preferring reported usage and falling back to the four-characters-per-token
+// estimate.
+func responseTokens(prompt string, resp *ai.ModelResponse) (in, out int) {
+	if resp.Usage != nil && (resp.Usage.InputTokens > 0 || resp.Usage.OutputTokens > 0) {
+		return resp.Usage.InputTokens, resp.Usage.OutputTokens
+	}
+	var text string
+	if resp.Message != nil {
+		text = resp.Message.Text()
+	}
+	return len(prompt) / 4, len(text) / 4
+}
+
+// percentile returns the p-th percentile of the latencies using
+// nearest-rank, or 0 when there are none.
+func percentile(latencies []time.Duration, p int) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
@@ -0,0 +1,80 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// benchModel fails every n-th request and reports fixed usage.
+type benchModel struct {
+	name      string
+	failEvery int
+	calls     int
+}
+
+func (m *benchModel) Name() string { return m.name }
+
+func (m *benchModel) Generate(context.Context, *ai.ModelRequest, ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	m.calls++
+	if m.failEvery > 0 && m.calls%m.failEvery == 0 {
+		return nil, errors.New("upstream exploded")
+	}
+	return &ai.ModelResponse{
+		Message: ai.NewModelTextMessage("fine"),
+		Usage:   &ai.GenerationUsage{InputTokens: 10, OutputTokens: 20},
+	}, nil
+}
+
+func TestBenchmark(t *testing.T) {
+	solid := &benchModel{name: "solid"}
+	flaky := &benchModel{name: "flaky", failEvery: 2}
+
+	report, err := Benchmark(context.Background(),
+		[]ai.Model{solid, flaky},
+		[]string{"prompt one", "prompt two"},
+		&BenchmarkOptions{
+			Runs: 2,
+			Cost: func(model string, usage *ai.GenerationUsage) float64 {
+				return float64(usage.OutputTokens) * 0.001
+			},
+		})
+	require.NoError(t, err)
+	require.Len(t, report.Models, 2)
+
+	got := report.Models[0]
+	assert.Equal(t, "solid", got.Model)
+	assert.Equal(t, 4, got.Requests)
+	assert.Zero(t, got.Failures)
+	assert.Equal(t, 1.0, got.SuccessRate)
+	assert.Equal(t, 40, got.InputTokens)
+	assert.Equal(t, 80, got.OutputTokens)
+	assert.InDelta(t, 0.08, got.EstimatedCost, 1e-9)
+	assert.Greater(t, got.OutputTokensPerSec, 0.0)
+	assert.LessOrEqual(t, got.LatencyP50, got.LatencyP99)
+
+	got = report.Models[1]
+	assert.Equal(t, 2, got.Failures)
+	assert.Equal(t, 0.5, got.SuccessRate)
+	assert.Equal(t, "upstream exploded", got.LastError)
+}
+
+func TestBenchmarkValidation(t *testing.T) {
+	_, err := Benchmark(context.Background(), nil, []string{"p"}, nil)
+	require.Error(t, err)
+	_, err = Benchmark(context.Background(), []ai.Model{&benchModel{name: "m"}}, nil, nil)
+	require.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := []time.Duration{4, 1, 3, 2, 5}
+	assert.Equal(t, time.Duration(3), percentile(latencies, 50))
+	assert.Equal(t, time.Duration(5), percentile(latencies, 95))
+	assert.Equal(t, time.Duration(1), percentile(latencies, 1))
+	assert.Zero(t, percentile(nil, 50))
+}
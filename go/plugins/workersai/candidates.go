@@ -0,0 +1,105 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"encoding/json"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Candidate is one alternative completion returned when a request asked for
+// multiple candidates (Config.Candidates). Each candidate finishes
+// independently.
+type Candidate struct {
+	Message      *ai.Message     `json:"message"`
+	FinishReason ai.FinishReason `json:"finishReason"`
+}
+
+// Candidates returns every completion of a response in order — the primary
+// message first, then the alternatives a Config.Candidates request produced
+// — for best-of-n sampling and rerank-then-pick pipelines. Responses without
+// alternatives return just the primary message.
+func Candidates(resp *ai.ModelResponse) []*ai.Message {
+	var out []*ai.Message
+	if resp.Message != nil {
+		out = append(out, resp.Message)
+	}
+	if custom, ok := resp.Custom.(map[string]any); ok {
+		if extras, ok := custom["candidates"].([]Candidate); ok {
+			for _, c := range extras {
+				out = append(out, c.Message)
+			}
+		}
+	}
+	return out
+}
+
+// extraCandidates converts the choices beyond the first into candidates.
+// Only the OpenAI-compatible response format can carry multiple choices; the
+// legacy format never does.
+func extraCandidates(resp *client.ChatResponse) []Candidate {
+	if resp.IsLegacyResult {
+		return nil
+	}
+	choices := resp.ChatCompletionResponse.Choices
+	if len(choices) < 2 {
+		return nil
+	}
+
+	out := make([]Candidate, 0, len(choices)-1)
+	for _, choice := range choices[1:] {
+		msg := &ai.Message{Role: ai.RoleModel}
+		if choice.Message.Content != nil && *choice.Message.Content != "" {
+			msg.Content = append(msg.Content, ai.NewTextPart(*choice.Message.Content))
+		}
+		for _, tc := range choice.Message.ToolCalls {
+			var input map[string]any
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &input); err != nil {
+				continue
+			}
+			msg.Content = append(msg.Content, ai.NewToolRequestPart(&ai.ToolRequest{
+				Ref:   tc.ID,
+				Name:  tc.Function.Name,
+				Input: input,
+			}))
+		}
+		if len(msg.Content) == 0 {
+			msg.Content = append(msg.Content, ai.NewTextPart(""))
+		}
+		out = append(out, Candidate{
+			Message:      msg,
+			FinishReason: finishReasonFromChoice(choice.FinishReason),
+		})
+	}
+	return out
+}
+
+// finishReasonFromChoice maps an OpenAI-style finish_reason string onto
+// Genkit's finish reasons.
+func finishReasonFromChoice(reason string) ai.FinishReason {
+	switch reason {
+	case "length":
+		return ai.FinishReasonLength
+	case "content_filter":
+		return ai.FinishReasonBlocked
+	case "stop", "tool_calls", "":
+		return ai.FinishReasonStop
+	default:
+		return ai.FinishReasonOther
+	}
+}
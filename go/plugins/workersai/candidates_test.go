@@ -0,0 +1,74 @@
+package workersai
+
+import (
+	"encoding/json"
+	"testing"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtraCandidates(t *testing.T) {
+	body := `{"success":true,"errors":[],"result":{"choices":[
+		{"index":0,"message":{"role":"assistant","content":"first"},"finish_reason":"stop"},
+		{"index":1,"message":{"role":"assistant","content":"second"},"finish_reason":"length"},
+		{"index":2,"message":{"role":"assistant","tool_calls":[
+			{"id":"call_1","type":"function","function":{"name":"getWeather","arguments":"{\"city\":\"Lisbon\"}"}}
+		]},"finish_reason":"tool_calls"}
+	]}}`
+	var resp client.ChatResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+	extras := extraCandidates(&resp)
+	require.Len(t, extras, 2)
+
+	assert.Equal(t, "second", extras[0].Message.Text())
+	assert.Equal(t, ai.FinishReasonLength, extras[0].FinishReason)
+
+	require.True(t, extras[1].Message.Content[0].IsToolRequest())
+	assert.Equal(t, "getWeather", extras[1].Message.Content[0].ToolRequest.Name)
+	assert.Equal(t, ai.FinishReasonStop, extras[1].FinishReason)
+}
+
+func TestExtraCandidatesSingleChoice(t *testing.T) {
+	body := `{"success":true,"errors":[],"result":{"choices":[
+		{"index":0,"message":{"role":"assistant","content":"only"},"finish_reason":"stop"}
+	]}}`
+	var resp client.ChatResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+	assert.Empty(t, extraCandidates(&resp))
+
+	legacy := `{"success":true,"errors":[],"result":{"response":"legacy"}}`
+	require.NoError(t, json.Unmarshal([]byte(legacy), &resp))
+	assert.Empty(t, extraCandidates(&resp))
+}
+
+func TestCandidates(t *testing.T) {
+	primary := ai.NewModelTextMessage("first")
+	alt := ai.NewModelTextMessage("second")
+
+	resp := &ai.ModelResponse{
+		Message: primary,
+		Custom: map[string]any{
+			"candidates": []Candidate{{Message: alt, FinishReason: ai.FinishReasonStop}},
+		},
+	}
+	messages := Candidates(resp)
+	require.Len(t, messages, 2)
+	assert.Same(t, primary, messages[0])
+	assert.Same(t, alt, messages[1])
+
+	// No alternatives: just the primary message.
+	messages = Candidates(&ai.ModelResponse{Message: primary})
+	require.Len(t, messages, 1)
+}
+
+func TestConfigBodyParamsCandidates(t *testing.T) {
+	params := (&Config{Candidates: 3}).bodyParams()
+	assert.Equal(t, 3, params["n"])
+	// A single candidate is the default and is not sent.
+	_, ok := (&Config{Candidates: 1}).bodyParams()["n"]
+	assert.False(t, ok)
+}
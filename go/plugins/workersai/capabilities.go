@@ -0,0 +1,59 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ErrUnsupportedCapability reports a request that needs a feature the target
+// model does not support. The request fails fast with the model and feature
+// named, instead of being sent and rejected opaquely by the API.
+type ErrUnsupportedCapability struct {
+	// Model is the model the request targeted.
+	Model string
+	// Feature is the missing capability: "media", "tools" or "system role".
+	Feature string
+}
+
+func (e *ErrUnsupportedCapability) Error() string {
+	return fmt.Sprintf("workersai: model %s does not support %s", e.Model, e.Feature)
+}
+
+// checkCapabilities validates the request against the model's declared
+// capabilities. A nil supports means the capabilities are unknown, in which
+// case the request is sent as-is and the API has the final say.
+func checkCapabilities(model string, supports *ai.ModelSupports, input *ai.ModelRequest) error {
+	if supports == nil {
+		return nil
+	}
+	if len(input.Tools) > 0 && !supports.Tools {
+		return &ErrUnsupportedCapability{Model: model, Feature: "tools"}
+	}
+	for _, msg := range input.Messages {
+		if msg.Role == ai.RoleSystem && !supports.SystemRole {
+			return &ErrUnsupportedCapability{Model: model, Feature: "system role"}
+		}
+		for _, part := range msg.Content {
+			if part.IsMedia() && !supports.Media {
+				return &ErrUnsupportedCapability{Model: model, Feature: "media"}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCapabilities(t *testing.T) {
+	textOnly := &ai.ModelSupports{Multiturn: true}
+
+	testCases := []struct {
+		name     string
+		supports *ai.ModelSupports
+		input    *ai.ModelRequest
+		feature  string
+	}{
+		{
+			name:     "tools on a non-tools model",
+			supports: textOnly,
+			input: &ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewUserTextMessage("hi")},
+				Tools:    []*ai.ToolDefinition{{Name: "lookup"}},
+			},
+			feature: "tools",
+		},
+		{
+			name:     "system role unsupported",
+			supports: textOnly,
+			input: &ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewSystemTextMessage("be brief")},
+			},
+			feature: "system role",
+		},
+		{
+			name:     "media on a text-only model",
+			supports: textOnly,
+			input: &ai.ModelRequest{
+				Messages: []*ai.Message{{
+					Role:    ai.RoleUser,
+					Content: []*ai.Part{ai.NewMediaPart("image/png", "data:image/png;base64,AA==")},
+				}},
+			},
+			feature: "media",
+		},
+		{
+			name:     "supported request passes",
+			supports: &ai.ModelSupports{Tools: true, SystemRole: true},
+			input: &ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewSystemTextMessage("be brief"), ai.NewUserTextMessage("hi")},
+				Tools:    []*ai.ToolDefinition{{Name: "lookup"}},
+			},
+		},
+		{
+			name: "nil supports skips validation",
+			input: &ai.ModelRequest{
+				Tools: []*ai.ToolDefinition{{Name: "lookup"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkCapabilities("@cf/test/model", tc.supports, tc.input)
+			if tc.feature == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var capErr *ErrUnsupportedCapability
+			require.ErrorAs(t, err, &capErr)
+			assert.Equal(t, "@cf/test/model", capErr.Model)
+			assert.Equal(t, tc.feature, capErr.Feature)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// catalogTextModels is the rest of the Workers AI text-generation catalog,
+// registered only when the plugin's AllModels option is set. Unlike the
+// curated models in supportedWorkersAIModels, these have not been
+// individually verified, so they are registered with conservative default
+// capabilities (multiturn chat with a system prompt, nothing more).
+var catalogTextModels = []string{
+	"@cf/deepseek-ai/deepseek-math-7b-instruct",
+	"@cf/defog/sqlcoder-7b-2",
+	"@cf/google/gemma-3-12b-it",
+	"@cf/google/gemma-7b-it",
+	"@cf/meta/llama-2-7b-chat-fp16",
+	"@cf/meta/llama-3-8b-instruct",
+	"@cf/meta/llama-3.1-8b-instruct",
+	"@cf/meta/llama-3.1-8b-instruct-fast",
+	"@cf/meta/llama-3.2-1b-instruct",
+	"@cf/meta/llama-3.2-3b-instruct",
+	"@cf/microsoft/phi-2",
+	"@cf/mistral/mistral-7b-instruct-v0.1",
+	"@cf/mistralai/mistral-7b-instruct-v0.2",
+	"@cf/nousresearch/hermes-2-pro-mistral-7b",
+	"@cf/openchat/openchat-3.5-0106",
+	"@cf/qwen/qwen1.5-14b-chat-awq",
+	"@cf/qwen/qwen2.5-coder-32b-instruct",
+	"@cf/tinyllama/tinyllama-1.1b-chat-v1.0",
+}
+
+// catalogModelInfo returns the conservative defaults used for models
+// registered through AllModels. Nothing beyond multiturn chat with a system
+// prompt is advertised, so capability checks fail fast rather than the API.
+func catalogModelInfo(name string) ai.ModelInfo {
+	return ai.ModelInfo{
+		Label: name,
+		Stage: ai.ModelStageUnstable,
+		Supports: &ai.ModelSupports{
+			Multiturn:  true,
+			SystemRole: true,
+		},
+	}
+}
+
+// registerCatalogModels defines the extended catalog, skipping models the
+// curated set already registered with richer capabilities.
+func (w *WorkersAI) registerCatalogModels(g *genkit.Genkit) {
+	for _, name := range catalogTextModels {
+		if _, ok := supportedWorkersAIModels[name]; ok {
+			continue
+		}
+		w.defineModel(g, name, catalogModelInfo(name))
+	}
+}
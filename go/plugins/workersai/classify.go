@@ -0,0 +1,166 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// ClassifyOptions configures [Classify].
+type ClassifyOptions struct {
+	// Model runs the classification. Required.
+	Model ai.Model
+
+	// Instructions, when non-empty, describes what the labels mean —
+	// without it the model goes by the label names alone.
+	Instructions string
+
+	// Scores asks the model to score every label instead of picking one,
+	// at the cost of a JSON generation instead of a single constrained
+	// token. Implied by a non-zero AbstainThreshold.
+	Scores bool
+
+	// AbstainThreshold abstains (empty Label) when the best label's score
+	// is below it, for callers that prefer no answer over a shaky one.
+	// Zero never abstains.
+	AbstainThreshold float64
+
+	// Config is passed through as the request's config. In single-label
+	// mode the labels are enforced with guided choice, so a [Config] here
+	// must not set GuidedChoice or GuidedRegex itself.
+	Config any
+}
+
+// Classification is the outcome of one classification.
+type Classification struct {
+	// Label is the winning label, or "" when the helper abstained.
+	Label string
+	// Scores holds each label's score, 0..1, when scoring was requested.
+	Scores map[string]float64
+}
+
+// Classify assigns one of the given labels to a text. By default the answer
+// is enum-constrained with guided choice, so the model cannot return
+// anything but a label. With scoring enabled it instead asks for a score per
+// label and picks the best, abstaining below the configured threshold —
+// calibrate the threshold on a labeled sample, the raw scores are only as
+// honest as the model.
+func Classify(ctx context.Context, text string, labels []string, opts *ClassifyOptions) (*Classification, error) {
+	if opts == nil || opts.Model == nil {
+		return nil, errors.New("workersai: Classify requires a model")
+	}
+	if len(labels) < 2 {
+		return nil, errors.New("workersai: Classify requires at least two labels")
+	}
+	cfg := *opts
+	if cfg.AbstainThreshold > 0 {
+		cfg.Scores = true
+	}
+
+	if cfg.Scores {
+		return classifyScored(ctx, text, labels, &cfg)
+	}
+	return classifyChoice(ctx, text, labels, &cfg)
+}
+
+// classifyChoice picks one label with guided choice, so the reply is a label
+// by construction.
+func classifyChoice(ctx context.Context, text string, labels []string, cfg *ClassifyOptions) (*Classification, error) {
+	if cfg.Config != nil {
+		return nil, errors.New("workersai: single-label Classify sets guided choice itself; use Scores to pass a custom config")
+	}
+	prompt := fmt.Sprintf("Classify the text into exactly one of these labels: %s.%s\n\nText:\n%s\n\nReply with only the label.",
+		strings.Join(labels, ", "), classifyInstructions(cfg), text)
+	resp, err := cfg.Model.Generate(ctx, &ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage(prompt)},
+		Config:   &Config{GuidedChoice: labels},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	answer := strings.TrimSpace(resp.Message.Text())
+	for _, label := range labels {
+		if strings.EqualFold(answer, label) {
+			return &Classification{Label: label}, nil
+		}
+	}
+	return nil, errors.Errorf("model answered %q, which is not one of the labels", answer)
+}
+
+// classifyScored asks for a score per label as guided JSON and picks the
+// best, abstaining under the threshold.
+func classifyScored(ctx context.Context, text string, labels []string, cfg *ClassifyOptions) (*Classification, error) {
+	properties := map[string]any{}
+	for _, label := range labels {
+		properties[label] = map[string]any{"type": "number", "minimum": 0, "maximum": 1}
+	}
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             labels,
+		"additionalProperties": false,
+	}
+
+	prompt := fmt.Sprintf("Score how well each label fits the text, 0 to 1.%s\n\nText:\n%s\n\nReply with only a JSON object mapping every label to its score.",
+		classifyInstructions(cfg), text)
+	resp, err := cfg.Model.Generate(ctx, &ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage(prompt)},
+		Config:   cfg.Config,
+		Output:   &ai.ModelOutputConfig{Format: ai.OutputFormatJSON, Schema: schema},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cleaned, err := cleanJSONOutput(resp.Message.Text())
+	if err != nil {
+		return nil, err
+	}
+	var scores map[string]float64
+	if err := json.Unmarshal([]byte(cleaned), &scores); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal label scores")
+	}
+
+	result := &Classification{Scores: map[string]float64{}}
+	best := -1.0
+	for _, label := range labels {
+		score := scores[label]
+		result.Scores[label] = score
+		if score > best {
+			best = score
+			result.Label = label
+		}
+	}
+	if best < cfg.AbstainThreshold {
+		result.Label = ""
+	}
+	return result, nil
+}
+
+// classifyInstructions formats the optional label guidance for a prompt.
+func classifyInstructions(cfg *ClassifyOptions) string {
+	if cfg.Instructions == "" {
+		return ""
+	}
+	return " " + cfg.Instructions
+}
@@ -0,0 +1,82 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyChoice(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{textResponse("Refund")}}
+
+	got, err := Classify(context.Background(), "I want my money back", []string{"refund", "complaint", "praise"}, &ClassifyOptions{Model: model})
+	require.NoError(t, err)
+	// The answer is matched case-insensitively and canonicalized.
+	assert.Equal(t, "refund", got.Label)
+	assert.Nil(t, got.Scores)
+
+	// The request enforces the labels with guided choice.
+	cfg, ok := model.requests[0].Config.(*Config)
+	require.True(t, ok)
+	assert.Equal(t, []string{"refund", "complaint", "praise"}, cfg.GuidedChoice)
+}
+
+func TestClassifyChoiceRejectsOffLabelAnswer(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{textResponse("maybe a refund?")}}
+	_, err := Classify(context.Background(), "text", []string{"refund", "praise"}, &ClassifyOptions{Model: model})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not one of the labels")
+}
+
+func TestClassifyScored(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{
+		textResponse(`{"refund": 0.8, "complaint": 0.3, "praise": 0.0}`),
+	}}
+
+	got, err := Classify(context.Background(), "text", []string{"refund", "complaint", "praise"}, &ClassifyOptions{
+		Model:  model,
+		Scores: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "refund", got.Label)
+	assert.Equal(t, 0.8, got.Scores["refund"])
+	assert.Equal(t, 0.0, got.Scores["praise"])
+
+	// Scoring goes through guided JSON with a per-label schema.
+	output := model.requests[0].Output
+	require.NotNil(t, output)
+	assert.Equal(t, ai.OutputFormatJSON, output.Format)
+}
+
+func TestClassifyAbstains(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{
+		textResponse(`{"refund": 0.4, "praise": 0.3}`),
+	}}
+
+	// A non-zero threshold implies scoring; the best score is below it.
+	got, err := Classify(context.Background(), "text", []string{"refund", "praise"}, &ClassifyOptions{
+		Model:            model,
+		AbstainThreshold: 0.6,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, got.Label)
+	assert.Equal(t, 0.4, got.Scores["refund"])
+}
+
+func TestClassifyValidatesArguments(t *testing.T) {
+	_, err := Classify(context.Background(), "text", []string{"a", "b"}, nil)
+	require.Error(t, err)
+
+	model := &queueModel{}
+	_, err = Classify(context.Background(), "text", []string{"only"}, &ClassifyOptions{Model: model})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least two labels")
+
+	// Single-label mode owns the config; a custom one is rejected rather
+	// than silently dropping its guided choice.
+	_, err = Classify(context.Background(), "text", []string{"a", "b"}, &ClassifyOptions{Model: model, Config: &Config{}})
+	require.Error(t, err)
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// CompareOptions configures [Compare].
+type CompareOptions struct {
+	// ModelA and ModelB are the candidates under comparison. Required.
+	ModelA ai.Model
+	ModelB ai.Model
+
+	// Judge picks the better answer for each prompt. Required; use a
+	// model stronger than either candidate, a judge cannot rank answers
+	// it could not have written.
+	Judge ai.Model
+
+	// Concurrency is how many prompts are processed at once. The default
+	// is 4.
+	Concurrency int
+
+	// Config is passed through as the candidates' request config, for
+	// example a [Config]. The judge always runs with guided JSON output.
+	Config any
+}
+
+// ComparisonVerdict is the judged outcome for one prompt.
+type ComparisonVerdict struct {
+	Prompt  string `json:"prompt"`
+	AnswerA string `json:"answerA"`
+	AnswerB string `json:"answerB"`
+	// Winner is "a", "b" or "tie".
+	Winner    string `json:"winner"`
+	Rationale string `json:"rationale"`
+}
+
+// ComparisonReport aggregates the verdicts of one comparison run.
+type ComparisonReport struct {
+	Verdicts []ComparisonVerdict `json:"verdicts"`
+	WinsA    int                 `json:"winsA"`
+	WinsB    int                 `json:"winsB"`
+	Ties     int                 `json:"ties"`
+	// WinRateA and WinRateB are each model's share of all prompts, so
+	// they and the tie share sum to 1.
+	WinRateA float64 `json:"winRateA"`
+	WinRateB float64 `json:"winRateB"`
+}
+
+// comparePrompt instructs the judge; answers are labeled by position, not by
+// model.
+const comparePrompt = "Two assistants answered the same prompt. Judge which " +
+	"answer is better on correctness first, then completeness and clarity. " +
+	"Reply with only a JSON object: {\"winner\": \"first\"|\"second\"|\"tie\", " +
+	"\"rationale\": \"...\"}.\n\nPrompt:\n%s\n\nFirst answer:\n%s\n\nSecond answer:\n%s"
+
+// Compare sends every prompt through both candidate models and has a judge
+// model pick the better answer with a rationale, reporting per-prompt
+// verdicts and win-rate totals — the data for deciding a catalog upgrade
+// such as llama-3.3 to llama-4. The judge sees the answers by position, with
+// the order swapped on every other prompt so its position bias cancels out
+// across the dataset instead of favoring one model.
+func Compare(ctx context.Context, prompts []string, opts *CompareOptions) (*ComparisonReport, error) {
+	if opts == nil || opts.ModelA == nil || opts.ModelB == nil {
+		return nil, errors.New("workersai: Compare requires two candidate models")
+	}
+	if opts.Judge == nil {
+		return nil, errors.New("workersai: Compare requires a judge model")
+	}
+	if len(prompts) == 0 {
+		return nil, errors.New("workersai: Compare requires at least one prompt")
+	}
+	cfg := *opts
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+
+	report := &ComparisonReport{Verdicts: make([]ComparisonVerdict, len(prompts))}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sem := make(chan struct{}, cfg.Concurrency)
+	for i, prompt := range prompts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int, prompt string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			verdict, err := compareOne(ctx, prompt, i%2 == 1, &cfg)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errors.Wrapf(err, "prompt %d failed", i)
+					cancel()
+				}
+				return
+			}
+			report.Verdicts[i] = *verdict
+		}(i, prompt)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	for _, v := range report.Verdicts {
+		switch v.Winner {
+		case "a":
+			report.WinsA++
+		case "b":
+			report.WinsB++
+		default:
+			report.Ties++
+		}
+	}
+	report.WinRateA = float64(report.WinsA) / float64(len(prompts))
+	report.WinRateB = float64(report.WinsB) / float64(len(prompts))
+	return report, nil
+}
+
+// compareOne runs both candidates on one prompt and judges the pair. With
+// swapped, model B's answer is presented first.
+func compareOne(ctx context.Context, prompt string, swapped bool, cfg *CompareOptions) (*ComparisonVerdict, error) {
+	generate := func(model ai.Model) (string, error) {
+		resp, err := model.Generate(ctx, &ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserTextMessage(prompt)},
+			Config:   cfg.Config,
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(resp.Message.Text()), nil
+	}
+	answerA, err := generate(cfg.ModelA)
+	if err != nil {
+		return nil, errors.Wrap(err, "model A failed")
+	}
+	answerB, err := generate(cfg.ModelB)
+	if err != nil {
+		return nil, errors.Wrap(err, "model B failed")
+	}
+
+	first, second := answerA, answerB
+	if swapped {
+		first, second = answerB, answerA
+	}
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"winner":    map[string]any{"type": "string", "enum": []string{"first", "second", "tie"}},
+			"rationale": map[string]any{"type": "string"},
+		},
+		"required":             []string{"winner", "rationale"},
+		"additionalProperties": false,
+	}
+	resp, err := cfg.Judge.Generate(ctx, &ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage(fmt.Sprintf(comparePrompt, prompt, first, second))},
+		Output:   &ai.ModelOutputConfig{Format: ai.OutputFormatJSON, Schema: schema},
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "judge failed")
+	}
+	cleaned, err := cleanJSONOutput(resp.Message.Text())
+	if err != nil {
+		return nil, errors.Wrap(err, "judge verdict")
+	}
+	var judged struct {
+		Winner    string `json:"winner"`
+		Rationale string `json:"rationale"`
+	}
+	if err := json.Unmarshal([]byte(cleaned), &judged); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal judge verdict")
+	}
+
+	verdict := &ComparisonVerdict{
+		Prompt:    prompt,
+		AnswerA:   answerA,
+		AnswerB:   answerB,
+		Rationale: judged.Rationale,
+	}
+	switch judged.Winner {
+	case "first":
+		verdict.Winner = "a"
+		if swapped {
+			verdict.Winner = "b"
+		}
+	case "second":
+		verdict.Winner = "b"
+		if swapped {
+			verdict.Winner = "a"
+		}
+	case "tie":
+		verdict.Winner = "tie"
+	default:
+		return nil, errors.Errorf("judge answered %q, expected first, second or tie", judged.Winner)
+	}
+	return verdict, nil
+}
@@ -0,0 +1,107 @@
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prefixModel answers every prompt with a fixed prefix, so the judge fake can
+// tell the candidates apart regardless of presentation order.
+type prefixModel struct{ prefix string }
+
+func (m *prefixModel) Name() string { return m.prefix }
+
+func (m *prefixModel) Generate(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	return &ai.ModelResponse{Message: ai.NewModelTextMessage(m.prefix + ": " + req.Messages[0].Text())}, nil
+}
+
+// preferJudge always picks the answer from the preferred model, wherever it
+// appears in the pair.
+type preferJudge struct{ prefer string }
+
+func (j *preferJudge) Name() string { return "judge" }
+
+func (j *preferJudge) Generate(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	prompt := req.Messages[0].Text()
+	first := strings.Split(strings.SplitN(prompt, "First answer:\n", 2)[1], ":")[0]
+	winner := "second"
+	if first == j.prefer {
+		winner = "first"
+	}
+	return &ai.ModelResponse{Message: ai.NewModelTextMessage(
+		fmt.Sprintf(`{"winner": %q, "rationale": "prefers %s"}`, winner, j.prefer))}, nil
+}
+
+func TestCompare(t *testing.T) {
+	report, err := Compare(context.Background(),
+		[]string{"q1", "q2", "q3", "q4"},
+		&CompareOptions{
+			ModelA: &prefixModel{prefix: "alpha"},
+			ModelB: &prefixModel{prefix: "beta"},
+			Judge:  &preferJudge{prefer: "beta"},
+		})
+	require.NoError(t, err)
+
+	// The judge prefers model B on every prompt, with and without the
+	// swapped presentation order, so the swap mapped verdicts back
+	// correctly.
+	assert.Equal(t, 0, report.WinsA)
+	assert.Equal(t, 4, report.WinsB)
+	assert.Equal(t, 0, report.Ties)
+	assert.Equal(t, 1.0, report.WinRateB)
+
+	require.Len(t, report.Verdicts, 4)
+	v := report.Verdicts[1]
+	assert.Equal(t, "q2", v.Prompt)
+	assert.Equal(t, "alpha: q2", v.AnswerA)
+	assert.Equal(t, "beta: q2", v.AnswerB)
+	assert.Equal(t, "b", v.Winner)
+	assert.Equal(t, "prefers beta", v.Rationale)
+}
+
+func TestCompareCountsTies(t *testing.T) {
+	judge := &queueModel{responses: []*ai.ModelResponse{
+		textResponse(`{"winner": "tie", "rationale": "equivalent"}`),
+	}}
+	report, err := Compare(context.Background(), []string{"q1"}, &CompareOptions{
+		ModelA:      &prefixModel{prefix: "alpha"},
+		ModelB:      &prefixModel{prefix: "beta"},
+		Judge:       judge,
+		Concurrency: 1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Ties)
+	assert.Equal(t, 0.0, report.WinRateA)
+}
+
+func TestCompareRejectsOffScriptJudge(t *testing.T) {
+	judge := &queueModel{responses: []*ai.ModelResponse{
+		textResponse(`{"winner": "both", "rationale": "?"}`),
+	}}
+	_, err := Compare(context.Background(), []string{"q1"}, &CompareOptions{
+		ModelA:      &prefixModel{prefix: "alpha"},
+		ModelB:      &prefixModel{prefix: "beta"},
+		Judge:       judge,
+		Concurrency: 1,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `judge answered "both"`)
+}
+
+func TestCompareValidatesArguments(t *testing.T) {
+	_, err := Compare(context.Background(), []string{"q"}, nil)
+	require.Error(t, err)
+	_, err = Compare(context.Background(), nil, &CompareOptions{
+		ModelA: &prefixModel{prefix: "a"},
+		ModelB: &prefixModel{prefix: "b"},
+		Judge:  &preferJudge{prefer: "a"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one prompt")
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// CompressConfig configures [CompressionMiddleware].
+type CompressConfig struct {
+	// MaxPromptTokens is the estimated prompt size above which compression
+	// kicks in; shorter prompts pass through untouched. Defaults to 2000.
+	MaxPromptTokens int
+
+	// KeepRecentMessages is how many of the most recent non-system
+	// messages are kept verbatim when old turns are summarized. Defaults
+	// to 4.
+	KeepRecentMessages int
+
+	// Summarizer, when set, condenses the conversation turns older than
+	// KeepRecentMessages into a single context message using that model —
+	// typically a small, cheap Workers AI model. Nil limits compression to
+	// boilerplate stripping.
+	Summarizer ai.Model
+}
+
+// summarizePrompt instructs the summarizer model; the conversation to
+// condense follows as a user message.
+const summarizePrompt = "Summarize the following conversation in at most " +
+	"150 words, keeping every fact, name, number and decision needed to " +
+	"continue it. Reply with only the summary."
+
+// CompressionMiddleware returns a model middleware that shrinks long prompts
+// before they reach the model, cutting neuron costs on conversations that
+// accumulate history. Boilerplate whitespace is stripped from text parts,
+// and when a summarizer model is configured, turns older than the most
+// recent ones are replaced with a generated summary. The estimated before
+// and after token counts are recorded in the response's custom metadata
+// (promptTokensBefore, promptTokensAfter); requests below the size threshold
+// pass through untouched.
+func CompressionMiddleware(cfg *CompressConfig) ai.ModelMiddleware {
+	maxTokens := 2000
+	keepRecent := 4
+	var summarizer ai.Model
+	if cfg != nil {
+		if cfg.MaxPromptTokens > 0 {
+			maxTokens = cfg.MaxPromptTokens
+		}
+		if cfg.KeepRecentMessages > 0 {
+			keepRecent = cfg.KeepRecentMessages
+		}
+		summarizer = cfg.Summarizer
+	}
+
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			before := estimatePromptTokens(input.Messages)
+			if before <= maxTokens {
+				return next(ctx, input, cb)
+			}
+
+			messages := stripMessageBoilerplate(input.Messages)
+			if summarizer != nil {
+				messages = summarizeOldTurns(ctx, summarizer, messages, keepRecent)
+			}
+
+			compressed := *input
+			compressed.Messages = messages
+			resp, err := next(ctx, &compressed, cb)
+			if err == nil && resp != nil {
+				custom, ok := resp.Custom.(map[string]any)
+				if !ok {
+					custom = map[string]any{}
+				}
+				custom["promptTokensBefore"] = before
+				custom["promptTokensAfter"] = estimatePromptTokens(messages)
+				resp.Custom = custom
+			}
+			return resp, err
+		}
+	}
+}
+
+// estimatePromptTokens estimates the token count of a prompt using the usual
+// four-characters-per-token heuristic. Workers AI has no tokenizer endpoint,
+// so an estimate is the best available signal.
+func estimatePromptTokens(messages []*ai.Message) int {
+	chars := 0
+	for _, msg := range messages {
+		for _, part := range msg.Content {
+			if part.IsText() {
+				chars += len(part.Text)
+			}
+		}
+	}
+	return chars / 4
+}
+
+// The whitespace stripped during compression: trailing space before
+// newlines, three or more consecutive newlines, and tabs or runs of spaces,
+// which are normalized to a single space.
+var (
+	trailingSpace = regexp.MustCompile(`[ \t]+\n`)
+	blankRuns     = regexp.MustCompile(`\n{3,}`)
+	spaceRuns     = regexp.MustCompile(`\t[ \t]*| [ \t]+`)
+)
+
+// stripBoilerplate collapses the whitespace padding that inflates prompts
+// assembled from templates: trailing spaces, runs of blank lines and runs of
+// indentation.
+func stripBoilerplate(text string) string {
+	text = trailingSpace.ReplaceAllString(text, "\n")
+	text = blankRuns.ReplaceAllString(text, "\n\n")
+	text = spaceRuns.ReplaceAllString(text, " ")
+	return strings.TrimSpace(text)
+}
+
+// stripMessageBoilerplate returns a copy of the messages with boilerplate
+// whitespace stripped from every text part. Non-text parts are untouched.
+func stripMessageBoilerplate(messages []*ai.Message) []*ai.Message {
+	out := make([]*ai.Message, len(messages))
+	for i, msg := range messages {
+		stripped := *msg
+		stripped.Content = make([]*ai.Part, len(msg.Content))
+		for j, part := range msg.Content {
+			if part.IsText() {
+				stripped.Content[j] = ai.NewTextPart(stripBoilerplate(part.Text))
+			} else {
+				stripped.Content[j] = part
+			}
+		}
+		out[i] = &stripped
+	}
+	return out
+}
+
+// summarizeOldTurns replaces the conversation turns older than the most
+// recent keepRecent non-system messages with a single summary message
+// generated by the summarizer model. Summarization failures are deliberately
+// non-fatal: the original turns are kept and the request proceeds
+// uncompressed.
+func summarizeOldTurns(ctx context.Context, summarizer ai.Model, messages []*ai.Message, keepRecent int) []*ai.Message {
+	// System messages always survive; only conversation turns are
+	// condensed.
+	var system, turns []*ai.Message
+	for _, msg := range messages {
+		if msg.Role == ai.RoleSystem {
+			system = append(system, msg)
+		} else {
+			turns = append(turns, msg)
+		}
+	}
+	if len(turns) <= keepRecent {
+		return messages
+	}
+	old, recent := turns[:len(turns)-keepRecent], turns[len(turns)-keepRecent:]
+
+	var transcript strings.Builder
+	for _, msg := range old {
+		transcript.WriteString(string(msg.Role))
+		transcript.WriteString(": ")
+		transcript.WriteString(msg.Text())
+		transcript.WriteString("\n")
+	}
+	resp, err := summarizer.Generate(ctx, &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage(summarizePrompt),
+			ai.NewUserTextMessage(transcript.String()),
+		},
+	}, nil)
+	if err != nil || resp == nil || resp.Message == nil {
+		return messages
+	}
+
+	summary := ai.NewUserTextMessage("Summary of the conversation so far: " + resp.Message.Text())
+	out := make([]*ai.Message, 0, len(system)+1+len(recent))
+	out = append(out, system...)
+	out = append(out, summary)
+	out = append(out, recent...)
+	return out
+}
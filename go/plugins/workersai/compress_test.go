@@ -0,0 +1,100 @@
+package workersai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModel is a minimal ai.Model returning a canned text response.
+type fakeModel struct {
+	name     string
+	response string
+	requests []*ai.ModelRequest
+}
+
+func (m *fakeModel) Name() string { return m.name }
+
+func (m *fakeModel) Generate(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	m.requests = append(m.requests, req)
+	return &ai.ModelResponse{
+		Message:      ai.NewModelTextMessage(m.response),
+		FinishReason: ai.FinishReasonStop,
+	}, nil
+}
+
+func TestStripBoilerplate(t *testing.T) {
+	in := "Hello   world.   \n\n\n\n\nNext\tline.\n"
+	assert.Equal(t, "Hello world.\n\nNext line.", stripBoilerplate(in))
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	capture := func(got **ai.ModelRequest) ai.ModelFunc {
+		return func(_ context.Context, input *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			*got = input
+			return &ai.ModelResponse{Message: ai.NewModelTextMessage("ok")}, nil
+		}
+	}
+
+	t.Run("short prompts pass through untouched", func(t *testing.T) {
+		var got *ai.ModelRequest
+		mw := CompressionMiddleware(nil)(capture(&got))
+
+		input := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("hi     there")}}
+		resp, err := mw(context.Background(), input, nil)
+		require.NoError(t, err)
+		assert.Same(t, input, got)
+		assert.Nil(t, resp.Custom)
+	})
+
+	t.Run("long prompts are stripped and counted", func(t *testing.T) {
+		var got *ai.ModelRequest
+		mw := CompressionMiddleware(&CompressConfig{MaxPromptTokens: 10})(capture(&got))
+
+		padded := "padded   text" + strings.Repeat("   \n\n\n\nfiller", 20)
+		input := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage(padded)}}
+		resp, err := mw(context.Background(), input, nil)
+		require.NoError(t, err)
+
+		assert.NotContains(t, got.Messages[0].Text(), "   ")
+		custom, ok := resp.Custom.(map[string]any)
+		require.True(t, ok)
+		assert.Greater(t, custom["promptTokensBefore"].(int), custom["promptTokensAfter"].(int))
+	})
+
+	t.Run("old turns are summarized", func(t *testing.T) {
+		summarizer := &fakeModel{name: "summarizer", response: "they discussed cheese"}
+		var got *ai.ModelRequest
+		mw := CompressionMiddleware(&CompressConfig{
+			MaxPromptTokens:    1,
+			KeepRecentMessages: 2,
+			Summarizer:         summarizer,
+		})(capture(&got))
+
+		input := &ai.ModelRequest{Messages: []*ai.Message{
+			ai.NewSystemTextMessage("be helpful"),
+			ai.NewUserTextMessage("tell me about cheddar"),
+			ai.NewModelTextMessage("cheddar is a hard cheese"),
+			ai.NewUserTextMessage("and brie?"),
+			ai.NewModelTextMessage("brie is soft"),
+		}}
+		_, err := mw(context.Background(), input, nil)
+		require.NoError(t, err)
+
+		// System + summary + the two most recent turns.
+		require.Len(t, got.Messages, 4)
+		assert.Equal(t, ai.RoleSystem, got.Messages[0].Role)
+		assert.Contains(t, got.Messages[1].Text(), "they discussed cheese")
+		assert.Contains(t, got.Messages[2].Text(), "and brie?")
+
+		// The summarizer saw the old turns but not the recent ones.
+		require.Len(t, summarizer.requests, 1)
+		transcript := summarizer.requests[0].Messages[1].Text()
+		assert.Contains(t, transcript, "cheddar")
+		assert.NotContains(t, transcript, "brie")
+	})
+}
@@ -0,0 +1,326 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Config holds the Workers-AI-specific generation options for a single
+// Generate call. Generation parameters are merged into the request body;
+// gateway fields (cache controls) are sent as AI Gateway headers.
+type Config struct {
+	// Generation parameters, passed through to the model.
+	Temperature       float64 `json:"temperature,omitempty"`
+	MaxTokens         int     `json:"max_tokens,omitempty"`
+	TopP              float64 `json:"top_p,omitempty"`
+	TopK              int     `json:"top_k,omitempty"`
+	Seed              int     `json:"seed,omitempty"`
+	RepetitionPenalty float64 `json:"repetition_penalty,omitempty"`
+	FrequencyPenalty  float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty   float64 `json:"presence_penalty,omitempty"`
+	// StopSequences ends generation as soon as the model produces any of
+	// the given strings (the Workers AI stop parameter).
+	StopSequences []string `json:"stop,omitempty"`
+
+	// UnknownContent decides what happens to message content the wire
+	// format cannot carry (data parts, custom parts, unknown roles):
+	// skip with a warning (the default), fail the request, or stringify
+	// it into the prompt. See [UnknownContentPolicy].
+	UnknownContent UnknownContentPolicy `json:"unknownContent,omitempty"`
+
+	// GuidedRegex constrains decoding so the output matches the given
+	// regular expression, on models served with constrained decoding.
+	// Useful for formats a JSON schema cannot express, like ISO dates.
+	// Mutually exclusive with GuidedChoice and JSON output.
+	GuidedRegex string `json:"guidedRegex,omitempty"`
+	// GuidedChoice constrains decoding so the output is exactly one of
+	// the given strings, for fixed label sets. Mutually exclusive with
+	// GuidedRegex and JSON output.
+	GuidedChoice []string `json:"guidedChoice,omitempty"`
+
+	// Candidates asks the model for that many independent completions
+	// (the OpenAI-style n parameter). The first becomes the response
+	// message; the rest are surfaced through [Candidates]. Models that do
+	// not support sampling multiple completions return just one.
+	Candidates int `json:"candidates,omitempty"`
+
+	// StreamFlush controls how much text each streamed chunk carries:
+	// per part (the default), per token, or per sentence. See [FlushPolicy].
+	StreamFlush FlushPolicy `json:"streamFlush,omitempty"`
+	// StreamFlushTokens groups that many tokens per chunk when StreamFlush
+	// is [FlushPerToken]. Zero or one means one token per chunk.
+	StreamFlushTokens int `json:"streamFlushTokens,omitempty"`
+
+	// Jurisdiction restricts where Cloudflare may process the request, for
+	// deployments with data-residency requirements: [JurisdictionEU] or
+	// [JurisdictionFedRAMP]. Sent as a routing hint header, honored where
+	// Cloudflare's data-locality support applies; the serving colo is
+	// reported back in the response's custom metadata either way.
+	Jurisdiction string `json:"jurisdiction,omitempty"`
+
+	// CacheTTLSeconds opts the request into AI Gateway caching for the
+	// given number of seconds (sent as cf-aig-cache-ttl).
+	CacheTTLSeconds int `json:"cacheTtlSeconds,omitempty"`
+	// SkipCache bypasses AI Gateway caching for this request (sent as
+	// cf-aig-skip-cache).
+	SkipCache bool `json:"skipCache,omitempty"`
+	// Metadata tags the request in AI Gateway analytics (sent as
+	// cf-aig-metadata), so calls can be segmented by feature, tenant or
+	// experiment in the dashboard.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// ReasoningEffort hints how much a reasoning model (DeepSeek R1
+	// distills, qwen3) should think before answering: "low", "medium" or
+	// "high". Sent as the reasoning_effort body parameter; non-reasoning
+	// models ignore it.
+	ReasoningEffort string `json:"reasoningEffort,omitempty"`
+	// ShowReasoning surfaces the model's reasoning (its <think> output or
+	// reasoning_content) as a reasoning part ahead of the answer. By
+	// default reasoning is stripped and only the answer is returned.
+	ShowReasoning bool `json:"showReasoning,omitempty"`
+
+	// EmbeddedToolCalling resolves tool calls inside the plugin, in the
+	// style of Cloudflare's embedded function calling (beta): registered
+	// tools run immediately after the model call and only the final answer
+	// is returned. Requests involving tools not registered in this Genkit
+	// instance fall back to the standard client-side loop.
+	EmbeddedToolCalling bool `json:"embeddedToolCalling,omitempty"`
+
+	// StrictTools requests strict schema adherence for tool calls: tool
+	// schemas are sent with every property required and no additional
+	// properties allowed, and returned arguments are additionally validated
+	// against each tool's schema locally, so a call with unexpected or
+	// missing keys is rejected before the tool executes.
+	StrictTools bool `json:"strictTools,omitempty"`
+
+	// GatewayRoute routes the request through the named AI Gateway dynamic
+	// route instead of the registered model, so routing and fallback
+	// policies managed in the Cloudflare dashboard can be selected per
+	// request. Only meaningful for models registered in gateway provider
+	// mode.
+	GatewayRoute string `json:"gatewayRoute,omitempty"`
+
+	// MaxResponseBytes caps how many response bytes may accumulate before
+	// the request is aborted with [ai.FinishReasonLength] and a warning.
+	// It protects memory when a model goes into a repetition loop. Zero
+	// means no cap.
+	MaxResponseBytes int `json:"maxResponseBytes,omitempty"`
+
+	// RawFields are merged verbatim into the outgoing JSON request body,
+	// overriding any typed field above that targets the same key. It is an
+	// escape hatch for exercising Workers AI parameters the plugin has no
+	// typed support for yet.
+	RawFields map[string]any `json:"rawFields,omitempty"`
+
+	// ReturnPartialOnDeadline returns whatever has been accumulated with
+	// [ai.FinishReasonInterrupted] and truncation metadata when the request
+	// context's deadline expires, instead of failing with a deadline error.
+	ReturnPartialOnDeadline bool `json:"returnPartialOnDeadline,omitempty"`
+}
+
+// configFromRequest converts any supported config type to [Config].
+func configFromRequest(input *ai.ModelRequest) (*Config, error) {
+	var result Config
+
+	switch config := input.Config.(type) {
+	case Config:
+		result = config
+	case *Config:
+		result = *config
+	case ai.GenerationCommonConfig:
+		result = configFromCommon(&config)
+	case *ai.GenerationCommonConfig:
+		result = configFromCommon(config)
+	case map[string]any:
+		// This is the path dotprompt config blocks arrive through.
+		cfg, err := configFromMap(config)
+		if err != nil {
+			return nil, err
+		}
+		result = cfg
+	case nil:
+		// Empty but valid config.
+	default:
+		return nil, fmt.Errorf("unexpected config type: %T", input.Config)
+	}
+
+	return &result, nil
+}
+
+// configFromCommon maps Genkit's provider-agnostic config onto [Config].
+func configFromCommon(c *ai.GenerationCommonConfig) Config {
+	return Config{
+		Temperature:   c.Temperature,
+		MaxTokens:     c.MaxOutputTokens,
+		TopP:          c.TopP,
+		TopK:          c.TopK,
+		StopSequences: c.StopSequences,
+	}
+}
+
+// commonConfigKeys are the provider-agnostic option names Genkit and
+// dotprompt front matter use; they are mapped onto [Config] rather than
+// rejected as unknown fields.
+var commonConfigKeys = map[string]bool{
+	"maxOutputTokens": true,
+	"stopSequences":   true,
+	"temperature":     true,
+	"topK":            true,
+	"topP":            true,
+	"version":         true,
+}
+
+// configFromMap decodes an untyped config block (for example from a .prompt
+// file) into [Config], validating it against the provider config schema.
+// Unknown fields produce an error rather than being silently dropped, so
+// typos in .prompt config blocks fail loudly. Provider-agnostic option names
+// (maxOutputTokens, topP, ...) are accepted alongside the provider-specific
+// ones.
+func configFromMap(m map[string]any) (Config, error) {
+	var result Config
+
+	providerFields := make(map[string]any, len(m))
+	commonFields := make(map[string]any)
+	for k, v := range m {
+		if commonConfigKeys[k] && k != "temperature" {
+			commonFields[k] = v
+			continue
+		}
+		providerFields[k] = v
+	}
+
+	data, err := json.Marshal(providerFields)
+	if err != nil {
+		return result, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&result); err != nil {
+		return result, fmt.Errorf("invalid workersai config: %w", err)
+	}
+
+	if len(commonFields) > 0 {
+		var common ai.GenerationCommonConfig
+		if err := mapToStruct(commonFields, &common); err != nil {
+			return result, fmt.Errorf("invalid workersai config: %w", err)
+		}
+		if result.MaxTokens == 0 {
+			result.MaxTokens = common.MaxOutputTokens
+		}
+		if result.TopP == 0 {
+			result.TopP = common.TopP
+		}
+		if result.TopK == 0 {
+			result.TopK = common.TopK
+		}
+		if result.StopSequences == nil {
+			result.StopSequences = common.StopSequences
+		}
+	}
+	return result, nil
+}
+
+// mapToStruct unmarshals a map[string]any to the expected config type.
+func mapToStruct(m map[string]any, v any) error {
+	jsonData, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonData, v)
+}
+
+// bodyOverrides returns request-body fields that must replace what the
+// client serialized. Dynamic routes are addressed on the unified endpoint by
+// sending "dynamic/<route>" as the model name.
+func (c *Config) bodyOverrides() map[string]any {
+	overrides := map[string]any{}
+	if c.GatewayRoute != "" {
+		overrides["model"] = "dynamic/" + c.GatewayRoute
+	}
+	return overrides
+}
+
+// requestHeaders returns the per-request AI Gateway headers implied by the
+// config.
+func (c *Config) requestHeaders() map[string]string {
+	headers := map[string]string{}
+	if c.CacheTTLSeconds > 0 {
+		headers["cf-aig-cache-ttl"] = strconv.Itoa(c.CacheTTLSeconds)
+	}
+	if c.SkipCache {
+		headers["cf-aig-skip-cache"] = "true"
+	}
+	if c.Jurisdiction != "" {
+		headers[jurisdictionHeader] = c.Jurisdiction
+	}
+	if len(c.Metadata) > 0 {
+		// Marshaling a map[string]string cannot fail, and json.Marshal
+		// emits keys in sorted order, so the header value is stable.
+		meta, _ := json.Marshal(c.Metadata)
+		headers["cf-aig-metadata"] = string(meta)
+	}
+	return headers
+}
+
+// bodyParams returns the generation parameters to merge into the request
+// body. The vendored client only serializes model/messages/tools, so these
+// are injected at the transport layer.
+func (c *Config) bodyParams() map[string]any {
+	params := map[string]any{}
+	if c.Temperature != 0 {
+		params["temperature"] = c.Temperature
+	}
+	if c.MaxTokens != 0 {
+		params["max_tokens"] = c.MaxTokens
+	}
+	if c.TopP != 0 {
+		params["top_p"] = c.TopP
+	}
+	if c.TopK != 0 {
+		params["top_k"] = c.TopK
+	}
+	if c.Seed != 0 {
+		params["seed"] = c.Seed
+	}
+	if c.RepetitionPenalty != 0 {
+		params["repetition_penalty"] = c.RepetitionPenalty
+	}
+	if c.FrequencyPenalty != 0 {
+		params["frequency_penalty"] = c.FrequencyPenalty
+	}
+	if c.PresencePenalty != 0 {
+		params["presence_penalty"] = c.PresencePenalty
+	}
+	if len(c.StopSequences) > 0 {
+		params["stop"] = c.StopSequences
+	}
+	if c.ReasoningEffort != "" {
+		params["reasoning_effort"] = c.ReasoningEffort
+	}
+	if c.Candidates > 1 {
+		params["n"] = c.Candidates
+	}
+	for k, v := range c.RawFields {
+		params[k] = v
+	}
+	return params
+}
@@ -0,0 +1,145 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromRequest(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    any
+		expected  Config
+		expectErr bool
+	}{
+		{
+			name:     "typed config is used as-is",
+			config:   &Config{Temperature: 0.2, CacheTTLSeconds: 60},
+			expected: Config{Temperature: 0.2, CacheTTLSeconds: 60},
+		},
+		{
+			name:     "common config is mapped",
+			config:   &ai.GenerationCommonConfig{Temperature: 0.7, MaxOutputTokens: 128, StopSequences: []string{"END"}},
+			expected: Config{Temperature: 0.7, MaxTokens: 128, StopSequences: []string{"END"}},
+		},
+		{
+			name:     "map config is decoded",
+			config:   map[string]any{"temperature": 0.5, "skipCache": true},
+			expected: Config{Temperature: 0.5, SkipCache: true},
+		},
+		{
+			name:     "nil config yields the zero value",
+			config:   nil,
+			expected: Config{},
+		},
+		{
+			name:     "map config accepts common option names",
+			config:   map[string]any{"maxOutputTokens": 64, "topP": 0.9, "stopSequences": []any{"END", "\n\n"}},
+			expected: Config{MaxTokens: 64, TopP: 0.9, StopSequences: []string{"END", "\n\n"}},
+		},
+		{
+			name:     "provider stop key wins over stopSequences",
+			config:   map[string]any{"stop": []any{"</s>"}, "stopSequences": []any{"END"}},
+			expected: Config{StopSequences: []string{"</s>"}},
+		},
+		{
+			name:     "map config passes raw fields through",
+			config:   map[string]any{"rawFields": map[string]any{"logprobs": true}},
+			expected: Config{RawFields: map[string]any{"logprobs": true}},
+		},
+		{
+			name:      "map config rejects unknown fields",
+			config:    map[string]any{"temprature": 0.5},
+			expectErr: true,
+		},
+		{
+			name:      "unsupported config type errors",
+			config:    42,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := configFromRequest(&ai.ModelRequest{Config: tc.config})
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, *got)
+		})
+	}
+}
+
+func TestConfigRequestHeaders(t *testing.T) {
+	cfg := &Config{
+		CacheTTLSeconds: 300,
+		SkipCache:       true,
+		Metadata:        map[string]string{"tenant": "acme", "feature": "chat"},
+	}
+	headers := cfg.requestHeaders()
+	assert.Equal(t, "300", headers["cf-aig-cache-ttl"])
+	assert.Equal(t, "true", headers["cf-aig-skip-cache"])
+	assert.Equal(t, `{"feature":"chat","tenant":"acme"}`, headers["cf-aig-metadata"])
+
+	assert.Empty(t, (&Config{}).requestHeaders())
+}
+
+func TestConfigBodyParamsRawFields(t *testing.T) {
+	cfg := &Config{
+		Temperature: 0.7,
+		RawFields:   map[string]any{"temperature": 0.1, "logprobs": true},
+	}
+	params := cfg.bodyParams()
+	// Raw fields win over typed fields targeting the same key.
+	assert.Equal(t, 0.1, params["temperature"])
+	assert.Equal(t, true, params["logprobs"])
+}
+
+func TestConfigBodyParamsStopSequences(t *testing.T) {
+	params := (&Config{StopSequences: []string{"END"}}).bodyParams()
+	assert.Equal(t, []string{"END"}, params["stop"])
+
+	_, ok := (&Config{}).bodyParams()["stop"]
+	assert.False(t, ok)
+}
+
+func TestGuidedDecodingParams(t *testing.T) {
+	t.Run("regex", func(t *testing.T) {
+		params, err := guidedDecodingParams(&Config{GuidedRegex: `\d{4}-\d{2}-\d{2}`}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, `\d{4}-\d{2}-\d{2}`, params["guided_regex"])
+	})
+
+	t.Run("choice", func(t *testing.T) {
+		params, err := guidedDecodingParams(&Config{GuidedChoice: []string{"spam", "ham"}}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"spam", "ham"}, params["guided_choice"])
+	})
+
+	t.Run("neither", func(t *testing.T) {
+		params, err := guidedDecodingParams(&Config{}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, params)
+	})
+
+	t.Run("both rejected", func(t *testing.T) {
+		_, err := guidedDecodingParams(&Config{GuidedRegex: "a+", GuidedChoice: []string{"a"}}, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("json output rejected", func(t *testing.T) {
+		_, err := guidedDecodingParams(&Config{GuidedRegex: "a+"},
+			&ai.ModelOutputConfig{Format: ai.OutputFormatJSON})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid regex rejected", func(t *testing.T) {
+		_, err := guidedDecodingParams(&Config{GuidedRegex: "("}, nil)
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/internal/base"
+	"github.com/pkg/errors"
+)
+
+// A conformanceCheck exercises one Genkit capability against a model and
+// judges the response.
+type conformanceCheck struct {
+	capability string
+	declared   func(s *ai.ModelSupports) bool
+	request    *ai.ModelRequest
+	judge      func(resp *ai.ModelResponse) bool
+}
+
+// CapabilityResult is the outcome of one capability check against one model.
+type CapabilityResult struct {
+	// Capability names the checked feature: systemRole, multiTurn, tools,
+	// jsonOutput or media.
+	Capability string `json:"capability"`
+	// Declared is what the model's registered Supports flags claim.
+	Declared bool `json:"declared"`
+	// Worked is whether the capability actually worked against the live
+	// model.
+	Worked bool `json:"worked"`
+	// Detail describes a failure: the request error, or a note that the
+	// response did not match the expected shape.
+	Detail string `json:"detail,omitempty"`
+}
+
+// ModelConformance holds one model's results across the capability matrix.
+type ModelConformance struct {
+	Model   string             `json:"model"`
+	Results []CapabilityResult `json:"results"`
+}
+
+// Mismatches returns the results whose declared and observed behavior
+// disagree — declared capabilities that failed, and undeclared ones that
+// worked anyway.
+func (m *ModelConformance) Mismatches() []CapabilityResult {
+	var out []CapabilityResult
+	for _, r := range m.Results {
+		if r.Declared != r.Worked {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ConformanceReport is the result of one [RunConformance] run.
+type ConformanceReport struct {
+	Models []ModelConformance `json:"models"`
+}
+
+// RunConformance executes a canonical matrix of Genkit features — system
+// role, multi-turn context, tool calling, JSON output and media input —
+// against each of the given models and reports which capabilities actually
+// work, alongside what the catalog declares. The declared Supports flags in
+// models.go are aspirational; this runner is how they get verified against
+// the live API. Every check costs a model call, so expect
+// models x 5 requests.
+func RunConformance(ctx context.Context, models []ai.Model) (*ConformanceReport, error) {
+	if len(models) == 0 {
+		return nil, errors.New("workersai: RunConformance requires at least one model")
+	}
+
+	report := &ConformanceReport{}
+	for _, model := range models {
+		supports := declaredSupports(model.Name())
+		mc := ModelConformance{Model: model.Name()}
+		for _, check := range conformanceChecks() {
+			result := CapabilityResult{
+				Capability: check.capability,
+				Declared:   check.declared(supports),
+			}
+			resp, err := model.Generate(ctx, check.request, nil)
+			switch {
+			case err != nil:
+				result.Detail = err.Error()
+			case check.judge(resp):
+				result.Worked = true
+			default:
+				result.Detail = "response did not match the expected shape"
+			}
+			mc.Results = append(mc.Results, result)
+		}
+		report.Models = append(report.Models, mc)
+	}
+	return report, nil
+}
+
+// declaredSupports looks up the capabilities the catalog declares for a
+// model, accepting both bare and provider-prefixed registry names. Unknown
+// models get the conservative catalog defaults.
+func declaredSupports(name string) *ai.ModelSupports {
+	name = strings.TrimPrefix(name, provider+"/")
+	if info, ok := supportedWorkersAIModels[name]; ok {
+		return info.Supports
+	}
+	return catalogModelInfo(name).Supports
+}
+
+// conformanceChecks returns the canonical capability matrix. Each check
+// uses a prompt with an unambiguous golden response, so judging stays
+// cheap and deterministic.
+func conformanceChecks() []conformanceCheck {
+	// A 1x1 transparent PNG, the smallest media input a vision model
+	// should accept.
+	const tinyPNG = "data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="
+
+	return []conformanceCheck{
+		{
+			capability: "systemRole",
+			declared:   func(s *ai.ModelSupports) bool { return s != nil && s.SystemRole },
+			request: &ai.ModelRequest{Messages: []*ai.Message{
+				ai.NewSystemTextMessage("Always answer with exactly the word PONG."),
+				ai.NewUserTextMessage("ping"),
+			}},
+			judge: func(resp *ai.ModelResponse) bool {
+				return strings.Contains(strings.ToLower(resp.Text()), "pong")
+			},
+		},
+		{
+			capability: "multiTurn",
+			declared:   func(s *ai.ModelSupports) bool { return s != nil && s.Multiturn },
+			request: &ai.ModelRequest{Messages: []*ai.Message{
+				ai.NewUserTextMessage("My name is Ada."),
+				ai.NewModelTextMessage("Nice to meet you, Ada."),
+				ai.NewUserTextMessage("What is my name? Answer with just the name."),
+			}},
+			judge: func(resp *ai.ModelResponse) bool {
+				return strings.Contains(strings.ToLower(resp.Text()), "ada")
+			},
+		},
+		{
+			capability: "tools",
+			declared:   func(s *ai.ModelSupports) bool { return s != nil && s.Tools },
+			request: &ai.ModelRequest{
+				Messages: []*ai.Message{
+					ai.NewUserTextMessage("What is the weather in Lisbon right now? Use the tool."),
+				},
+				Tools: []*ai.ToolDefinition{{
+					Name:        "getWeather",
+					Description: "Returns the current weather for a city.",
+					InputSchema: map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"city": map[string]any{"type": "string"},
+						},
+						"required": []any{"city"},
+					},
+				}},
+			},
+			judge: func(resp *ai.ModelResponse) bool {
+				if resp.Message == nil {
+					return false
+				}
+				for _, part := range resp.Message.Content {
+					if part.IsToolRequest() && part.ToolRequest.Name == "getWeather" {
+						return true
+					}
+				}
+				return false
+			},
+		},
+		{
+			capability: "jsonOutput",
+			declared:   func(s *ai.ModelSupports) bool { return s != nil && s.Constrained != ai.ConstrainedSupportNone },
+			request: &ai.ModelRequest{
+				Messages: []*ai.Message{
+					ai.NewUserTextMessage(`Return a JSON object with a "city" string field set to "Lisbon".`),
+				},
+				Output: &ai.ModelOutputConfig{Format: ai.OutputFormatJSON},
+			},
+			judge: func(resp *ai.ModelResponse) bool {
+				cleaned := base.ExtractJSONFromMarkdown(resp.Text())
+				var parsed map[string]any
+				return json.Unmarshal([]byte(cleaned), &parsed) == nil
+			},
+		},
+		{
+			capability: "media",
+			declared:   func(s *ai.ModelSupports) bool { return s != nil && s.Media },
+			request: &ai.ModelRequest{Messages: []*ai.Message{{
+				Role: ai.RoleUser,
+				Content: []*ai.Part{
+					ai.NewTextPart("Describe this image in one sentence."),
+					ai.NewMediaPart("image/png", tinyPNG),
+				},
+			}}},
+			judge: func(resp *ai.ModelResponse) bool {
+				return strings.TrimSpace(resp.Text()) != ""
+			},
+		},
+	}
+}
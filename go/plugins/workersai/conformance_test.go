@@ -0,0 +1,115 @@
+package workersai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedModel answers each capability probe according to its flags,
+// simulating a model whose real behavior may diverge from the catalog.
+type scriptedModel struct {
+	name      string
+	obeyPong  bool
+	recallAda bool
+	callTool  bool
+	emitJSON  bool
+	seeMedia  bool
+}
+
+func (m *scriptedModel) Name() string { return m.name }
+
+func (m *scriptedModel) Generate(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	reply := func(text string) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage(text)}, nil
+	}
+
+	switch {
+	case len(req.Tools) > 0:
+		if m.callTool {
+			return &ai.ModelResponse{Message: &ai.Message{
+				Role: ai.RoleModel,
+				Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+					Name:  "getWeather",
+					Input: map[string]any{"city": "Lisbon"},
+				})},
+			}}, nil
+		}
+		return reply("It is sunny, probably.")
+	case req.Output != nil && req.Output.Format == ai.OutputFormatJSON:
+		if m.emitJSON {
+			return reply(`{"city": "Lisbon"}`)
+		}
+		return reply("Sure! The city is Lisbon.")
+	case len(req.Messages) > 0 && req.Messages[0].Role == ai.RoleSystem:
+		if m.obeyPong {
+			return reply("PONG")
+		}
+		return reply("Hello! How can I help?")
+	case len(req.Messages) > 1:
+		if m.recallAda {
+			return reply("Ada")
+		}
+		return reply("I do not know your name.")
+	default:
+		// Media probe: a single user message with an image part.
+		if m.seeMedia {
+			return reply("A tiny transparent square.")
+		}
+		return reply("")
+	}
+}
+
+func TestRunConformance(t *testing.T) {
+	// A model that does everything its curated catalog entry declares.
+	good := &scriptedModel{
+		name:     provider + "/" + ModelLlama4Scout17B16EInstruct,
+		obeyPong: true, recallAda: true, callTool: true, emitJSON: true, seeMedia: true,
+	}
+	// A model that fails its declared tool support but handles JSON fine.
+	liar := &scriptedModel{
+		name:     ModelLlama3370BInstructFp8Fast,
+		obeyPong: true, recallAda: true, emitJSON: true,
+	}
+
+	report, err := RunConformance(context.Background(), []ai.Model{good, liar})
+	require.NoError(t, err)
+	require.Len(t, report.Models, 2)
+
+	for _, result := range report.Models[0].Results {
+		if result.Capability == "media" {
+			// Declared false for this model, but the probe worked: a
+			// mismatch worth reporting, not an error.
+			assert.True(t, result.Worked)
+			continue
+		}
+		assert.True(t, result.Worked, result.Capability)
+	}
+
+	mismatches := report.Models[1].Mismatches()
+	var names []string
+	for _, m := range mismatches {
+		names = append(names, m.Capability)
+	}
+	assert.Contains(t, strings.Join(names, ","), "tools")
+}
+
+func TestRunConformanceValidation(t *testing.T) {
+	_, err := RunConformance(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestDeclaredSupports(t *testing.T) {
+	// Prefixed and bare names resolve to the same curated entry.
+	assert.Equal(t,
+		declaredSupports(ModelLlama4Scout17B16EInstruct),
+		declaredSupports(provider+"/"+ModelLlama4Scout17B16EInstruct))
+	// Unknown models get the conservative catalog defaults.
+	s := declaredSupports("@cf/somebody/brand-new-model")
+	require.NotNil(t, s)
+	assert.False(t, s.Tools)
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// correlationHeader carries the caller's correlation ID to Cloudflare, so
+// one identifier ties together the caller's logs, the plugin's trace and
+// the gateway's request logs.
+const correlationHeader = "X-Correlation-Id"
+
+// correlationContextKey carries the correlation ID through a request context.
+type correlationContextKey struct{}
+
+// WithCorrelationID returns a context carrying a correlation ID that is
+// forwarded to Cloudflare as the X-Correlation-Id header on requests made
+// with it.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationContextKey{}, id)
+}
+
+// correlationIDFromContext returns the ID set by [WithCorrelationID], or "".
+func correlationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationContextKey{}).(string)
+	return id
+}
+
+// recordCorrelation attaches the response's cf-ray and AI Gateway log ID to
+// the model call span and returns the ray ID, which error messages quote so
+// a failure can be handed to Cloudflare support as-is. Both come from the
+// most recent response seen by the request's capture layer; either may be
+// empty.
+func recordCorrelation(ctx context.Context, capture *captureTransport) string {
+	ray := capture.lastHeader("cf-ray")
+	logID := capture.lastHeader("cf-aig-log-id")
+
+	span := trace.SpanFromContext(ctx)
+	if ray != "" {
+		span.SetAttributes(attribute.String("workersai.cfRay", ray))
+		if colo := servingColo(ray); colo != "" {
+			span.SetAttributes(attribute.String("workersai.colo", colo))
+		}
+	}
+	if logID != "" {
+		span.SetAttributes(attribute.String("workersai.gatewayLogId", logID))
+	}
+	return ray
+}
@@ -0,0 +1,27 @@
+package workersai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCorrelationID(t *testing.T) {
+	assert.Empty(t, correlationIDFromContext(context.Background()))
+	ctx := WithCorrelationID(context.Background(), "req-1234")
+	assert.Equal(t, "req-1234", correlationIDFromContext(ctx))
+}
+
+func TestRecordCorrelation(t *testing.T) {
+	capture := &captureTransport{}
+
+	// No response captured yet.
+	assert.Empty(t, recordCorrelation(context.Background(), capture))
+
+	capture.header = http.Header{}
+	capture.header.Set("cf-ray", "8891aab2cd-LIS")
+	capture.header.Set("cf-aig-log-id", "log-42")
+	assert.Equal(t, "8891aab2cd-LIS", recordCorrelation(context.Background(), capture))
+}
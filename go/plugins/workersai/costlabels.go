@@ -0,0 +1,102 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// costLabelContextKey carries a cost-attribution label through a request
+// context.
+type costLabelContextKey struct{}
+
+// WithCostLabel returns a context carrying a cost-attribution label,
+// typically set by flow middleware naming the product feature the request
+// serves. Usage is aggregated per label and available through
+// [WorkersAI.CostByLabel], so the Cloudflare bill can be split by feature.
+func WithCostLabel(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, costLabelContextKey{}, label)
+}
+
+// costLabelFromContext returns the label set by [WithCostLabel], or "".
+func costLabelFromContext(ctx context.Context) string {
+	label, _ := ctx.Value(costLabelContextKey{}).(string)
+	return label
+}
+
+// CostLabelUsage is the accumulated consumption attributed to one label.
+// Counters only grow; periodic scrapers derive per-window numbers from
+// deltas. Cost in currency is deliberately not computed here — neuron rates
+// vary per model and pricing knowledge stays with the caller.
+type CostLabelUsage struct {
+	// Requests is the number of model calls, successful or not.
+	Requests int
+	// Failures is how many of those calls returned an error.
+	Failures int
+	// InputTokens and OutputTokens total the token usage of the
+	// successful calls.
+	InputTokens  int
+	OutputTokens int
+}
+
+// costTracker aggregates usage per cost label. The zero value is ready to
+// use.
+type costTracker struct {
+	mu      sync.Mutex
+	byLabel map[string]*CostLabelUsage
+}
+
+// record attributes one model call to the context's label. Requests without
+// a label are aggregated under the empty label, so untagged traffic still
+// shows up in the split rather than vanishing.
+func (t *costTracker) record(ctx context.Context, resp *ai.ModelResponse, err error) {
+	label := costLabelFromContext(ctx)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.byLabel == nil {
+		t.byLabel = map[string]*CostLabelUsage{}
+	}
+	usage := t.byLabel[label]
+	if usage == nil {
+		usage = &CostLabelUsage{}
+		t.byLabel[label] = usage
+	}
+	usage.Requests++
+	if err != nil {
+		usage.Failures++
+		return
+	}
+	if resp != nil && resp.Usage != nil {
+		usage.InputTokens += resp.Usage.InputTokens
+		usage.OutputTokens += resp.Usage.OutputTokens
+	}
+}
+
+// CostByLabel returns a snapshot of the usage accumulated per
+// cost-attribution label since the plugin was initialized. The empty label
+// collects requests made without [WithCostLabel].
+func (w *WorkersAI) CostByLabel() map[string]CostLabelUsage {
+	w.costs.mu.Lock()
+	defer w.costs.mu.Unlock()
+	out := make(map[string]CostLabelUsage, len(w.costs.byLabel))
+	for label, usage := range w.costs.byLabel {
+		out[label] = *usage
+	}
+	return out
+}
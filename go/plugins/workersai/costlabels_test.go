@@ -0,0 +1,41 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCostByLabel(t *testing.T) {
+	w := &WorkersAI{}
+	resp := func(in, out int) *ai.ModelResponse {
+		return &ai.ModelResponse{Usage: &ai.GenerationUsage{InputTokens: in, OutputTokens: out}}
+	}
+
+	search := WithCostLabel(context.Background(), "search")
+	w.costs.record(search, resp(100, 20), nil)
+	w.costs.record(search, resp(50, 10), nil)
+	w.costs.record(search, nil, errors.New("boom"))
+	w.costs.record(WithCostLabel(context.Background(), "summarize"), resp(10, 5), nil)
+	w.costs.record(context.Background(), resp(1, 1), nil)
+
+	usage := w.CostByLabel()
+	assert.Equal(t, CostLabelUsage{
+		Requests:     3,
+		Failures:     1,
+		InputTokens:  150,
+		OutputTokens: 30,
+	}, usage["search"])
+	assert.Equal(t, 1, usage["summarize"].Requests)
+
+	// Untagged traffic is collected under the empty label.
+	assert.Equal(t, 1, usage[""].Requests)
+
+	// The snapshot is a copy; mutating it does not touch the counters.
+	entry := usage["search"]
+	entry.Requests = 0
+	assert.Equal(t, 3, w.CostByLabel()["search"].Requests)
+}
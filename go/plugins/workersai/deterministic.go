@@ -0,0 +1,80 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"github.com/pkg/errors"
+)
+
+// defaultDeterministicSeed is the seed forced onto requests when the
+// deterministic config does not pick one.
+const defaultDeterministicSeed = 1
+
+// DeterministicConfig pins every request to reproducible settings so
+// golden-file assertions in app tests stop flaking: temperature is forced to
+// zero, a fixed seed is applied, and models can be pinned to stable
+// snapshots. Requests that would defeat determinism — an explicit non-zero
+// temperature, top_p, a conflicting seed, or raw sampling fields — are
+// rejected with an error rather than silently overridden, so a flaky test
+// points at the request that caused it.
+type DeterministicConfig struct {
+	// Seed is forced into every request. Defaults to 1.
+	Seed int
+
+	// PinnedModels maps a model name to the stable snapshot to actually
+	// call, for keeping golden files valid while an alias (for example a
+	// "-fast" variant) is re-pointed upstream. Models without an entry
+	// are called as addressed.
+	PinnedModels map[string]string
+}
+
+// sampling fields that defeat determinism when injected via RawFields.
+var nondeterministicRawFields = []string{"temperature", "top_p", "top_k", "seed"}
+
+// apply rewrites the request config for determinism, or reports why the
+// request is incompatible with it.
+func (d *DeterministicConfig) apply(cfg *Config) error {
+	seed := d.Seed
+	if seed == 0 {
+		seed = defaultDeterministicSeed
+	}
+
+	if cfg.Temperature != 0 {
+		return errors.Errorf("deterministic mode forbids temperature %g; leave it unset", cfg.Temperature)
+	}
+	if cfg.TopP != 0 {
+		return errors.Errorf("deterministic mode forbids top_p %g; leave it unset", cfg.TopP)
+	}
+	if cfg.Seed != 0 && cfg.Seed != seed {
+		return errors.Errorf("deterministic mode pins the seed to %d; remove the per-request seed %d", seed, cfg.Seed)
+	}
+	for _, field := range nondeterministicRawFields {
+		if _, ok := cfg.RawFields[field]; ok {
+			return errors.Errorf("deterministic mode forbids the raw %q field", field)
+		}
+	}
+
+	cfg.Seed = seed
+	return nil
+}
+
+// pinned returns the snapshot to call for a model, or the model unchanged.
+func (d *DeterministicConfig) pinned(model string) string {
+	if snapshot, ok := d.PinnedModels[model]; ok && snapshot != "" {
+		return snapshot
+	}
+	return model
+}
@@ -0,0 +1,55 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterministicConfigApply(t *testing.T) {
+	det := &DeterministicConfig{Seed: 7}
+
+	t.Run("forces the seed", func(t *testing.T) {
+		cfg := &Config{}
+		require.NoError(t, det.apply(cfg))
+		assert.Equal(t, 7, cfg.Seed)
+	})
+
+	t.Run("matching seed is allowed", func(t *testing.T) {
+		cfg := &Config{Seed: 7}
+		require.NoError(t, det.apply(cfg))
+	})
+
+	t.Run("defaults the seed", func(t *testing.T) {
+		cfg := &Config{}
+		require.NoError(t, (&DeterministicConfig{}).apply(cfg))
+		assert.Equal(t, defaultDeterministicSeed, cfg.Seed)
+	})
+
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+	}{
+		{"temperature", Config{Temperature: 0.7}},
+		{"top_p", Config{TopP: 0.9}},
+		{"conflicting seed", Config{Seed: 99}},
+		{"raw temperature", Config{RawFields: map[string]any{"temperature": 1.0}}},
+		{"raw top_k", Config{RawFields: map[string]any{"top_k": 40}}},
+	} {
+		t.Run(tc.name+" is rejected", func(t *testing.T) {
+			err := det.apply(&tc.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "deterministic mode")
+		})
+	}
+}
+
+func TestDeterministicConfigPinned(t *testing.T) {
+	det := &DeterministicConfig{PinnedModels: map[string]string{
+		ModelLlama3370BInstructFp8Fast: "@cf/meta/llama-3.3-70b-instruct-fp8-fast-2025-01",
+	}}
+	assert.Equal(t, "@cf/meta/llama-3.3-70b-instruct-fp8-fast-2025-01",
+		det.pinned(ModelLlama3370BInstructFp8Fast))
+	assert.Equal(t, ModelQwen330BA3BFp8, det.pinned(ModelQwen330BA3BFp8))
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// downgradeWindow is how long an observed rate-limit signal keeps triggering
+// downgrades before it is considered stale.
+const downgradeWindow = time.Minute
+
+// downgradeMinRequests is how many requests the health window must hold
+// before its error rate is trusted as a downgrade signal, so a single early
+// failure does not downgrade everything.
+const downgradeMinRequests = 5
+
+// DowngradePolicy automatically switches requests to a cheaper model while
+// the API is under pressure, so callers keep getting answers — at reduced
+// quality — instead of errors. A downgrade is annotated in the response's
+// custom metadata (downgradedFrom, downgradedTo, downgradeReason) so callers
+// can display a reduced-quality notice.
+type DowngradePolicy struct {
+	// Models maps a model name to the cheaper model to fall back to while
+	// the policy is triggered. Models without an entry are never
+	// downgraded.
+	Models map[string]string
+
+	// MinRemaining triggers the policy while the API-reported remaining
+	// request budget is at or below this value. The default of zero
+	// downgrades only once the budget is fully exhausted.
+	MinRemaining int
+
+	// UnhealthyErrorRate triggers the policy while the recent error rate
+	// (as tracked for [WorkersAI.Status]) is at or above this value.
+	// Defaults to the health handler's threshold of 0.5.
+	UnhealthyErrorRate float64
+}
+
+// reason reports why the policy is currently triggered, or "" when it is not.
+func (p *DowngradePolicy) reason(rateLimit *rateLimitState, health *healthState) string {
+	if info, at := rateLimit.getWithTime(); info != nil && time.Since(at) < downgradeWindow {
+		if info.RetryAfter > 0 || (info.Limit > 0 && info.Remaining <= p.MinRemaining) {
+			return "rate-limited"
+		}
+	}
+
+	threshold := p.UnhealthyErrorRate
+	if threshold == 0 {
+		threshold = defaultUnhealthyErrorRate
+	}
+	requests, failures, _, _ := health.snapshot()
+	if requests >= downgradeMinRequests && float64(failures)/float64(requests) >= threshold {
+		return "unhealthy"
+	}
+	return ""
+}
+
+// downgradeTarget returns the generator and reason to downgrade to, or the
+// receiver unchanged when the plugin's policy is absent, has no mapping for
+// this model, or is not triggered.
+func (gen *generator) downgradeTarget() (*generator, string) {
+	p := gen.plugin
+	if p == nil || p.Downgrade == nil {
+		return gen, ""
+	}
+	cheaper, ok := p.Downgrade.Models[gen.model]
+	if !ok || cheaper == gen.model {
+		return gen, ""
+	}
+	reason := p.Downgrade.reason(&p.rateLimit, &p.health)
+	if reason == "" {
+		return gen, ""
+	}
+	info, ok := supportedWorkersAIModels[cheaper]
+	if !ok {
+		info = catalogModelInfo(cheaper)
+	}
+	return &generator{model: cheaper, info: info, client: gen.client, plugin: p}, reason
+}
+
+// annotateDowngrade records the downgrade in the response's custom metadata.
+func annotateDowngrade(resp *ai.ModelResponse, from, to, reason string) {
+	if resp == nil {
+		return
+	}
+	custom, ok := resp.Custom.(map[string]any)
+	if !ok {
+		custom = map[string]any{}
+	}
+	custom["downgradedFrom"] = from
+	custom["downgradedTo"] = to
+	custom["downgradeReason"] = reason
+	resp.Custom = custom
+}
@@ -0,0 +1,95 @@
+package workersai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDowngradePolicyReason(t *testing.T) {
+	policy := &DowngradePolicy{}
+
+	t.Run("quiet state does not trigger", func(t *testing.T) {
+		assert.Empty(t, policy.reason(&rateLimitState{}, &healthState{}))
+	})
+
+	t.Run("fresh exhausted budget triggers", func(t *testing.T) {
+		state := &rateLimitState{}
+		state.set(&RateLimitInfo{Limit: 100, Remaining: 0})
+		assert.Equal(t, "rate-limited", policy.reason(state, &healthState{}))
+	})
+
+	t.Run("remaining budget does not trigger", func(t *testing.T) {
+		state := &rateLimitState{}
+		state.set(&RateLimitInfo{Limit: 100, Remaining: 40})
+		assert.Empty(t, policy.reason(state, &healthState{}))
+	})
+
+	t.Run("stale signal does not trigger", func(t *testing.T) {
+		state := &rateLimitState{}
+		state.set(&RateLimitInfo{Limit: 100, Remaining: 0})
+		state.at = time.Now().Add(-2 * downgradeWindow)
+		assert.Empty(t, policy.reason(state, &healthState{}))
+	})
+
+	t.Run("retry-after triggers", func(t *testing.T) {
+		state := &rateLimitState{}
+		state.set(&RateLimitInfo{RetryAfter: 30 * time.Second})
+		assert.Equal(t, "rate-limited", policy.reason(state, &healthState{}))
+	})
+
+	t.Run("elevated error rate triggers", func(t *testing.T) {
+		health := &healthState{}
+		for i := 0; i < downgradeMinRequests; i++ {
+			health.record(false, "upstream error")
+		}
+		assert.Equal(t, "unhealthy", policy.reason(&rateLimitState{}, health))
+	})
+
+	t.Run("too few samples do not trigger", func(t *testing.T) {
+		health := &healthState{}
+		health.record(false, "upstream error")
+		assert.Empty(t, policy.reason(&rateLimitState{}, health))
+	})
+}
+
+func TestDowngradeTarget(t *testing.T) {
+	plugin := &WorkersAI{
+		Downgrade: &DowngradePolicy{
+			Models: map[string]string{
+				ModelLlama4Scout17B16EInstruct: ModelQwen330BA3BFp8,
+			},
+		},
+	}
+	plugin.rateLimit.set(&RateLimitInfo{Limit: 100, Remaining: 0})
+	gen := &generator{
+		model:  ModelLlama4Scout17B16EInstruct,
+		info:   supportedWorkersAIModels[ModelLlama4Scout17B16EInstruct],
+		plugin: plugin,
+	}
+
+	target, reason := gen.downgradeTarget()
+	assert.Equal(t, "rate-limited", reason)
+	assert.Equal(t, ModelQwen330BA3BFp8, target.model)
+	assert.NotNil(t, target.info.Supports)
+
+	// Models outside the mapping are untouched even while triggered.
+	other := &generator{model: ModelDeepSeekR1DistillQwen32B, plugin: plugin}
+	target, reason = other.downgradeTarget()
+	assert.Empty(t, reason)
+	assert.Same(t, other, target)
+}
+
+func TestAnnotateDowngrade(t *testing.T) {
+	resp := &ai.ModelResponse{Custom: map[string]any{"truncated": true}}
+	annotateDowngrade(resp, "big-model", "small-model", "rate-limited")
+
+	custom, ok := resp.Custom.(map[string]any)
+	assert.True(t, ok)
+	assert.Equal(t, true, custom["truncated"])
+	assert.Equal(t, "big-model", custom["downgradedFrom"])
+	assert.Equal(t, "small-model", custom["downgradedTo"])
+	assert.Equal(t, "rate-limited", custom["downgradeReason"])
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+	"github.com/firebase/genkit/go/genkit"
+)
+
+// The workersai plugin resolves actions dynamically: referencing a model
+// that was not pre-registered defines it on the fly instead of failing the
+// lookup, so apps can use any valid Workers AI model identifier without the
+// plugin's curated set having caught up.
+
+// ListActions returns descriptors for every action the plugin can resolve on
+// demand: the curated models, the extended text-generation catalog and the
+// known embedders.
+func (w *WorkersAI) ListActions(ctx context.Context) []core.ActionDesc {
+	var actions []core.ActionDesc
+	seen := map[string]bool{}
+	addModel := func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		info := detectModelInfo(name)
+		actions = append(actions, core.ActionDesc{
+			Type: core.ActionTypeModel,
+			Name: fmt.Sprintf("%s/%s", provider, name),
+			Key:  fmt.Sprintf("/%s/%s/%s", core.ActionTypeModel, provider, name),
+			Metadata: map[string]any{
+				"label": info.Label,
+				"model": map[string]any{
+					"supports": map[string]any{
+						"multiturn":  info.Supports.Multiturn,
+						"systemRole": info.Supports.SystemRole,
+						"tools":      info.Supports.Tools,
+						"toolChoice": info.Supports.ToolChoice,
+						"media":      info.Supports.Media,
+					},
+					"stage": string(info.Stage),
+				},
+			},
+		})
+	}
+	for name := range supportedWorkersAIModels {
+		addModel(name)
+	}
+	for _, name := range catalogTextModels {
+		addModel(name)
+	}
+	for name := range supportedWorkersAIEmbedders {
+		actions = append(actions, core.ActionDesc{
+			Type: core.ActionTypeEmbedder,
+			Name: fmt.Sprintf("%s/%s", provider, name),
+			Key:  fmt.Sprintf("/%s/%s/%s", core.ActionTypeEmbedder, provider, name),
+		})
+	}
+	return actions
+}
+
+// ResolveAction defines the named action on the fly. Model names are
+// validated first, so a typo still fails with a suggestion instead of
+// registering a model the API will 404 on.
+func (w *WorkersAI) ResolveAction(g *genkit.Genkit, atype core.ActionType, name string) error {
+	switch atype {
+	case core.ActionTypeModel:
+		if err := validateModelName(name); err != nil {
+			return err
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.defineModel(g, name, detectModelInfo(name))
+	case core.ActionTypeEmbedder:
+		if _, ok := supportedWorkersAIEmbedders[name]; !ok {
+			return fmt.Errorf("unknown Workers AI embedder %q", name)
+		}
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.defineEmbedder(g, name, supportedWorkersAIEmbedders[name])
+	}
+	return nil
+}
+
+// Model families Cloudflare documents as supporting the capability, matched
+// as substrings of the model identifier. Used when a model is resolved
+// dynamically and the curated catalog has nothing verified for it.
+var (
+	toolCapableFamilies  = []string{"llama-3.1", "llama-3.3", "llama-4", "hermes-2-pro", "mistral-small", "qwen"}
+	mediaCapableFamilies = []string{"llama-4", "llava", "vision"}
+)
+
+// detectModelInfo returns the capabilities to register a model with: the
+// curated entry when one exists, otherwise the conservative catalog defaults
+// widened by what the model's family name implies.
+func detectModelInfo(name string) ai.ModelInfo {
+	if info, ok := supportedWorkersAIModels[name]; ok {
+		return info
+	}
+	info := catalogModelInfo(name)
+	lower := strings.ToLower(name)
+	for _, family := range toolCapableFamilies {
+		if strings.Contains(lower, family) {
+			info.Supports.Tools = true
+			break
+		}
+	}
+	for _, family := range mediaCapableFamilies {
+		if strings.Contains(lower, family) {
+			info.Supports.Media = true
+			break
+		}
+	}
+	return info
+}
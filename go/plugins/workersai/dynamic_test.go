@@ -0,0 +1,68 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectModelInfo(t *testing.T) {
+	t.Run("curated models keep their verified capabilities", func(t *testing.T) {
+		info := detectModelInfo(ModelLlama4Scout17B16EInstruct)
+		assert.True(t, info.Supports.Tools)
+		assert.True(t, info.Supports.Media)
+		assert.Equal(t, ai.ModelStageFeatured, info.Stage)
+	})
+
+	t.Run("tool-capable families are widened", func(t *testing.T) {
+		info := detectModelInfo("@cf/meta/llama-3.1-405b-instruct")
+		assert.True(t, info.Supports.Tools)
+		assert.False(t, info.Supports.Media)
+	})
+
+	t.Run("unknown families stay conservative", func(t *testing.T) {
+		info := detectModelInfo("@cf/acme/brand-new-model")
+		assert.True(t, info.Supports.Multiturn)
+		assert.False(t, info.Supports.Tools)
+		assert.False(t, info.Supports.Media)
+	})
+}
+
+func TestListActions(t *testing.T) {
+	w := &WorkersAI{}
+	actions := w.ListActions(context.Background())
+
+	keys := map[string]core.ActionType{}
+	for _, a := range actions {
+		keys[a.Name] = a.Type
+	}
+	assert.Equal(t, core.ActionTypeModel, keys[provider+"/"+ModelLlama4Scout17B16EInstruct])
+	assert.Equal(t, core.ActionTypeModel, keys[provider+"/@cf/google/gemma-7b-it"])
+	assert.Equal(t, core.ActionTypeEmbedder, keys[provider+"/@cf/baai/bge-base-en-v1.5"])
+
+	// Curated models appear once even though the catalog may list them too.
+	count := 0
+	for _, a := range actions {
+		if a.Name == provider+"/"+ModelLlama4Scout17B16EInstruct {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestResolveActionRejectsBadNames(t *testing.T) {
+	w := &WorkersAI{}
+
+	// A typo close to a known model fails with a suggestion rather than
+	// registering a model the API would 404 on.
+	err := w.ResolveAction(nil, core.ActionTypeModel, "@cf/meta/lama-4-scout-17b-16e-instruct")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did you mean")
+
+	err = w.ResolveAction(nil, core.ActionTypeEmbedder, "@cf/acme/no-such-embedder")
+	require.ErrorContains(t, err, "unknown Workers AI embedder")
+}
@@ -0,0 +1,256 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/pkg/errors"
+)
+
+const (
+	bgeBaseEnV15  = "@cf/baai/bge-base-en-v1.5"
+	bgeLargeEnV15 = "@cf/baai/bge-large-en-v1.5"
+	bgeSmallEnV15 = "@cf/baai/bge-small-en-v1.5"
+	bgeM3         = "@cf/baai/bge-m3"
+)
+
+// supportedWorkersAIEmbedders maps the known embedding models to their
+// output dimensions.
+var supportedWorkersAIEmbedders = map[string]int{
+	bgeBaseEnV15:  768,
+	bgeLargeEnV15: 1024,
+	bgeSmallEnV15: 384,
+	bgeM3:         1024,
+}
+
+// Embedding task types. BGE models perform better when queries carry the
+// retrieval instruction prefix while passages are embedded as-is.
+const (
+	EmbedTaskQuery    = "query"
+	EmbedTaskDocument = "document"
+)
+
+// bgeQueryPrefix is the instruction prefix the BGE family expects on
+// retrieval queries.
+const bgeQueryPrefix = "Represent this sentence for searching relevant passages: "
+
+// EmbedOptions are the options for a Workers AI embedding request, passed
+// via ai.WithEmbedOptions.
+type EmbedOptions struct {
+	// TaskType is [EmbedTaskQuery] or [EmbedTaskDocument]. Queries get the
+	// BGE instruction prefix applied automatically; documents are embedded
+	// as-is, which is also the default.
+	TaskType string `json:"taskType,omitempty"`
+	// Dimensions truncates the output vectors to the leading dimensions and
+	// renormalizes them. Only bge-m3 embeddings remain meaningful when
+	// truncated.
+	Dimensions int `json:"dimensions,omitempty"`
+}
+
+// embedder implements embedding generation for a single Workers AI model.
+// The vendored client has no embeddings API, so requests go through the
+// shared HTTP client directly.
+type embedder struct {
+	model  string
+	plugin *WorkersAI
+}
+
+// defineEmbedder is a helper to register an embedding model with Genkit.
+func (w *WorkersAI) defineEmbedder(g *genkit.Genkit, name string, dimensions int) {
+	e := &embedder{model: name, plugin: w}
+	genkit.DefineEmbedder(g, provider, name, &ai.EmbedderOptions{
+		Info: &ai.EmbedderInfo{
+			Label: "Workers AI - " + name,
+			Supports: &ai.EmbedderSupports{
+				Input: []string{"text"},
+			},
+			Dimensions: dimensions,
+		},
+	}, e.embed)
+}
+
+// DefineEmbedder defines a Workers AI embedding model for use in Genkit.
+func (w *WorkersAI) DefineEmbedder(g *genkit.Genkit, name string) {
+	if !w.initted {
+		panic("Workers AI plugin not initialized")
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.defineEmbedder(g, name, supportedWorkersAIEmbedders[name])
+}
+
+// embed calls the model with the text of every input document and converts
+// the result to Genkit embeddings.
+func (e *embedder) embed(ctx context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+	if e.plugin != nil {
+		if err := e.plugin.acquire(); err != nil {
+			return nil, err
+		}
+		defer e.plugin.release()
+	}
+	opts, err := embedOptionsFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Dimensions > 0 && e.model != bgeM3 {
+		return nil, fmt.Errorf("dimension truncation is only supported for %s, not %s", bgeM3, e.model)
+	}
+	if opts.Dimensions > supportedWorkersAIEmbedders[e.model] {
+		return nil, fmt.Errorf("requested %d dimensions but %s produces %d", opts.Dimensions, e.model, supportedWorkersAIEmbedders[e.model])
+	}
+
+	texts := make([]string, 0, len(req.Input))
+	for _, doc := range req.Input {
+		text := concatenateText(doc)
+		if opts.TaskType == EmbedTaskQuery {
+			text = bgeQueryPrefix + text
+		}
+		texts = append(texts, text)
+	}
+	if len(texts) == 0 {
+		return &ai.EmbedResponse{}, nil
+	}
+
+	vectors, err := e.plugin.runEmbedding(ctx, e.model, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embedding model returned %d vectors for %d inputs", len(vectors), len(texts))
+	}
+
+	resp := &ai.EmbedResponse{}
+	for _, vec := range vectors {
+		if opts.Dimensions > 0 && opts.Dimensions < len(vec) {
+			vec = renormalize(vec[:opts.Dimensions])
+		}
+		resp.Embeddings = append(resp.Embeddings, &ai.Embedding{Embedding: vec})
+	}
+	return resp, nil
+}
+
+// embedOptionsFromRequest parses the request's typed options, defaulting to
+// the zero value when none are set.
+func embedOptionsFromRequest(req *ai.EmbedRequest) (*EmbedOptions, error) {
+	switch opts := req.Options.(type) {
+	case *EmbedOptions:
+		if opts != nil {
+			return opts, nil
+		}
+	case EmbedOptions:
+		return &opts, nil
+	case nil:
+	default:
+		return nil, fmt.Errorf("unexpected embed options type: %T", req.Options)
+	}
+	return &EmbedOptions{}, nil
+}
+
+// renormalize scales a truncated vector back to unit length so cosine
+// similarity remains meaningful.
+func renormalize(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = v / norm
+	}
+	return out
+}
+
+// IsDefinedEmbedder reports whether an embedder is defined.
+func IsDefinedEmbedder(g *genkit.Genkit, name string) bool {
+	return genkit.LookupEmbedder(g, provider, name) != nil
+}
+
+// Embedder returns the [ai.Embedder] with the given name.
+func Embedder(g *genkit.Genkit, name string) ai.Embedder {
+	return genkit.LookupEmbedder(g, provider, name)
+}
+
+// concatenateText flattens a document's text parts into one string.
+func concatenateText(doc *ai.Document) string {
+	var builder strings.Builder
+	for _, part := range doc.Content {
+		builder.WriteString(part.Text)
+	}
+	return builder.String()
+}
+
+// runEmbedding invokes an embedding model over the plugin's HTTP stack and
+// returns one vector per input text.
+func (w *WorkersAI) runEmbedding(ctx context.Context, model string, texts []string) ([][]float32, error) {
+	c := w.client
+	body, err := json.Marshal(map[string]any{"text": texts})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal embedding request")
+	}
+
+	url := fmt.Sprintf("%s/accounts/%s/ai/run/%s", c.BaseURL, c.AccountID, model)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create embedding request")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "embedding request failed")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read embedding response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding request returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var parsed struct {
+		Success bool     `json:"success"`
+		Errors  []string `json:"errors"`
+		Result  struct {
+			Shape []int       `json:"shape"`
+			Data  [][]float32 `json:"data"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, errors.Wrap(err, "failed to parse embedding response")
+	}
+	if !parsed.Success {
+		return nil, fmt.Errorf("embedding request returned an error: %v", parsed.Errors)
+	}
+	return parsed.Result.Data, nil
+}
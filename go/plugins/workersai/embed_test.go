@@ -0,0 +1,85 @@
+package workersai
+
+import (
+	"math"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedOptionsFromRequest(t *testing.T) {
+	testCases := []struct {
+		name      string
+		options   any
+		want      *EmbedOptions
+		expectErr bool
+	}{
+		{
+			name:    "nil options default to zero value",
+			options: nil,
+			want:    &EmbedOptions{},
+		},
+		{
+			name:    "pointer options pass through",
+			options: &EmbedOptions{TaskType: EmbedTaskQuery},
+			want:    &EmbedOptions{TaskType: EmbedTaskQuery},
+		},
+		{
+			name:    "value options pass through",
+			options: EmbedOptions{Dimensions: 256},
+			want:    &EmbedOptions{Dimensions: 256},
+		},
+		{
+			name:      "unsupported type errors",
+			options:   "query",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := embedOptionsFromRequest(&ai.EmbedRequest{Options: tc.options})
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestRenormalize(t *testing.T) {
+	vec := renormalize([]float32{3, 4})
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	assert.InDelta(t, 1.0, math.Sqrt(sum), 1e-6)
+	assert.InDelta(t, 0.6, vec[0], 1e-6)
+	assert.InDelta(t, 0.8, vec[1], 1e-6)
+}
+
+func TestEmbedDimensionValidation(t *testing.T) {
+	plugin := &WorkersAI{}
+
+	t.Run("truncation rejected for non-m3 models", func(t *testing.T) {
+		e := &embedder{model: bgeBaseEnV15, plugin: plugin}
+		_, err := e.embed(t.Context(), &ai.EmbedRequest{
+			Options: &EmbedOptions{Dimensions: 256},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "only supported for "+bgeM3)
+	})
+
+	t.Run("truncation beyond model dimensions rejected", func(t *testing.T) {
+		e := &embedder{model: bgeM3, plugin: plugin}
+		_, err := e.embed(t.Context(), &ai.EmbedRequest{
+			Options: &EmbedOptions{Dimensions: 2048},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "produces 1024")
+	})
+}
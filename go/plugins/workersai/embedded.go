@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/pkg/errors"
+)
+
+// maxEmbeddedToolTurns bounds how many model round-trips the embedded tool
+// loop may take before giving up, mirroring the cap Cloudflare's own
+// embedded function-calling runner applies.
+const maxEmbeddedToolTurns = 5
+
+// resolveToolsEmbedded resolves tool calls inside the plugin, in the style
+// of Cloudflare's embedded function calling: tools run as close to the model
+// call as possible and only the final answer is returned. The Workers-side
+// runner is not reachable over the REST API, so the loop executes the
+// registered Genkit tools here instead. When any requested tool is not
+// registered in this Genkit instance, the response is returned untouched and
+// the standard client-side generate loop takes over.
+func (gen *generator) resolveToolsEmbedded(ctx context.Context, input *ai.ModelRequest, cb func(context.Context, *ai.ModelResponseChunk) error, resp *ai.ModelResponse) (*ai.ModelResponse, error) {
+	g := gen.plugin.genkit
+	messages := input.Messages
+
+	for turn := 0; turn < maxEmbeddedToolTurns; turn++ {
+		requests := toolRequestParts(resp.Message)
+		if len(requests) == 0 {
+			return resp, nil
+		}
+
+		tools := make(map[string]ai.Tool, len(requests))
+		for _, part := range requests {
+			tool := genkit.LookupTool(g, part.ToolRequest.Name)
+			if tool == nil {
+				// Not runnable here; hand the tool calls back to the
+				// caller's loop.
+				return resp, nil
+			}
+			tools[part.ToolRequest.Name] = tool
+		}
+
+		toolMsg := &ai.Message{Role: ai.RoleTool}
+		for _, part := range requests {
+			req := part.ToolRequest
+			output, err := tools[req.Name].RunRaw(ctx, req.Input)
+			if err != nil {
+				return nil, errors.Wrapf(err, "embedded tool %s failed", req.Name)
+			}
+			toolMsg.Content = append(toolMsg.Content, ai.NewToolResponsePart(&ai.ToolResponse{
+				Name:   req.Name,
+				Ref:    req.Ref,
+				Output: output,
+			}))
+		}
+
+		messages = append(append([]*ai.Message{}, messages...), resp.Message, toolMsg)
+		next := *input
+		next.Messages = messages
+
+		var err error
+		resp, err = gen.doGenerate(ctx, &next, cb)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, errors.Errorf("embedded tool calling did not settle within %d turns", maxEmbeddedToolTurns)
+}
+
+// toolRequestParts returns the tool-request parts of a message, or nil for a
+// nil message.
+func toolRequestParts(msg *ai.Message) []*ai.Part {
+	if msg == nil {
+		return nil
+	}
+	var parts []*ai.Part
+	for _, part := range msg.Content {
+		if part.IsToolRequest() {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/internal/base"
+	"github.com/pkg/errors"
+)
+
+// ExtractOptions configures [Extract] and [ExtractNamed].
+type ExtractOptions struct {
+	// Model runs the extraction. Required.
+	Model ai.Model
+
+	// Instructions, when non-empty, is appended to the extraction prompt —
+	// field conventions, disambiguation rules, and the like. For a named
+	// schema it is added on top of the schema's own instructions.
+	Instructions string
+
+	// MaxRetries is how often the model is re-asked when its output is
+	// invalid JSON or fails schema validation, with the validation error
+	// fed back. The default is 1.
+	MaxRetries int
+
+	// Config is passed through as every request's config, for example a
+	// [Config].
+	Config any
+}
+
+// extractPrompt frames the task; the schema rides along as guided JSON
+// output, so the model is constrained rather than merely instructed.
+const extractPrompt = "Extract the requested fields from the text below. " +
+	"Reply with only a JSON object matching the requested schema; use null " +
+	"for fields the text does not mention.\n\nText:\n"
+
+// Extract pulls structured fields out of messy text: it asks the model with
+// natively guided JSON output for T's schema, validates the reply against
+// that schema, and re-asks with the validation error when the model deviates
+// anyway. The type parameter is the contract — tag fields with `json` names
+// matching what the text should yield.
+func Extract[T any](ctx context.Context, text string, opts *ExtractOptions) (T, error) {
+	var value T
+	schema := base.SchemaAsMap(base.InferJSONSchema(value))
+	raw, err := runExtraction(ctx, text, schema, "", opts)
+	if err != nil {
+		return value, err
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, errors.Wrap(err, "failed to unmarshal extraction")
+	}
+	return value, nil
+}
+
+// extractionSchemas is the process-wide registry of reusable extraction
+// schemas, keyed by name.
+var extractionSchemas = struct {
+	mu   sync.Mutex
+	byID map[string]extractionSchema
+}{byID: map[string]extractionSchema{}}
+
+type extractionSchema struct {
+	schema       map[string]any
+	instructions string
+}
+
+// RegisterExtraction registers a reusable extraction schema under a name.
+// The schema is inferred from the prototype value the same way [Extract]
+// infers it from its type parameter; instructions travel with the schema, so
+// every call site extracts by the same rules. Registering a name twice
+// replaces the earlier schema.
+func RegisterExtraction(name string, prototype any, instructions string) {
+	extractionSchemas.mu.Lock()
+	defer extractionSchemas.mu.Unlock()
+	extractionSchemas.byID[name] = extractionSchema{
+		schema:       base.SchemaAsMap(base.InferJSONSchema(prototype)),
+		instructions: instructions,
+	}
+}
+
+// ExtractNamed runs an extraction against a schema registered with
+// [RegisterExtraction], returning the validated fields as a map — for call
+// sites that share a schema by name rather than a Go type.
+func ExtractNamed(ctx context.Context, text, name string, opts *ExtractOptions) (map[string]any, error) {
+	extractionSchemas.mu.Lock()
+	registered, ok := extractionSchemas.byID[name]
+	extractionSchemas.mu.Unlock()
+	if !ok {
+		return nil, errors.Errorf("workersai: no extraction schema registered as %q", name)
+	}
+	raw, err := runExtraction(ctx, text, registered.schema, registered.instructions, opts)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal extraction")
+	}
+	return fields, nil
+}
+
+// runExtraction is the shared ask-validate-re-ask loop behind [Extract] and
+// [ExtractNamed].
+func runExtraction(ctx context.Context, text string, schema map[string]any, instructions string, opts *ExtractOptions) (json.RawMessage, error) {
+	if opts == nil || opts.Model == nil {
+		return nil, errors.New("workersai: Extract requires a model")
+	}
+	cfg := *opts
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 1
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal extraction schema")
+	}
+
+	prompt := extractPrompt + text
+	for _, extra := range []string{instructions, cfg.Instructions} {
+		if extra != "" {
+			prompt += "\n\n" + extra
+		}
+	}
+	messages := []*ai.Message{ai.NewUserTextMessage(prompt)}
+
+	var lastErr error
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		resp, err := cfg.Model.Generate(ctx, &ai.ModelRequest{
+			Messages: messages,
+			Config:   cfg.Config,
+			Output:   &ai.ModelOutputConfig{Format: ai.OutputFormatJSON, Schema: schema},
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		cleaned, err := cleanJSONOutput(resp.Message.Text())
+		if err == nil {
+			if verr := base.ValidateRaw([]byte(cleaned), schemaBytes); verr != nil {
+				err = verr
+			}
+		}
+		if err == nil {
+			return json.RawMessage(cleaned), nil
+		}
+		lastErr = err
+		messages = append(messages, resp.Message, ai.NewUserTextMessage(fmt.Sprintf(
+			"That output was rejected: %v. Reply again with only a JSON object matching the schema.", err)))
+	}
+	return nil, errors.Wrapf(lastErr, "extraction failed after %d attempts", cfg.MaxRetries+1)
+}
@@ -0,0 +1,85 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type invoice struct {
+	Vendor string  `json:"vendor"`
+	Total  float64 `json:"total"`
+}
+
+func TestExtract(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{
+		textResponse("```json\n{\"vendor\": \"Acme GmbH\", \"total\": 119.5}\n```"),
+	}}
+
+	got, err := Extract[invoice](context.Background(), "Rechnung von Acme GmbH über 119,50 EUR", &ExtractOptions{Model: model})
+	require.NoError(t, err)
+	assert.Equal(t, invoice{Vendor: "Acme GmbH", Total: 119.5}, got)
+
+	// The request asked for guided JSON output with the inferred schema.
+	require.Len(t, model.requests, 1)
+	output := model.requests[0].Output
+	require.NotNil(t, output)
+	assert.Equal(t, ai.OutputFormatJSON, output.Format)
+	assert.Contains(t, output.Schema, "properties")
+}
+
+func TestExtractReasksOnInvalidOutput(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{
+		textResponse("The vendor appears to be Acme."),
+		textResponse(`{"vendor": "Acme", "total": 10}`),
+	}}
+
+	got, err := Extract[invoice](context.Background(), "some text", &ExtractOptions{Model: model})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", got.Vendor)
+
+	// The re-ask carries the rejected answer and the reason.
+	require.Len(t, model.requests, 2)
+	retry := model.requests[1].Messages
+	require.Len(t, retry, 3)
+	assert.Equal(t, ai.RoleModel, retry[1].Role)
+	assert.Contains(t, retry[2].Text(), "rejected")
+}
+
+func TestExtractGivesUpAfterRetries(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{
+		textResponse("not json"),
+		textResponse("still not json"),
+	}}
+
+	_, err := Extract[invoice](context.Background(), "text", &ExtractOptions{Model: model})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "after 2 attempts")
+}
+
+func TestExtractNamed(t *testing.T) {
+	RegisterExtraction("test-invoice", invoice{}, "Totals are gross amounts.")
+	model := &queueModel{responses: []*ai.ModelResponse{
+		textResponse(`{"vendor": "Acme", "total": 42}`),
+	}}
+
+	fields, err := ExtractNamed(context.Background(), "text", "test-invoice", &ExtractOptions{Model: model})
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", fields["vendor"])
+	assert.Equal(t, 42.0, fields["total"])
+
+	// The registered instructions ride along in the prompt.
+	assert.Contains(t, model.requests[0].Messages[0].Text(), "gross amounts")
+
+	_, err = ExtractNamed(context.Background(), "text", "no-such-schema", &ExtractOptions{Model: model})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `no extraction schema registered as "no-such-schema"`)
+}
+
+func TestExtractRequiresModel(t *testing.T) {
+	_, err := Extract[invoice](context.Background(), "text", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FailoverConfig configures a secondary Cloudflare account the plugin fails
+// over to when the primary hits quota or auth trouble.
+type FailoverConfig struct {
+	// AccountID and APIToken identify the secondary account. Both are
+	// required.
+	AccountID string
+	APIToken  string
+
+	// FailbackAfter is how long requests stay on the secondary before the
+	// primary is probed again. The default is one minute.
+	FailbackAfter time.Duration
+
+	// OnSwitch, when set, is invoked on every switchover and failback, so
+	// operators can alert on a deployment running on its fallback quota.
+	// It is called synchronously and must be safe for concurrent use.
+	OnSwitch func(FailoverEvent)
+}
+
+// FailoverEvent describes one account switch.
+type FailoverEvent struct {
+	// ToSecondary is true for a failover to the secondary account and
+	// false for a failback to the primary.
+	ToSecondary bool
+	// Reason is what triggered the switch, for example "primary returned
+	// status 429".
+	Reason string
+	// At is when the switch happened.
+	At time.Time
+}
+
+// failoverStatus reports whether a response status from the primary account
+// is worth failing over for: the quota and auth failures a second account
+// can actually remedy. Server errors are left to the retry layer, which a
+// different account would hit just the same.
+func failoverStatus(status int) bool {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return false
+}
+
+// failoverTransport routes requests to the secondary account while the
+// primary is hitting quota or auth failures, and periodically probes the
+// primary to fail back. It sits at the top of the transport stack so it sees
+// each request's final outcome, after any retries below it.
+type failoverTransport struct {
+	base http.RoundTripper
+	cfg  FailoverConfig
+	// accountID is the primary account, replaced in request paths when
+	// serving from the secondary.
+	accountID string
+
+	mu          sync.Mutex
+	onSecondary bool
+	lastFailure time.Time
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	failbackAfter := t.cfg.FailbackAfter
+	if failbackAfter <= 0 {
+		failbackAfter = time.Minute
+	}
+
+	// Serve from the secondary while failed over, except when it is time
+	// to probe the primary again; the probe is an ordinary request, so
+	// failing back costs no extra call.
+	t.mu.Lock()
+	useSecondary := t.onSecondary && time.Since(t.lastFailure) < failbackAfter
+	probing := t.onSecondary && !useSecondary
+	t.mu.Unlock()
+
+	if useSecondary {
+		return base.RoundTrip(t.toSecondary(req))
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || !failoverStatus(resp.StatusCode) {
+		if err == nil && probing {
+			t.switchTo(false, "primary recovered")
+		}
+		return resp, err
+	}
+
+	reason := fmt.Sprintf("primary returned status %d", resp.StatusCode)
+	if probing {
+		// The primary is still unhealthy; stay failed over and restart
+		// the failback clock without announcing anything new.
+		t.mu.Lock()
+		t.lastFailure = time.Now()
+		t.mu.Unlock()
+	} else {
+		t.switchTo(true, reason)
+	}
+
+	// Replay the request against the secondary. Without GetBody the body
+	// is already consumed, so the primary's response is all there is.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	retry := t.toSecondary(req)
+	if req.GetBody != nil {
+		body, bodyErr := req.GetBody()
+		if bodyErr != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return base.RoundTrip(retry)
+}
+
+// failedOverContextKey marks a request already re-credentialed for the
+// secondary account, so the auth layers below the failover transport leave
+// its Authorization header and path alone.
+type failedOverContextKey struct{}
+
+// failedOver reports whether the request was addressed to the secondary
+// account by the failover transport.
+func failedOver(req *http.Request) bool {
+	marked, _ := req.Context().Value(failedOverContextKey{}).(bool)
+	return marked
+}
+
+// toSecondary returns a copy of the request addressed to the secondary
+// account: its token in the Authorization header and its account ID in the
+// request path. The copy is marked so [tokenTransport] and [tenantTransport]
+// below do not clobber the secondary credentials with the primary's.
+func (t *failoverTransport) toSecondary(req *http.Request) *http.Request {
+	req = req.Clone(context.WithValue(req.Context(), failedOverContextKey{}, true))
+	req.Header.Set("Authorization", "Bearer "+t.cfg.APIToken)
+	if t.accountID != "" {
+		req.URL.Path = strings.Replace(req.URL.Path,
+			"/accounts/"+t.accountID+"/", "/accounts/"+t.cfg.AccountID+"/", 1)
+	}
+	return req
+}
+
+// switchTo records a switchover or failback and notifies the observer.
+func (t *failoverTransport) switchTo(secondary bool, reason string) {
+	t.mu.Lock()
+	t.onSecondary = secondary
+	t.lastFailure = time.Now()
+	t.mu.Unlock()
+	if t.cfg.OnSwitch != nil {
+		t.cfg.OnSwitch(FailoverEvent{ToSecondary: secondary, Reason: reason, At: time.Now()})
+	}
+}
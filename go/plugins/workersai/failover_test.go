@@ -0,0 +1,172 @@
+package workersai
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverTransport(t *testing.T) {
+	var mu sync.Mutex
+	primaryHealthy := false
+	var primaryHits, secondaryHits int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.Contains(r.URL.Path, "/accounts/primary/"):
+			assert.Equal(t, "Bearer tok-primary", r.Header.Get("Authorization"))
+			primaryHits++
+			if !primaryHealthy {
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte("primary ok"))
+		case strings.Contains(r.URL.Path, "/accounts/backup/"):
+			assert.Equal(t, "Bearer tok-backup", r.Header.Get("Authorization"))
+			secondaryHits++
+			w.Write([]byte("secondary ok"))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	var events []FailoverEvent
+	transport := &failoverTransport{
+		accountID: "primary",
+		cfg: FailoverConfig{
+			AccountID:     "backup",
+			APIToken:      "tok-backup",
+			FailbackAfter: 50 * time.Millisecond,
+			OnSwitch: func(e FailoverEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, e)
+			},
+		},
+	}
+
+	do := func() string {
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/accounts/primary/ai/run", strings.NewReader(`{}`))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer tok-primary")
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return string(body)
+	}
+
+	// The primary's 429 triggers a transparent replay on the secondary.
+	assert.Equal(t, "secondary ok", do())
+	mu.Lock()
+	require.Len(t, events, 1)
+	assert.True(t, events[0].ToSecondary)
+	assert.Contains(t, events[0].Reason, "status 429")
+	mu.Unlock()
+
+	// While failed over, requests go straight to the secondary.
+	assert.Equal(t, "secondary ok", do())
+	mu.Lock()
+	assert.Equal(t, 1, primaryHits)
+	mu.Unlock()
+
+	// After the failback window an unhealthy primary keeps us failed over,
+	// without a second switchover event.
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, "secondary ok", do())
+	mu.Lock()
+	assert.Equal(t, 2, primaryHits)
+	require.Len(t, events, 1)
+	mu.Unlock()
+
+	// Once the primary recovers, the next probe fails back.
+	mu.Lock()
+	primaryHealthy = true
+	mu.Unlock()
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, "primary ok", do())
+	mu.Lock()
+	require.Len(t, events, 2)
+	assert.False(t, events[1].ToSecondary)
+	mu.Unlock()
+
+	// And the one after that stays on the primary.
+	assert.Equal(t, "primary ok", do())
+}
+
+// staticTokenSource hands out a fixed token, standing in for a secret
+// manager.
+type staticTokenSource struct{ value string }
+
+func (s staticTokenSource) Token() (*Token, error) {
+	return &Token{Value: s.value}, nil
+}
+
+func TestFailoverTransportWithTokenSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/accounts/primary/"):
+			assert.Equal(t, "Bearer tok-primary", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusTooManyRequests)
+		case strings.Contains(r.URL.Path, "/accounts/backup/"):
+			// The token source sits below the failover transport and
+			// must not clobber the secondary account's token.
+			assert.Equal(t, "Bearer tok-backup", r.Header.Get("Authorization"))
+			w.Write([]byte("secondary ok"))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	// Stacked as in Init: the token source below, failover on top.
+	transport := &failoverTransport{
+		base:      &tokenTransport{source: staticTokenSource{value: "tok-primary"}},
+		accountID: "primary",
+		cfg:       FailoverConfig{AccountID: "backup", APIToken: "tok-backup"},
+	}
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/accounts/primary/ai/run", nil)
+		require.NoError(t, err)
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		// Both the initial replay and the subsequent failed-over request
+		// reach the secondary with its own token.
+		assert.Equal(t, "secondary ok", string(body))
+	}
+}
+
+func TestFailoverTransportIgnoresServerErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Contains(t, r.URL.Path, "/accounts/primary/")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	transport := &failoverTransport{
+		accountID: "primary",
+		cfg:       FailoverConfig{AccountID: "backup", APIToken: "tok-backup"},
+	}
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/accounts/primary/ai/run", nil)
+	require.NoError(t, err)
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	// A 5xx is the retry layer's business, not grounds for switching
+	// accounts.
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.False(t, transport.onSecondary)
+}
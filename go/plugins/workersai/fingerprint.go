@@ -0,0 +1,92 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/firebase/genkit/go/ai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HistoryFingerprint returns a stable hash of a conversation history: the
+// roles and semantic content of each message, in order. Unlike hashing the
+// marshaled request, the fingerprint ignores per-part metadata and generation
+// config, so the same history produces the same value across retries and
+// across processes. Every response records it in custom metadata under the
+// key "historyFingerprint", so caching layers can key on it and callers can
+// detect history divergence between attempts.
+func HistoryFingerprint(messages []*ai.Message) string {
+	h := sha256.New()
+	for _, msg := range messages {
+		writeField(h, "role", string(msg.Role))
+		for _, p := range msg.Content {
+			switch {
+			case p.IsText():
+				writeField(h, "text", p.Text)
+			case p.IsReasoning():
+				writeField(h, "reasoning", p.Text)
+			case p.IsMedia():
+				writeField(h, "media", p.ContentType)
+				writeField(h, "", p.Text)
+			case p.IsData():
+				writeField(h, "data", p.Text)
+			case p.IsToolRequest():
+				writeField(h, "toolRequest", p.ToolRequest.Name)
+				writeJSONField(h, p.ToolRequest.Input)
+			case p.IsToolResponse():
+				writeField(h, "toolResponse", p.ToolResponse.Name)
+				writeJSONField(h, p.ToolResponse.Output)
+			case p.IsCustom():
+				writeField(h, "custom", "")
+				writeJSONField(h, p.Custom)
+			}
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// recordFingerprint computes the history fingerprint for a request, attaches
+// it to the model call span and returns it for the response metadata.
+func recordFingerprint(ctx context.Context, messages []*ai.Message) string {
+	fp := HistoryFingerprint(messages)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("workersai.historyFingerprint", fp))
+	return fp
+}
+
+// writeField writes a length-prefixed labeled value, so adjacent fields can
+// never run together and produce the same digest for different histories.
+func writeField(w io.Writer, label, value string) {
+	fmt.Fprintf(w, "%s:%d:%s\n", label, len(value), value)
+}
+
+// writeJSONField writes a structured value in its canonical JSON form;
+// json.Marshal emits map keys in sorted order, so equal values hash equally.
+func writeJSONField(w io.Writer, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		// Unmarshalable tool payloads still contribute their type, so two
+		// histories differing only in such a payload rarely collide.
+		fmt.Fprintf(w, "!%T\n", v)
+		return
+	}
+	writeField(w, "json", string(b))
+}
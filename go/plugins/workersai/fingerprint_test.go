@@ -0,0 +1,62 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryFingerprint(t *testing.T) {
+	history := func() []*ai.Message {
+		return []*ai.Message{
+			ai.NewSystemTextMessage("You are terse."),
+			ai.NewUserTextMessage("What is the capital of France?"),
+			{
+				Role: ai.RoleModel,
+				Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+					Name:  "lookup",
+					Input: map[string]any{"country": "France"},
+				})},
+			},
+		}
+	}
+
+	t.Run("stable across calls", func(t *testing.T) {
+		assert.Equal(t, HistoryFingerprint(history()), HistoryFingerprint(history()))
+	})
+
+	t.Run("ignores part metadata", func(t *testing.T) {
+		annotated := history()
+		annotated[1].Content[0].Metadata = map[string]any{"source": "retry"}
+		assert.Equal(t, HistoryFingerprint(history()), HistoryFingerprint(annotated))
+	})
+
+	t.Run("changed content diverges", func(t *testing.T) {
+		changed := history()
+		changed[1].Content[0].Text = "What is the capital of Spain?"
+		assert.NotEqual(t, HistoryFingerprint(history()), HistoryFingerprint(changed))
+	})
+
+	t.Run("changed role diverges", func(t *testing.T) {
+		changed := history()
+		changed[0].Role = ai.RoleUser
+		assert.NotEqual(t, HistoryFingerprint(history()), HistoryFingerprint(changed))
+	})
+
+	t.Run("changed tool input diverges", func(t *testing.T) {
+		changed := history()
+		changed[2].Content[0].ToolRequest.Input = map[string]any{"country": "Spain"}
+		assert.NotEqual(t, HistoryFingerprint(history()), HistoryFingerprint(changed))
+	})
+
+	t.Run("adjacent parts cannot collide", func(t *testing.T) {
+		a := []*ai.Message{{Role: ai.RoleUser, Content: []*ai.Part{
+			ai.NewTextPart("ab"), ai.NewTextPart("c"),
+		}}}
+		b := []*ai.Message{{Role: ai.RoleUser, Content: []*ai.Part{
+			ai.NewTextPart("a"), ai.NewTextPart("bc"),
+		}}}
+		assert.NotEqual(t, HistoryFingerprint(a), HistoryFingerprint(b))
+	})
+}
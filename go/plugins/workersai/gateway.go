@@ -0,0 +1,139 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/firebase/genkit/go/internal/base"
+)
+
+// gatewayBaseURL is the root of Cloudflare's AI Gateway unified endpoint.
+const gatewayBaseURL = "https://gateway.ai.cloudflare.com/v1"
+
+// GatewayProvider names an upstream BYOK provider proxied by an AI Gateway
+// and the models to register from it. The gateway holds the upstream API
+// keys; requests authenticate with the Cloudflare token as usual.
+type GatewayProvider struct {
+	// Provider is the provider slug understood by the gateway, such as
+	// "openai", "anthropic" or "groq".
+	Provider string
+	// Models are the upstream model names to register, such as
+	// "gpt-4o-mini".
+	Models []string
+}
+
+// GatewayConfig enables gateway provider mode: upstream models proxied by a
+// Cloudflare AI Gateway are registered under this plugin using the gateway's
+// unified endpoint, so one Go plugin covers all gateway-routed models.
+type GatewayConfig struct {
+	// Name is the gateway's ID (slug) within the account.
+	Name string
+	// Providers lists the upstream providers and models to register.
+	Providers []GatewayProvider
+}
+
+// gatewayTransport redirects requests to the AI Gateway unified endpoint and
+// adapts the raw OpenAI-style response into the envelope the vendored client
+// expects.
+type gatewayTransport struct {
+	base     http.RoundTripper
+	endpoint *url.URL
+}
+
+func (t *gatewayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	req = req.Clone(req.Context())
+	req.URL = t.endpoint
+	req.Host = ""
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	// The unified endpoint returns a bare chat-completion object, while the
+	// client expects Cloudflare's {success, errors, result} envelope.
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gateway response: %w", err)
+	}
+	wrapped := make([]byte, 0, len(body)+64)
+	wrapped = append(wrapped, []byte(`{"success":true,"errors":[],"result":`)...)
+	wrapped = append(wrapped, body...)
+	wrapped = append(wrapped, '}')
+
+	resp.Body = io.NopCloser(bytes.NewReader(wrapped))
+	resp.ContentLength = int64(len(wrapped))
+	return resp, nil
+}
+
+// setupGateway registers the configured gateway-routed upstream models.
+func (w *WorkersAI) setupGateway(g *genkit.Genkit) error {
+	if w.Gateway.Name == "" {
+		return fmt.Errorf("gateway provider mode requires a gateway name")
+	}
+
+	endpoint, err := url.Parse(fmt.Sprintf("%s/%s/%s/compat/chat/completions",
+		gatewayBaseURL, w.client.AccountID, w.Gateway.Name))
+	if err != nil {
+		return fmt.Errorf("failed to build gateway endpoint: %w", err)
+	}
+
+	// The gateway client shares the configured transport stack with an
+	// extra redirection layer on top.
+	gwClient := *w.client
+	httpClient := *gwClient.HTTPClient
+	httpClient.Transport = &gatewayTransport{base: httpClient.Transport, endpoint: endpoint}
+	gwClient.HTTPClient = &httpClient
+
+	for _, p := range w.Gateway.Providers {
+		if p.Provider == "" {
+			return fmt.Errorf("gateway provider entries require a provider slug")
+		}
+		for _, m := range p.Models {
+			name := p.Provider + "/" + m
+			info := ai.ModelInfo{
+				Label: "AI Gateway - " + name,
+				Supports: &ai.ModelSupports{
+					Multiturn:  true,
+					SystemRole: true,
+					Tools:      true,
+				},
+				ConfigSchema: base.ToSchemaMap(&Config{}),
+			}
+			gen := &generator{
+				model:  name,
+				info:   info,
+				client: &gwClient,
+				plugin: w,
+			}
+			genkit.DefineModel(g, provider, name, &info, gen.generate)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// healthWindow is how far back Status looks when computing the recent error
+// rate. Samples older than this are dropped.
+const healthWindow = 5 * time.Minute
+
+// defaultUnhealthyErrorRate is the error rate above which HealthHandler
+// reports the plugin as unhealthy when no threshold is configured.
+const defaultUnhealthyErrorRate = 0.5
+
+// Status is a snapshot of the plugin's health, suitable for readiness and
+// liveness decisions.
+type Status struct {
+	// Initialized reports whether Init has completed successfully.
+	Initialized bool `json:"initialized"`
+	// CredentialsPresent reports whether API credentials are configured,
+	// either in the environment or through a TokenSource. It does not
+	// verify them against the API.
+	CredentialsPresent bool `json:"credentialsPresent"`
+	// RequestCount is the number of API requests observed in the recent
+	// window.
+	RequestCount int `json:"requestCount"`
+	// ErrorCount is how many of those requests failed (transport errors,
+	// 429s and 5xx responses).
+	ErrorCount int `json:"errorCount"`
+	// ErrorRate is ErrorCount/RequestCount, or 0 when no requests were
+	// observed.
+	ErrorRate float64 `json:"errorRate"`
+	// LastError describes the most recent failure, if any.
+	LastError string `json:"lastError,omitempty"`
+	// LastErrorAt is when the most recent failure happened.
+	LastErrorAt time.Time `json:"lastErrorAt,omitempty"`
+}
+
+// healthSample is one observed request outcome.
+type healthSample struct {
+	at time.Time
+	ok bool
+}
+
+// healthState tracks recent request outcomes. It is updated by the transport
+// layer and read by Status, so it must be safe for concurrent use.
+type healthState struct {
+	mu          sync.Mutex
+	samples     []healthSample
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// record notes the outcome of one request, pruning samples that have aged out
+// of the window.
+func (h *healthState) record(ok bool, detail string) {
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prune(now)
+	h.samples = append(h.samples, healthSample{at: now, ok: ok})
+	if !ok {
+		h.lastError = detail
+		h.lastErrorAt = now
+	}
+}
+
+// snapshot returns the counts over the current window.
+func (h *healthState) snapshot() (requests, failures int, lastError string, lastErrorAt time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prune(time.Now())
+	for _, s := range h.samples {
+		requests++
+		if !s.ok {
+			failures++
+		}
+	}
+	return requests, failures, h.lastError, h.lastErrorAt
+}
+
+// prune drops samples older than the window. The caller must hold the mutex.
+func (h *healthState) prune(now time.Time) {
+	cutoff := now.Add(-healthWindow)
+	i := 0
+	for i < len(h.samples) && h.samples[i].at.Before(cutoff) {
+		i++
+	}
+	if i > 0 {
+		h.samples = append(h.samples[:0], h.samples[i:]...)
+	}
+}
+
+// healthTransport records the outcome of every API request into the plugin's
+// health state. Transport errors, 429s and 5xx responses count as failures.
+type healthTransport struct {
+	base  http.RoundTripper
+	state *healthState
+}
+
+func (t *healthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err != nil:
+		t.state.record(false, err.Error())
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		t.state.record(false, "API returned status "+resp.Status)
+	default:
+		t.state.record(true, "")
+	}
+	return resp, err
+}
+
+// Status returns a snapshot of the plugin's health: whether it is
+// initialized, whether credentials are configured, and the error rate over
+// the last few minutes of API traffic.
+func (w *WorkersAI) Status() Status {
+	w.mu.Lock()
+	initted := w.initted
+	w.mu.Unlock()
+
+	requests, failures, lastError, lastErrorAt := w.health.snapshot()
+	s := Status{
+		Initialized:        initted,
+		CredentialsPresent: os.Getenv("CLOUDFLARE_API_TOKEN") != "" || w.TokenSource != nil,
+		RequestCount:       requests,
+		ErrorCount:         failures,
+		LastError:          lastError,
+		LastErrorAt:        lastErrorAt,
+	}
+	if requests > 0 {
+		s.ErrorRate = float64(failures) / float64(requests)
+	}
+	return s
+}
+
+// HealthHandler returns an http.Handler suitable for Kubernetes readiness
+// and liveness probes. It serves the [Status] snapshot as JSON, with status
+// 200 while the plugin is healthy and 503 when it is uninitialized, missing
+// credentials, or its recent error rate exceeds unhealthyErrorRate (values
+// <= 0 use the default of 0.5). The handler makes no API calls, so it is
+// cheap enough for frequent probing.
+func (w *WorkersAI) HealthHandler(unhealthyErrorRate float64) http.Handler {
+	if unhealthyErrorRate <= 0 {
+		unhealthyErrorRate = defaultUnhealthyErrorRate
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		s := w.Status()
+		code := http.StatusOK
+		if !s.Initialized || !s.CredentialsPresent || s.ErrorRate > unhealthyErrorRate {
+			code = http.StatusServiceUnavailable
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(code)
+		json.NewEncoder(rw).Encode(s)
+	})
+}
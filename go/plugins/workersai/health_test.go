@@ -0,0 +1,58 @@
+package workersai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthStateErrorRate(t *testing.T) {
+	var h healthState
+	h.record(true, "")
+	h.record(true, "")
+	h.record(false, "API returned status 500 Internal Server Error")
+
+	requests, failures, lastError, lastErrorAt := h.snapshot()
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 1, failures)
+	assert.Contains(t, lastError, "500")
+	assert.False(t, lastErrorAt.IsZero())
+}
+
+func TestStatusUninitialized(t *testing.T) {
+	w := &WorkersAI{}
+	s := w.Status()
+	assert.False(t, s.Initialized)
+	assert.Zero(t, s.RequestCount)
+	assert.Zero(t, s.ErrorRate)
+}
+
+func TestHealthHandler(t *testing.T) {
+	w := &WorkersAI{}
+
+	// Uninitialized plugins are not ready.
+	rec := httptest.NewRecorder()
+	w.HealthHandler(0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"initialized":false`)
+
+	// An initialized plugin with credentials and a clean window is ready.
+	t.Setenv("CLOUDFLARE_API_TOKEN", "token")
+	w.initted = true
+	w.health.record(true, "")
+
+	rec = httptest.NewRecorder()
+	w.HealthHandler(0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// A high recent error rate flips readiness back off.
+	for range 5 {
+		w.health.record(false, "API returned status 503 Service Unavailable")
+	}
+	rec = httptest.NewRecorder()
+	w.HealthHandler(0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
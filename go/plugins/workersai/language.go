@@ -0,0 +1,211 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// LanguageConfig configures [LanguageMiddleware].
+type LanguageConfig struct {
+	// Language is the required output language, as an ISO 639-1 code such
+	// as "en" or "de". Required.
+	Language string
+
+	// MaxRetries is how often the model is re-asked when it answers in
+	// the wrong language. The default is 1; after the last retry the
+	// response is returned as is.
+	MaxRetries int
+
+	// Detector overrides the built-in heuristic, returning the ISO 639-1
+	// code of the text's language or "" when undetermined — for example a
+	// call into a small classification model. When nil, a script and
+	// stopword heuristic is used.
+	Detector func(ctx context.Context, text string) (string, error)
+}
+
+// languageNames spells out the codes the heuristic knows, for the corrective
+// prompt sent on a retry.
+var languageNames = map[string]string{
+	"en": "English",
+	"de": "German",
+	"fr": "French",
+	"es": "Spanish",
+	"it": "Italian",
+	"pt": "Portuguese",
+	"nl": "Dutch",
+	"ru": "Russian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"zh": "Chinese",
+	"ar": "Arabic",
+	"hi": "Hindi",
+}
+
+// LanguageMiddleware returns a model middleware that detects the language of
+// each response and re-asks the model when it answered in the wrong one — a
+// common failure with multilingual Workers AI models, which drift into
+// English or the prompt's language. Detection runs on the complete response,
+// so with streaming the wrong-language chunks of a rejected answer have
+// already been delivered; prefer non-streaming calls when enforcing.
+func LanguageMiddleware(cfg *LanguageConfig) ai.ModelMiddleware {
+	maxRetries := 1
+	var detector func(ctx context.Context, text string) (string, error)
+	var language string
+	if cfg != nil {
+		language = cfg.Language
+		if cfg.MaxRetries > 0 {
+			maxRetries = cfg.MaxRetries
+		}
+		detector = cfg.Detector
+	}
+	if detector == nil {
+		detector = func(ctx context.Context, text string) (string, error) {
+			return detectLanguage(text), nil
+		}
+	}
+
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			resp, err := next(ctx, input, cb)
+			if language == "" {
+				return resp, err
+			}
+			for attempt := 0; attempt < maxRetries; attempt++ {
+				if err != nil || resp == nil || resp.Message == nil {
+					return resp, err
+				}
+				detected, derr := detector(ctx, resp.Message.Text())
+				if derr != nil || detected == "" || detected == language {
+					// Undetermined counts as compliant; the
+					// heuristic only acts when it is sure.
+					return resp, err
+				}
+				retry := *input
+				retry.Messages = append(append([]*ai.Message(nil), input.Messages...),
+					resp.Message,
+					ai.NewUserTextMessage(languageRetryPrompt(language)))
+				resp, err = next(ctx, &retry, cb)
+			}
+			return resp, err
+		}
+	}
+}
+
+// languageRetryPrompt asks for the same answer again in the required
+// language.
+func languageRetryPrompt(code string) string {
+	name := languageNames[code]
+	if name == "" {
+		name = code
+	}
+	return fmt.Sprintf("Your answer is not in %s. Repeat your answer, written entirely in %s.", name, name)
+}
+
+// languageStopwords are short, frequent words distinctive enough to separate
+// the Latin-script languages the heuristic knows.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "is", "that", "with", "for", "this", "are", "was"},
+	"de": {"der", "die", "das", "und", "nicht", "ist", "ein", "mit", "sich"},
+	"fr": {"le", "les", "est", "dans", "pour", "une", "que", "pas", "vous"},
+	"es": {"el", "los", "es", "en", "una", "para", "por", "como", "pero"},
+	"it": {"il", "di", "che", "non", "per", "con", "sono", "della", "una"},
+	"pt": {"o", "de", "que", "em", "uma", "para", "com", "não", "mais"},
+	"nl": {"de", "het", "een", "van", "niet", "met", "voor", "zijn", "dat"},
+}
+
+// detectLanguage guesses the ISO 639-1 language of the text, or returns ""
+// when undetermined. Non-Latin scripts are recognized by their Unicode
+// ranges; Latin-script languages by stopword counting, which needs a couple
+// of sentences to be reliable — short fragments come back undetermined
+// rather than wrong.
+func detectLanguage(text string) string {
+	if code := detectScript(text); code != "" {
+		return code
+	}
+
+	counts := map[string]int{}
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		word = strings.TrimFunc(word, func(r rune) bool {
+			return !unicode.IsLetter(r)
+		})
+		for code, stopwords := range languageStopwords {
+			for _, stopword := range stopwords {
+				if word == stopword {
+					counts[code]++
+				}
+			}
+		}
+	}
+
+	best, bestCount, runnerUp := "", 0, 0
+	for code, count := range counts {
+		switch {
+		case count > bestCount:
+			best, runnerUp, bestCount = code, bestCount, count
+		case count > runnerUp:
+			runnerUp = count
+		}
+	}
+	// Demand a clear winner: at least three hits and twice the runner-up.
+	if bestCount < 3 || bestCount < 2*runnerUp {
+		return ""
+	}
+	return best
+}
+
+// detectScript returns the language implied by the dominant non-Latin script,
+// or "" for Latin and mixed text.
+func detectScript(text string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			counts["ja"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["ru"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["ar"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		}
+	}
+	// Kana mixed with Han is Japanese, not Chinese.
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+	for code, count := range counts {
+		if letters > 0 && count*2 > letters {
+			return code
+		}
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The weather is nice today and the forecast says that it will stay that way.", "en"},
+		{"Das Wetter ist heute schön und die Vorhersage sagt, dass es so bleibt.", "de"},
+		{"Le temps est agréable et les prévisions disent que cela va durer, pour une fois.", "fr"},
+		{"今日はいい天気ですね。", "ja"},
+		{"오늘 날씨가 좋네요.", "ko"},
+		{"今天天气很好。", "zh"},
+		{"Сегодня хорошая погода.", "ru"},
+		// Too short for stopword counting: undetermined, not wrong.
+		{"OK", ""},
+		{"42", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, detectLanguage(tt.text), "text: %s", tt.text)
+	}
+}
+
+func TestLanguageMiddlewareReasks(t *testing.T) {
+	var requests []*ai.ModelRequest
+	responses := []string{
+		"The answer is that the weather is nice and that it will stay that way for the week.",
+		"Die Antwort ist, dass das Wetter schön ist und dass es die ganze Woche so bleibt.",
+	}
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		requests = append(requests, input)
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage(responses[len(requests)-1])}, nil
+	}
+
+	mw := LanguageMiddleware(&LanguageConfig{Language: "de"})
+	resp, err := mw(next)(context.Background(), &ai.ModelRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage("Wie ist das Wetter?")},
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+
+	// The retry carries the history, the wrong answer, and the corrective
+	// instruction.
+	retry := requests[1].Messages
+	require.Len(t, retry, 3)
+	assert.Equal(t, ai.RoleModel, retry[1].Role)
+	assert.Contains(t, retry[2].Text(), "written entirely in German")
+	assert.Contains(t, resp.Message.Text(), "Die Antwort")
+}
+
+func TestLanguageMiddlewarePassesCompliant(t *testing.T) {
+	calls := 0
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		calls++
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage(
+			"Das Wetter ist heute schön und die Sonne scheint, nicht wahr?")}, nil
+	}
+
+	mw := LanguageMiddleware(&LanguageConfig{Language: "de"})
+	_, err := mw(next)(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestLanguageMiddlewareGivesUpAfterRetries(t *testing.T) {
+	calls := 0
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		calls++
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage(
+			"The answer stays in English and the model is not cooperating with the request.")}, nil
+	}
+
+	mw := LanguageMiddleware(&LanguageConfig{Language: "de", MaxRetries: 2})
+	resp, err := mw(next)(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	// One initial call plus two retries; the last answer is returned as is.
+	assert.Equal(t, 3, calls)
+	assert.Contains(t, resp.Message.Text(), "English")
+}
+
+func TestLanguageMiddlewareCustomDetector(t *testing.T) {
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage("whatever")}, nil
+	}
+	detected := ""
+	mw := LanguageMiddleware(&LanguageConfig{
+		Language: "en",
+		Detector: func(ctx context.Context, text string) (string, error) {
+			detected = text
+			return "en", nil
+		},
+	})
+	_, err := mw(next)(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "whatever", detected)
+}
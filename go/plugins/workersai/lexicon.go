@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// LexiconAction is what [LexiconMiddleware] does with an output that matches
+// the word list.
+type LexiconAction string
+
+const (
+	// LexiconMask replaces each matched word with the configured mask.
+	LexiconMask LexiconAction = "mask"
+	// LexiconReject fails the request instead of returning the output.
+	LexiconReject LexiconAction = "reject"
+)
+
+// LexiconConfig configures [LexiconMiddleware].
+type LexiconConfig struct {
+	// Words are filtered on every request, matched case-insensitively on
+	// word boundaries.
+	Words []string
+
+	// Locales adds per-locale word lists on top of Words. The request's
+	// locale comes from [WithLocale] on the context, so one middleware
+	// serves a multilingual app; requests without a locale use Words
+	// alone.
+	Locales map[string][]string
+
+	// Action is what to do with a matching output. The default is
+	// [LexiconMask].
+	Action LexiconAction
+
+	// Mask replaces each matched word when masking. The default is "****".
+	Mask string
+}
+
+// localeContextKey carries a request locale through a request context.
+type localeContextKey struct{}
+
+// WithLocale returns a context carrying the request's locale, e.g. "de",
+// which selects the matching per-locale word list in [LexiconMiddleware].
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+// localeFromContext returns the locale set by [WithLocale], or "".
+func localeFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeContextKey{}).(string)
+	return locale
+}
+
+// lexiconFilter holds the compiled per-locale matchers. Compilation happens
+// once per locale, not per request.
+type lexiconFilter struct {
+	cfg LexiconConfig
+
+	mu       sync.Mutex
+	byLocale map[string]*regexp.Regexp
+}
+
+// LexiconMiddleware returns a model middleware that masks or rejects outputs
+// containing words from the configured lists, before they leave the plugin.
+// It is a lightweight brand-safety guardrail: for simple term policies a
+// lexicon costs nothing per request, where a model-based moderation pass
+// costs another inference.
+func LexiconMiddleware(cfg *LexiconConfig) ai.ModelMiddleware {
+	filter := &lexiconFilter{byLocale: map[string]*regexp.Regexp{}}
+	if cfg != nil {
+		filter.cfg = *cfg
+	}
+	if filter.cfg.Action == "" {
+		filter.cfg.Action = LexiconMask
+	}
+	if filter.cfg.Mask == "" {
+		filter.cfg.Mask = "****"
+	}
+
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			resp, err := next(ctx, input, cb)
+			if err != nil || resp == nil || resp.Message == nil {
+				return resp, err
+			}
+			if err := filter.apply(localeFromContext(ctx), resp.Message); err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
+	}
+}
+
+// apply filters the text parts of one generated message in place, or fails
+// the request when the action is reject.
+func (f *lexiconFilter) apply(locale string, msg *ai.Message) error {
+	matcher := f.matcher(locale)
+	if matcher == nil {
+		return nil
+	}
+	for _, part := range msg.Content {
+		if !part.IsText() || part.Text == "" {
+			continue
+		}
+		if f.cfg.Action == LexiconReject {
+			if match := matcher.FindString(part.Text); match != "" {
+				return errors.Errorf("lexicon filter: output contains the blocked term %q", match)
+			}
+			continue
+		}
+		part.Text = matcher.ReplaceAllString(part.Text, f.cfg.Mask)
+	}
+	return nil
+}
+
+// matcher returns the compiled matcher for one locale's effective word list,
+// or nil when the list is empty.
+func (f *lexiconFilter) matcher(locale string) *regexp.Regexp {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if matcher, ok := f.byLocale[locale]; ok {
+		return matcher
+	}
+	words := append([]string(nil), f.cfg.Words...)
+	words = append(words, f.cfg.Locales[locale]...)
+	matcher := compileLexicon(words)
+	f.byLocale[locale] = matcher
+	return matcher
+}
+
+// compileLexicon builds one case-insensitive whole-word matcher for the list.
+// Longer words first, so a list holding both a word and a phrase containing
+// it matches the phrase.
+func compileLexicon(words []string) *regexp.Regexp {
+	var quoted []string
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return nil
+	}
+	sort.Slice(quoted, func(i, j int) bool { return len(quoted[i]) > len(quoted[j]) })
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
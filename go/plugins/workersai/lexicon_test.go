@@ -0,0 +1,70 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lexiconNext(text string) ai.ModelFunc {
+	return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage(text)}, nil
+	}
+}
+
+func TestLexiconMiddlewareMasks(t *testing.T) {
+	mw := LexiconMiddleware(&LexiconConfig{Words: []string{"Acme", "mega deal"}})
+
+	resp, err := mw(lexiconNext("Try the ACME mega deal from Acme Corp."))(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Try the **** **** from **** Corp.", resp.Message.Text())
+
+	// Word boundaries: no masking inside larger words.
+	resp, err = mw(lexiconNext("macadamia"))(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "macadamia", resp.Message.Text())
+}
+
+func TestLexiconMiddlewareRejects(t *testing.T) {
+	mw := LexiconMiddleware(&LexiconConfig{
+		Words:  []string{"forbidden"},
+		Action: LexiconReject,
+	})
+
+	resp, err := mw(lexiconNext("This mentions the Forbidden topic."))(context.Background(), &ai.ModelRequest{}, nil)
+	require.Error(t, err)
+	assert.Nil(t, resp)
+	assert.Contains(t, err.Error(), `blocked term "Forbidden"`)
+
+	resp, err = mw(lexiconNext("All clear."))(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "All clear.", resp.Message.Text())
+}
+
+func TestLexiconMiddlewareLocales(t *testing.T) {
+	mw := LexiconMiddleware(&LexiconConfig{
+		Words:   []string{"shared"},
+		Locales: map[string][]string{"de": {"verboten"}},
+		Mask:    "[entfernt]",
+	})
+
+	// The German list applies only when the context carries that locale.
+	de := WithLocale(context.Background(), "de")
+	resp, err := mw(lexiconNext("Das ist verboten und shared."))(de, &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Das ist [entfernt] und [entfernt].", resp.Message.Text())
+
+	resp, err = mw(lexiconNext("Das ist verboten und shared."))(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Das ist verboten und [entfernt].", resp.Message.Text())
+}
+
+func TestLexiconMiddlewareEmptyList(t *testing.T) {
+	mw := LexiconMiddleware(nil)
+	resp, err := mw(lexiconNext("anything goes"))(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "anything goes", resp.Message.Text())
+}
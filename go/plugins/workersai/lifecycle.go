@@ -0,0 +1,69 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"github.com/pkg/errors"
+)
+
+// acquire registers the start of a request against the plugin's lifecycle:
+// it fails once Close has been called and otherwise counts the request as
+// in-flight until the paired release.
+func (w *WorkersAI) acquire() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return errors.New("workersai: plugin is closed")
+	}
+	w.inflight.Add(1)
+	return nil
+}
+
+// release marks a request acquired with acquire as finished.
+func (w *WorkersAI) release() {
+	w.inflight.Done()
+}
+
+// Close shuts the plugin down cleanly so flow servers can drain on SIGTERM:
+// new requests are rejected, in-flight ones are waited for, the keepalive
+// pinger stops and pooled connections are released. Close is idempotent;
+// the plugin cannot be reused afterwards.
+func (w *WorkersAI) Close() error {
+	w.mu.Lock()
+	if !w.initted || w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	stop := w.keepAliveStop
+	w.keepAliveStop = nil
+	reloadStop := w.reloadStop
+	w.reloadStop = nil
+	w.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+	}
+	if reloadStop != nil {
+		close(reloadStop)
+	}
+	// Wait for requests that were already past acquire when closed flipped.
+	w.inflight.Wait()
+	if w.baseTransport != nil {
+		w.baseTransport.CloseIdleConnections()
+	}
+	return nil
+}
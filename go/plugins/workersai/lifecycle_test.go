@@ -0,0 +1,71 @@
+package workersai
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClose(t *testing.T) {
+	t.Run("is a no-op before Init", func(t *testing.T) {
+		w := &WorkersAI{}
+		require.NoError(t, w.Close())
+	})
+
+	t.Run("rejects new requests and is idempotent", func(t *testing.T) {
+		w := &WorkersAI{initted: true}
+		require.NoError(t, w.Close())
+		require.NoError(t, w.Close())
+
+		gen := &generator{model: "m", plugin: w}
+		_, err := gen.generate(context.Background(), &ai.ModelRequest{}, nil)
+		require.ErrorContains(t, err, "plugin is closed")
+
+		e := &embedder{model: "m", plugin: w}
+		_, err = e.embed(context.Background(), &ai.EmbedRequest{})
+		require.ErrorContains(t, err, "plugin is closed")
+	})
+
+	t.Run("stops the keepalive pinger", func(t *testing.T) {
+		w := &WorkersAI{initted: true, keepAliveStop: make(chan struct{})}
+		stop := w.keepAliveStop
+		require.NoError(t, w.Close())
+		select {
+		case <-stop:
+		default:
+			t.Fatal("keepalive stop channel was not closed")
+		}
+	})
+
+	t.Run("drains in-flight requests", func(t *testing.T) {
+		w := &WorkersAI{initted: true}
+		require.NoError(t, w.acquire())
+
+		var mu sync.Mutex
+		closed := false
+		go func() {
+			w.Close()
+			mu.Lock()
+			closed = true
+			mu.Unlock()
+		}()
+
+		// Close must still be waiting on the in-flight request.
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		assert.False(t, closed)
+		mu.Unlock()
+
+		w.release()
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return closed
+		}, time.Second, 5*time.Millisecond)
+	})
+}
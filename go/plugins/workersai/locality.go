@@ -0,0 +1,60 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Jurisdictions accepted by [Config.Jurisdiction], matching the ones
+// Cloudflare's data-locality products recognize.
+const (
+	// JurisdictionEU restricts processing to Cloudflare's European Union
+	// jurisdiction.
+	JurisdictionEU = "eu"
+	// JurisdictionFedRAMP restricts processing to Cloudflare's FedRAMP
+	// compliant infrastructure.
+	JurisdictionFedRAMP = "fedramp"
+)
+
+// jurisdictionHeader carries the data-locality hint to Cloudflare, honored
+// where the product supports jurisdictional restrictions.
+const jurisdictionHeader = "cf-jurisdiction"
+
+// validateJurisdiction rejects jurisdiction values Cloudflare does not
+// recognize, so a typo fails the request instead of silently running without
+// the residency restriction the caller asked for.
+func validateJurisdiction(j string) error {
+	switch j {
+	case "", JurisdictionEU, JurisdictionFedRAMP:
+		return nil
+	default:
+		return fmt.Errorf("unknown jurisdiction %q; supported values are %q and %q", j, JurisdictionEU, JurisdictionFedRAMP)
+	}
+}
+
+// servingColo extracts the serving data center from a cf-ray ID, whose
+// suffix is the IATA-style colo code ("93a1b2c3d4e5f607-AMS" served from
+// Amsterdam). Customers with data-residency requirements can audit from the
+// response metadata where each request was actually processed.
+func servingColo(ray string) string {
+	i := strings.LastIndexByte(ray, '-')
+	if i < 0 || i == len(ray)-1 {
+		return ""
+	}
+	return ray[i+1:]
+}
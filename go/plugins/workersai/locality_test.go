@@ -0,0 +1,32 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateJurisdiction(t *testing.T) {
+	assert.NoError(t, validateJurisdiction(""))
+	assert.NoError(t, validateJurisdiction(JurisdictionEU))
+	assert.NoError(t, validateJurisdiction(JurisdictionFedRAMP))
+
+	err := validateJurisdiction("EU-west")
+	require.ErrorContains(t, err, `unknown jurisdiction "EU-west"`)
+}
+
+func TestJurisdictionHeader(t *testing.T) {
+	cfg := &Config{Jurisdiction: JurisdictionEU}
+	assert.Equal(t, "eu", cfg.requestHeaders()[jurisdictionHeader])
+
+	_, ok := (&Config{}).requestHeaders()[jurisdictionHeader]
+	assert.False(t, ok)
+}
+
+func TestServingColo(t *testing.T) {
+	assert.Equal(t, "AMS", servingColo("93a1b2c3d4e5f607-AMS"))
+	assert.Equal(t, "", servingColo("93a1b2c3d4e5f607"))
+	assert.Equal(t, "", servingColo("93a1b2c3d4e5f607-"))
+	assert.Equal(t, "", servingColo(""))
+}
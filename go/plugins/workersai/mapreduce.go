@@ -0,0 +1,186 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// MapReduceOptions configures [MapReduce].
+type MapReduceOptions struct {
+	// Model runs the map and reduce prompts. Required.
+	Model ai.Model
+
+	// MapPrompt is the per-chunk prompt; {{chunk}} substitutes the chunk
+	// text and {{index}} its zero-based position. Required.
+	MapPrompt string
+
+	// ReducePrompt combines the per-chunk outputs; {{results}} substitutes
+	// them, separated by blank lines in chunk order. Required.
+	ReducePrompt string
+
+	// ChunkChars is the maximum chunk size in characters. The default of
+	// 12000 leaves an 8k-token Workers AI model room for the prompt and
+	// its answer; chunks split on paragraph boundaries where possible.
+	ChunkChars int
+
+	// Concurrency is how many map calls are in flight at once. The
+	// default is 4.
+	Concurrency int
+
+	// Config is passed through as every request's config, for example a
+	// [Config].
+	Config any
+}
+
+// MapReduceResult is the outcome of a map-reduce run.
+type MapReduceResult struct {
+	// Output is the reduce stage's answer — or the single map output when
+	// the document fit in one chunk and no reduce was needed.
+	Output string
+	// MapOutputs holds the per-chunk outputs, in chunk order.
+	MapOutputs []string
+	// Usage accumulates token usage across every call, for cost tracking.
+	Usage *ai.GenerationUsage
+}
+
+// MapReduce runs a prompt over a document too large for one context window:
+// the document is chunked, the map prompt runs per chunk with bounded
+// concurrency, and the reduce prompt combines the outputs. The classic use
+// is summarization or extraction over large documents on the small context
+// windows of Workers AI models. A document that fits in one chunk skips the
+// reduce stage. The combined map outputs feed a single reduce call, so keep
+// map outputs short — a summary-of-summaries, not a concatenation.
+func MapReduce(ctx context.Context, document string, opts *MapReduceOptions) (*MapReduceResult, error) {
+	if opts == nil || opts.Model == nil {
+		return nil, errors.New("workersai: MapReduce requires a model")
+	}
+	if opts.MapPrompt == "" || opts.ReducePrompt == "" {
+		return nil, errors.New("workersai: MapReduce requires a map prompt and a reduce prompt")
+	}
+	cfg := *opts
+	if cfg.ChunkChars <= 0 {
+		cfg.ChunkChars = 12000
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+
+	result := &MapReduceResult{Usage: &ai.GenerationUsage{}}
+	var usageMu sync.Mutex
+	ask := func(prompt string) (string, error) {
+		resp, err := cfg.Model.Generate(ctx, &ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserTextMessage(prompt)},
+			Config:   cfg.Config,
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		if resp.Usage != nil {
+			usageMu.Lock()
+			result.Usage.InputTokens += resp.Usage.InputTokens
+			result.Usage.OutputTokens += resp.Usage.OutputTokens
+			usageMu.Unlock()
+		}
+		return strings.TrimSpace(resp.Message.Text()), nil
+	}
+
+	chunks := chunkDocument(document, cfg.ChunkChars)
+	result.MapOutputs = make([]string, len(chunks))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sem := make(chan struct{}, cfg.Concurrency)
+	for i, chunk := range chunks {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			prompt := strings.NewReplacer(
+				"{{chunk}}", chunk,
+				"{{index}}", strconv.Itoa(i),
+			).Replace(cfg.MapPrompt)
+			out, err := ask(prompt)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errors.Wrapf(err, "map call %d failed", i)
+					cancel()
+				}
+				return
+			}
+			result.MapOutputs[i] = out
+		}(i, chunk)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if len(chunks) == 1 {
+		result.Output = result.MapOutputs[0]
+		return result, nil
+	}
+
+	reduce := strings.ReplaceAll(cfg.ReducePrompt, "{{results}}", strings.Join(result.MapOutputs, "\n\n"))
+	out, err := ask(reduce)
+	if err != nil {
+		return nil, errors.Wrap(err, "reduce call failed")
+	}
+	result.Output = out
+	return result, nil
+}
+
+// chunkDocument splits the document into chunks of at most maxChars,
+// preferring paragraph breaks, then line breaks, then spaces, and only then
+// cutting mid-word.
+func chunkDocument(document string, maxChars int) []string {
+	var chunks []string
+	for len(document) > maxChars {
+		cut := maxChars
+		for _, sep := range []string{"\n\n", "\n", " "} {
+			if i := strings.LastIndex(document[:maxChars], sep); i > 0 {
+				cut = i + len(sep)
+				break
+			}
+		}
+		chunks = append(chunks, strings.TrimSpace(document[:cut]))
+		document = document[cut:]
+	}
+	if trimmed := strings.TrimSpace(document); trimmed != "" || len(chunks) == 0 {
+		chunks = append(chunks, trimmed)
+	}
+	return chunks
+}
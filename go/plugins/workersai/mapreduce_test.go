@@ -0,0 +1,89 @@
+package workersai
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingModel echoes each prompt back and counts its tokens as one in, one
+// out, so usage accumulation is checkable.
+type countingModel struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (m *countingModel) Name() string { return "counting" }
+
+func (m *countingModel) Generate(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	prompt := req.Messages[0].Text()
+	m.mu.Lock()
+	m.prompts = append(m.prompts, prompt)
+	m.mu.Unlock()
+	return &ai.ModelResponse{
+		Message: ai.NewModelTextMessage("out: " + prompt),
+		Usage:   &ai.GenerationUsage{InputTokens: 10, OutputTokens: 5},
+	}, nil
+}
+
+func TestMapReduce(t *testing.T) {
+	model := &countingModel{}
+	document := "alpha alpha alpha\n\nbeta beta beta\n\ngamma gamma gamma"
+
+	result, err := MapReduce(context.Background(), document, &MapReduceOptions{
+		Model:        model,
+		MapPrompt:    "summarize chunk {{index}}: {{chunk}}",
+		ReducePrompt: "combine: {{results}}",
+		ChunkChars:   20,
+	})
+	require.NoError(t, err)
+
+	// Chunks split on paragraph boundaries; map outputs keep chunk order.
+	require.Len(t, result.MapOutputs, 3)
+	assert.Equal(t, "out: summarize chunk 0: alpha alpha alpha", result.MapOutputs[0])
+	assert.Equal(t, "out: summarize chunk 2: gamma gamma gamma", result.MapOutputs[2])
+
+	// The reduce prompt carries every map output and produces the result.
+	assert.True(t, strings.HasPrefix(result.Output, "out: combine:"), "output %q", result.Output)
+	assert.Contains(t, result.Output, "beta beta beta")
+
+	// Three map calls plus one reduce, each 10 in / 5 out.
+	assert.Equal(t, 40, result.Usage.InputTokens)
+	assert.Equal(t, 20, result.Usage.OutputTokens)
+}
+
+func TestMapReduceSingleChunkSkipsReduce(t *testing.T) {
+	model := &countingModel{}
+	result, err := MapReduce(context.Background(), "short document", &MapReduceOptions{
+		Model:        model,
+		MapPrompt:    "summarize: {{chunk}}",
+		ReducePrompt: "combine: {{results}}",
+	})
+	require.NoError(t, err)
+	assert.Len(t, model.prompts, 1)
+	assert.Equal(t, "out: summarize: short document", result.Output)
+}
+
+func TestMapReduceRequiresPrompts(t *testing.T) {
+	_, err := MapReduce(context.Background(), "doc", &MapReduceOptions{Model: &countingModel{}})
+	require.Error(t, err)
+	_, err = MapReduce(context.Background(), "doc", nil)
+	require.Error(t, err)
+}
+
+func TestChunkDocument(t *testing.T) {
+	chunks := chunkDocument("aaa\n\nbbb\n\nccc", 5)
+	assert.Equal(t, []string{"aaa", "bbb", "ccc"}, chunks)
+
+	// No separator within the limit: cut mid-word rather than overflow.
+	chunks = chunkDocument("abcdefghij", 4)
+	assert.Equal(t, []string{"abcd", "efgh", "ij"}, chunks)
+
+	// An empty document still yields one (empty) chunk.
+	assert.Equal(t, []string{""}, chunkDocument("", 10))
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/plugins/internal/uri"
+)
+
+// maxAudioSizeBytes is the largest decoded audio payload we send inline.
+// Workers AI rejects larger request bodies, so fail fast with a clear error.
+const maxAudioSizeBytes = 25 << 20 // 25 MiB
+
+// audioContentTypes maps the audio MIME types Workers AI voice models accept
+// to their canonical form. Aliases are normalized before the request is sent.
+var audioContentTypes = map[string]string{
+	"audio/wav":   "audio/wav",
+	"audio/x-wav": "audio/wav",
+	"audio/wave":  "audio/wav",
+	"audio/mpeg":  "audio/mpeg",
+	"audio/mp3":   "audio/mpeg",
+	"audio/ogg":   "audio/ogg",
+	"audio/webm":  "audio/webm",
+	"audio/flac":  "audio/flac",
+	"audio/aac":   "audio/aac",
+	"audio/mp4":   "audio/mp4",
+}
+
+// isAudioPart reports whether the part carries inline audio media.
+func isAudioPart(p *ai.Part) bool {
+	return p.IsMedia() && strings.HasPrefix(p.ContentType, "audio/")
+}
+
+// toMessageContent flattens a message's parts into the single content string
+// the client library sends. Messages without audio parts pass through
+// unchanged; audio parts are validated and inlined as data URIs, which
+// requires the model to declare media support.
+func toMessageContent(msg *ai.Message, supports *ai.ModelSupports, policy UnknownContentPolicy) (string, error) {
+	hasAudio := false
+	for _, p := range msg.Content {
+		if isAudioPart(p) {
+			hasAudio = true
+			break
+		}
+	}
+	if hasAudio && (supports == nil || !supports.Media) {
+		return "", fmt.Errorf("model does not accept audio input; use a voice-capable model or remove the audio part")
+	}
+
+	var sb strings.Builder
+	appendPiece := func(s string) {
+		if sb.Len() > 0 && !endsWithSpace(sb.String()) && !startsWithSpace(s) {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(s)
+	}
+	for _, p := range msg.Content {
+		switch {
+		case p.IsText():
+			if p.Text != "" {
+				appendPiece(p.Text)
+			}
+		case isAudioPart(p):
+			dataURI, err := audioPartToDataURI(p)
+			if err != nil {
+				return "", err
+			}
+			appendPiece(dataURI)
+		default:
+			// Data, custom and non-audio media parts cannot be carried;
+			// the policy decides whether that is fatal, logged or
+			// stringified.
+			piece, err := handleUnknownPart(policy, msg.Role, p)
+			if err != nil {
+				return "", err
+			}
+			if piece != "" {
+				appendPiece(piece)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// joinTextParts flattens a message's text parts in order. Unlike
+// [ai.Message.Text], which concatenates parts with nothing between them, a
+// newline is inserted wherever two adjacent parts would otherwise run
+// together, so prompt structure assembled from multiple parts (as dotprompt
+// does) survives the flattening. Parts that already carry their own boundary
+// whitespace are joined as-is.
+func joinTextParts(msg *ai.Message) string {
+	var sb strings.Builder
+	for _, p := range msg.Content {
+		if !p.IsText() || p.Text == "" {
+			continue
+		}
+		if sb.Len() > 0 && !endsWithSpace(sb.String()) && !startsWithSpace(p.Text) {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(p.Text)
+	}
+	return sb.String()
+}
+
+func endsWithSpace(s string) bool {
+	return s != strings.TrimRight(s, " \t\n\r")
+}
+
+func startsWithSpace(s string) bool {
+	return s != strings.TrimLeft(s, " \t\n\r")
+}
+
+// audioPartToDataURI validates an audio media part and re-encodes it as a
+// base64 data URI suitable for inlining into a chat message. It normalizes
+// MIME-type aliases and enforces the inline size limit.
+func audioPartToDataURI(p *ai.Part) (string, error) {
+	contentType, data, err := uri.Data(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to read audio part: %w", err)
+	}
+
+	if strings.HasPrefix(string(data), "http") || strings.HasPrefix(string(data), "gs://") {
+		return "", fmt.Errorf("audio parts must be supplied inline, got remote URL %q", string(data))
+	}
+
+	canonical, ok := audioContentTypes[contentType]
+	if !ok {
+		return "", fmt.Errorf("unsupported audio content type %q", contentType)
+	}
+
+	if len(data) > maxAudioSizeBytes {
+		return "", fmt.Errorf("audio part of %d bytes exceeds the %d byte limit", len(data), maxAudioSizeBytes)
+	}
+
+	return "data:" + canonical + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// generateInstruments are the per-model instruments recorded around every
+// generate call. They flow through whatever MeterProvider the app has
+// installed (see, e.g., plugins/googlecloud), so capacity planning across the
+// catalog can use the same pipeline as the rest of Genkit's metrics.
+type generateInstruments struct {
+	requests      metric.Int64Counter
+	latency       metric.Int64Histogram
+	firstToken    metric.Int64Histogram
+	tokensPerSec  metric.Float64Histogram
+	outputTokens  metric.Int64Counter
+	requestErrors metric.Int64Counter
+}
+
+// Delay instrument creation until first use so a MeterProvider installed
+// after plugin init is still picked up.
+var fetchGenerateInstruments = sync.OnceValue(func() *generateInstruments {
+	insts, err := initGenerateInstruments()
+	if err != nil {
+		// Do not stop the program because we can't collect metrics.
+		slog.Default().Error("workersai metric initialization failed; no metrics will be collected", "err", err)
+		return nil
+	}
+	return insts
+})
+
+func initGenerateInstruments() (*generateInstruments, error) {
+	meter := otel.Meter("genkit/workersai")
+	var err error
+	insts := &generateInstruments{}
+	insts.requests, err = meter.Int64Counter("workersai/generate/requests")
+	if err != nil {
+		return nil, err
+	}
+	insts.latency, err = meter.Int64Histogram("workersai/generate/latency", metric.WithUnit("ms"))
+	if err != nil {
+		return nil, err
+	}
+	insts.firstToken, err = meter.Int64Histogram("workersai/generate/time_to_first_token", metric.WithUnit("ms"))
+	if err != nil {
+		return nil, err
+	}
+	insts.tokensPerSec, err = meter.Float64Histogram("workersai/generate/output_tokens_per_second")
+	if err != nil {
+		return nil, err
+	}
+	insts.outputTokens, err = meter.Int64Counter("workersai/generate/output_tokens")
+	if err != nil {
+		return nil, err
+	}
+	insts.requestErrors, err = meter.Int64Counter("workersai/generate/errors")
+	if err != nil {
+		return nil, err
+	}
+	return insts, nil
+}
+
+// writeGenerateMetrics records one generate call. firstToken is zero when no
+// chunk was emitted before the response completed.
+func writeGenerateMetrics(ctx context.Context, model string, latency, firstToken time.Duration, resp *ai.ModelResponse, err error) {
+	insts := fetchGenerateInstruments()
+	if insts == nil {
+		return
+	}
+	opt := metric.WithAttributes(
+		attribute.String("model", model),
+		attribute.String("source", "go"),
+	)
+	insts.requests.Add(ctx, 1, opt)
+	insts.latency.Record(ctx, latency.Milliseconds(), opt)
+	if err != nil {
+		insts.requestErrors.Add(ctx, 1, opt)
+		return
+	}
+	if firstToken > 0 {
+		insts.firstToken.Record(ctx, firstToken.Milliseconds(), opt)
+	}
+	if resp != nil && resp.Usage != nil && resp.Usage.OutputTokens > 0 {
+		insts.outputTokens.Add(ctx, int64(resp.Usage.OutputTokens), opt)
+		if secs := latency.Seconds(); secs > 0 {
+			insts.tokensPerSec.Record(ctx, float64(resp.Usage.OutputTokens)/secs, opt)
+		}
+	}
+}
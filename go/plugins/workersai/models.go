@@ -1,61 +1,187 @@
 package workersai
 
-import "github.com/firebase/genkit/go/ai"
+import (
+	"fmt"
+	"strings"
 
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// Model identifier constants for the supported text-generation models, so
+// users don't hardcode "@cf/..." strings and typos fail at compile time.
 const (
-	mistralSmall3124BInstruct     = "@cf/mistralai/mistral-small-3.1-24b-instruct"
-	metaLlama3370bInstructFp8Fast = "@cf/meta/llama-3.3-70b-instruct-fp8-fast"
-	metaLlama4scout17b16einstruct = "@cf/meta/llama-4-scout-17b-16e-instruct"
-	qwenQwen330ba3bfp8            = "@cf/qwen/qwen3-30b-a3b-fp8"
+	ModelMistralSmall3124BInstruct = "@cf/mistralai/mistral-small-3.1-24b-instruct"
+	ModelLlama3370BInstructFp8Fast = "@cf/meta/llama-3.3-70b-instruct-fp8-fast"
+	ModelLlama4Scout17B16EInstruct = "@cf/meta/llama-4-scout-17b-16e-instruct"
+	ModelQwen330BA3BFp8            = "@cf/qwen/qwen3-30b-a3b-fp8"
+	ModelDeepSeekR1DistillQwen32B  = "@cf/deepseek-ai/deepseek-r1-distill-qwen-32b"
 )
 
+// Typed ref helpers for the supported models. Pass the returned ref to
+// ai.WithModel; use [ModelRef] directly to attach a default config.
+
+// MistralSmall3124B returns a ModelRef for Mistral Small 3.1 24B Instruct.
+func MistralSmall3124B() ai.ModelRef {
+	return ModelRef(ModelMistralSmall3124BInstruct, nil)
+}
+
+// Llama3370BFast returns a ModelRef for Llama 3.3 70B Instruct (fp8 fast).
+func Llama3370BFast() ai.ModelRef {
+	return ModelRef(ModelLlama3370BInstructFp8Fast, nil)
+}
+
+// Llama4Scout returns a ModelRef for Llama 4 Scout 17B 16E Instruct.
+func Llama4Scout() ai.ModelRef {
+	return ModelRef(ModelLlama4Scout17B16EInstruct, nil)
+}
+
+// Qwen330BA3B returns a ModelRef for Qwen3 30B A3B (fp8).
+func Qwen330BA3B() ai.ModelRef {
+	return ModelRef(ModelQwen330BA3BFp8, nil)
+}
+
+// DeepSeekR1DistillQwen32B returns a ModelRef for DeepSeek-R1-Distill-Qwen-32B.
+// Its <think> output is extracted into reasoning parts; see
+// [Config.ShowReasoning] and [Config.ReasoningEffort].
+func DeepSeekR1DistillQwen32B() ai.ModelRef {
+	return ModelRef(ModelDeepSeekR1DistillQwen32B, nil)
+}
+
+// validateModelName checks that name looks like a Workers AI model
+// identifier and returns an actionable error when it doesn't, suggesting the
+// closest known model for likely typos.
+func validateModelName(name string) error {
+	if name == "" {
+		return errors.New(`model name is empty; expected an identifier like "@cf/meta/llama-4-scout-17b-16e-instruct"`)
+	}
+	if !strings.HasPrefix(name, "@cf/") {
+		if suggestion := closestKnownModel(name); suggestion != "" {
+			return fmt.Errorf("model %q is missing the %q prefix; did you mean %q?", name, "@cf/", suggestion)
+		}
+		return fmt.Errorf(`model %q does not look like a Workers AI model identifier; expected the form "@cf/<vendor>/<model>"`, name)
+	}
+	if strings.Count(name, "/") < 2 {
+		if suggestion := closestKnownModel(name); suggestion != "" {
+			return fmt.Errorf("model %q is incomplete; did you mean %q?", name, suggestion)
+		}
+		return fmt.Errorf(`model %q is incomplete; expected the form "@cf/<vendor>/<model>"`, name)
+	}
+	if suggestion := closestKnownModel(name); suggestion != "" && suggestion != name {
+		return fmt.Errorf("unknown model %q; did you mean %q?", name, suggestion)
+	}
+	return nil
+}
+
+// closestKnownModel returns the supported model whose name is within a small
+// edit distance of name, or "" when nothing is close enough to suggest.
+func closestKnownModel(name string) string {
+	target := strings.TrimPrefix(name, "@cf/")
+	best := ""
+	bestDist := 4
+	for known := range supportedWorkersAIModels {
+		if known == name {
+			return known
+		}
+		d := editDistance(strings.TrimPrefix(known, "@cf/"), target)
+		if d < bestDist {
+			best, bestDist = known, d
+		}
+	}
+	return best
+}
+
+// editDistance computes the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
 var (
 	workersAIModels = []string{
-		mistralSmall3124BInstruct,
-		metaLlama3370bInstructFp8Fast,
-		metaLlama4scout17b16einstruct,
+		ModelMistralSmall3124BInstruct,
+		ModelLlama3370BInstructFp8Fast,
+		ModelLlama4Scout17B16EInstruct,
 	}
 
+	// supportedWorkersAIModels is the known catalog, including each model's
+	// lifecycle stage so tooling can warn when a flow depends on a beta
+	// model.
 	supportedWorkersAIModels = map[string]ai.ModelInfo{
-		mistralSmall3124BInstruct: {
-			Label: mistralSmall3124BInstruct,
+		ModelMistralSmall3124BInstruct: {
+			Label: ModelMistralSmall3124BInstruct,
+			Stage: ai.ModelStageStable,
 			Supports: &ai.ModelSupports{
-				Multiturn:  true,
-				Tools:      true,
-				ToolChoice: false,
-				SystemRole: true,
-				Media:      true,
+				Multiturn:   true,
+				Tools:       true,
+				ToolChoice:  false,
+				SystemRole:  true,
+				Media:       true,
+				Constrained: ai.ConstrainedSupportNoTools,
 			},
 		},
 
-		metaLlama3370bInstructFp8Fast: {
-			Label: metaLlama3370bInstructFp8Fast,
+		ModelLlama3370BInstructFp8Fast: {
+			Label: ModelLlama3370BInstructFp8Fast,
+			Stage: ai.ModelStageStable,
+			Supports: &ai.ModelSupports{
+				Multiturn:   true,
+				Tools:       true,
+				ToolChoice:  false,
+				SystemRole:  true,
+				Media:       true,
+				Constrained: ai.ConstrainedSupportNoTools,
+			},
+		},
+		ModelLlama4Scout17B16EInstruct: {
+			Label: ModelLlama4Scout17B16EInstruct,
+			Stage: ai.ModelStageFeatured,
 			Supports: &ai.ModelSupports{
-				Multiturn:  true,
-				Tools:      true,
-				ToolChoice: false,
-				SystemRole: true,
-				Media:      true,
+				Multiturn:   true,
+				Tools:       true,
+				ToolChoice:  false,
+				SystemRole:  true,
+				Media:       true,
+				Constrained: ai.ConstrainedSupportNoTools,
 			},
 		},
-		metaLlama4scout17b16einstruct: {
-			Label: metaLlama4scout17b16einstruct,
+		ModelQwen330BA3BFp8: {
+			Label: ModelQwen330BA3BFp8,
+			// Beta in the Workers AI catalog.
+			Stage: ai.ModelStageUnstable,
 			Supports: &ai.ModelSupports{
-				Multiturn:  true,
-				Tools:      true,
-				ToolChoice: false,
-				SystemRole: true,
-				Media:      true,
+				Multiturn:   true,
+				Tools:       true,
+				ToolChoice:  true,
+				SystemRole:  true,
+				Media:       true,
+				Constrained: ai.ConstrainedSupportNoTools,
 			},
 		},
-		qwenQwen330ba3bfp8: {
-			Label: qwenQwen330ba3bfp8,
+		ModelDeepSeekR1DistillQwen32B: {
+			Label: ModelDeepSeekR1DistillQwen32B,
+			Stage: ai.ModelStageStable,
+			// A pure reasoning model: no tool calling or vision, and its
+			// <think> output is extracted into reasoning parts.
 			Supports: &ai.ModelSupports{
-				Multiturn:  true,
-				Tools:      true,
-				ToolChoice: true,
-				SystemRole: true,
-				Media:      true,
+				Multiturn:   true,
+				SystemRole:  true,
+				Constrained: ai.ConstrainedSupportNoTools,
 			},
 		},
 	}
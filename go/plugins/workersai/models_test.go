@@ -0,0 +1,89 @@
+package workersai
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateModelName(t *testing.T) {
+	testCases := []struct {
+		name          string
+		model         string
+		expectErr     bool
+		errorContains string
+	}{
+		{
+			name:  "known model passes",
+			model: ModelLlama4Scout17B16EInstruct,
+		},
+		{
+			name:  "unknown but well-formed model passes",
+			model: "@cf/google/gemma-7b-it",
+		},
+		{
+			name:          "empty name",
+			model:         "",
+			expectErr:     true,
+			errorContains: "model name is empty",
+		},
+		{
+			name:          "missing @cf/ prefix suggests the full name",
+			model:         "qwen/qwen3-30b-a3b-fp8",
+			expectErr:     true,
+			errorContains: ModelQwen330BA3BFp8,
+		},
+		{
+			name:          "typo in a known model suggests the correction",
+			model:         "@cf/qwen/qwen3-30b-a3b-fp16",
+			expectErr:     true,
+			errorContains: ModelQwen330BA3BFp8,
+		},
+		{
+			name:          "incomplete name",
+			model:         "@cf/meta",
+			expectErr:     true,
+			errorContains: "incomplete",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateModelName(tc.model)
+			if tc.expectErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errorContains)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestCatalogModels(t *testing.T) {
+	seen := map[string]bool{}
+	for _, name := range catalogTextModels {
+		assert.NoError(t, validateCatalogName(name))
+		assert.False(t, seen[name], "duplicate catalog entry %s", name)
+		seen[name] = true
+	}
+
+	info := catalogModelInfo("@cf/microsoft/phi-2")
+	assert.Equal(t, ai.ModelStageUnstable, info.Stage)
+	assert.True(t, info.Supports.Multiturn)
+	assert.False(t, info.Supports.Tools, "catalog models must not advertise tools")
+	assert.False(t, info.Supports.Media, "catalog models must not advertise media")
+}
+
+// validateCatalogName checks the basic @cf/<vendor>/<model> shape without
+// the curated-catalog typo suggestions.
+func validateCatalogName(name string) error {
+	if !strings.HasPrefix(name, "@cf/") || strings.Count(name, "/") < 2 {
+		return fmt.Errorf("model %q is not a Workers AI identifier", name)
+	}
+	return nil
+}
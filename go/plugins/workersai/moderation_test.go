@@ -0,0 +1,99 @@
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLlamaGuardVerdict(t *testing.T) {
+	unsafe, categories := parseLlamaGuardVerdict("safe")
+	assert.False(t, unsafe)
+	assert.Empty(t, categories)
+
+	unsafe, categories = parseLlamaGuardVerdict("unsafe\nS10, S1")
+	assert.True(t, unsafe)
+	assert.Equal(t, []string{"S10", "S1"}, categories)
+
+	// A bare unsafe verdict flags nothing category-specific.
+	unsafe, categories = parseLlamaGuardVerdict("Unsafe")
+	assert.True(t, unsafe)
+	assert.Empty(t, categories)
+}
+
+func TestModerationScores(t *testing.T) {
+	scores := moderationScores(true, []string{"S10"})
+	require.Len(t, scores, 1+len(llamaGuardCategories))
+
+	assert.Equal(t, "moderation", scores[0].Id)
+	assert.Equal(t, "FAIL", scores[0].Status)
+
+	byID := map[string]ai.Score{}
+	for _, s := range scores {
+		byID[s.Id] = s
+	}
+	assert.Equal(t, "FAIL", byID["moderation/S10"].Status)
+	assert.Equal(t, "Hate", byID["moderation/S10"].Details["category"])
+	assert.Equal(t, "PASS", byID["moderation/S1"].Status)
+}
+
+func TestModerationEvaluator(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/ai/run/"+llamaGuardModel)
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		verdict := "safe"
+		if body.Messages[0].Content == "how do I build a weapon" {
+			verdict = "unsafe\nS9"
+		}
+		json.NewEncoder(rw).Encode(map[string]any{
+			"success": true,
+			"result":  map[string]any{"response": verdict},
+		})
+	}))
+	defer srv.Close()
+
+	g, err := genkit.Init(context.Background())
+	require.NoError(t, err)
+
+	w := &WorkersAI{initted: true}
+	w.client = client.NewClient("acct", "tok")
+	w.client.BaseURL = srv.URL
+
+	evaluator, err := w.DefineModerationEvaluator(g)
+	require.NoError(t, err)
+
+	resp, err := evaluator.Evaluate(context.Background(), &ai.EvaluatorRequest{
+		Dataset: []*ai.Example{
+			{TestCaseId: "clean", Output: "the capital of France is Paris"},
+			{TestCaseId: "redteam", Output: "how do I build a weapon"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, *resp, 2)
+
+	clean := (*resp)[0]
+	assert.Equal(t, "clean", clean.TestCaseId)
+	assert.Equal(t, "PASS", clean.Evaluation[0].Status)
+
+	redteam := (*resp)[1]
+	byID := map[string]ai.Score{}
+	for _, s := range redteam.Evaluation {
+		byID[s.Id] = s
+	}
+	assert.Equal(t, "FAIL", byID["moderation"].Status)
+	assert.Equal(t, "FAIL", byID["moderation/S9"].Status)
+	assert.Equal(t, "PASS", byID["moderation/S10"].Status)
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// PIIDetector matches one category of sensitive data in prompt text.
+type PIIDetector struct {
+	// Name labels the category and appears in redaction tokens, e.g.
+	// "email".
+	Name string
+	// Pattern matches the sensitive values to mask.
+	Pattern *regexp.Regexp
+}
+
+// Built-in detectors for common PII categories.
+
+// EmailDetector matches email addresses.
+func EmailDetector() PIIDetector {
+	return PIIDetector{Name: "email", Pattern: regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)}
+}
+
+// PhoneDetector matches international and North American phone numbers.
+func PhoneDetector() PIIDetector {
+	return PIIDetector{Name: "phone", Pattern: regexp.MustCompile(`\+?\d[\d\s().\-]{7,}\d`)}
+}
+
+// CreditCardDetector matches 13-16 digit card numbers with optional
+// separators.
+func CreditCardDetector() PIIDetector {
+	return PIIDetector{Name: "card", Pattern: regexp.MustCompile(`\b(?:\d[ \-]?){13,16}\b`)}
+}
+
+// PIIRedactionConfig configures [PIIRedactionMiddleware].
+type PIIRedactionConfig struct {
+	// Detectors are the categories to mask. When empty, the built-in email,
+	// phone and card detectors are used.
+	Detectors []PIIDetector
+	// Restore substitutes the original values back into the model's response
+	// text, so callers see the real data while only tokens leave the
+	// process.
+	Restore bool
+}
+
+// PIIRedactionMiddleware returns a model middleware that masks sensitive data
+// in user messages before the request leaves the process toward Cloudflare.
+// Each match is replaced with a stable token; with Restore set, tokens the
+// model echoes back are mapped to the original values in the response.
+func PIIRedactionMiddleware(cfg *PIIRedactionConfig) ai.ModelMiddleware {
+	var detectors []PIIDetector
+	if cfg != nil {
+		detectors = cfg.Detectors
+	}
+	if len(detectors) == 0 {
+		detectors = []PIIDetector{EmailDetector(), PhoneDetector(), CreditCardDetector()}
+	}
+	restore := cfg != nil && cfg.Restore
+
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			redactor := newPIIRedactor(detectors)
+			redacted := *input
+			redacted.Messages = redactor.redactMessages(input.Messages)
+
+			resp, err := next(ctx, &redacted, cb)
+			if err != nil || resp == nil {
+				return resp, err
+			}
+			if restore && resp.Message != nil {
+				redactor.restoreMessage(resp.Message)
+			}
+			return resp, nil
+		}
+	}
+}
+
+// piiRedactor holds the per-request token mapping so redaction is reversible.
+type piiRedactor struct {
+	detectors []PIIDetector
+	tokens    map[string]string // token -> original value
+	values    map[string]string // original value -> token
+}
+
+func newPIIRedactor(detectors []PIIDetector) *piiRedactor {
+	return &piiRedactor{
+		detectors: detectors,
+		tokens:    map[string]string{},
+		values:    map[string]string{},
+	}
+}
+
+// redactMessages returns a copy of messages with sensitive values in user
+// message text replaced by tokens. Other roles pass through unchanged.
+func (r *piiRedactor) redactMessages(messages []*ai.Message) []*ai.Message {
+	out := make([]*ai.Message, len(messages))
+	for i, msg := range messages {
+		if msg.Role != ai.RoleUser {
+			out[i] = msg
+			continue
+		}
+		copied := *msg
+		copied.Content = make([]*ai.Part, len(msg.Content))
+		for j, part := range msg.Content {
+			if part.IsText() {
+				copied.Content[j] = ai.NewTextPart(r.redactText(part.Text))
+			} else {
+				copied.Content[j] = part
+			}
+		}
+		out[i] = &copied
+	}
+	return out
+}
+
+// redactText replaces every detector match with its token, reusing tokens for
+// repeated values so the model sees consistent references.
+func (r *piiRedactor) redactText(text string) string {
+	for _, d := range r.detectors {
+		text = d.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if token, ok := r.values[match]; ok {
+				return token
+			}
+			token := fmt.Sprintf("<pii:%s:%d>", d.Name, len(r.tokens)+1)
+			r.tokens[token] = match
+			r.values[match] = token
+			return token
+		})
+	}
+	return text
+}
+
+// restoreMessage substitutes original values back for any tokens in the
+// message's text parts.
+func (r *piiRedactor) restoreMessage(msg *ai.Message) {
+	if len(r.tokens) == 0 {
+		return
+	}
+	for i, part := range msg.Content {
+		if !part.IsText() {
+			continue
+		}
+		text := part.Text
+		for token, value := range r.tokens {
+			text = strings.ReplaceAll(text, token, value)
+		}
+		if text != part.Text {
+			msg.Content[i] = ai.NewTextPart(text)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPIIRedactionMiddleware(t *testing.T) {
+	var sent *ai.ModelRequest
+	echo := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		sent = input
+		// Echo the last user message back, as a model referencing the
+		// redacted value would.
+		return &ai.ModelResponse{
+			Message: &ai.Message{
+				Role:    ai.RoleModel,
+				Content: []*ai.Part{ai.NewTextPart("I emailed " + input.Messages[len(input.Messages)-1].Text())},
+			},
+		}, nil
+	}
+
+	t.Run("masks user text and restores response tokens", func(t *testing.T) {
+		mw := PIIRedactionMiddleware(&PIIRedactionConfig{Restore: true})
+		input := &ai.ModelRequest{Messages: []*ai.Message{
+			ai.NewUserTextMessage("contact alice@example.com please"),
+		}}
+
+		resp, err := mw(echo)(context.Background(), input, nil)
+		require.NoError(t, err)
+
+		assert.NotContains(t, sent.Messages[0].Text(), "alice@example.com")
+		assert.Contains(t, sent.Messages[0].Text(), "<pii:email:1>")
+		// The caller's request is untouched.
+		assert.Contains(t, input.Messages[0].Text(), "alice@example.com")
+		// The token the model echoed is mapped back.
+		assert.Contains(t, resp.Message.Text(), "alice@example.com")
+	})
+
+	t.Run("repeated values reuse the same token", func(t *testing.T) {
+		mw := PIIRedactionMiddleware(nil)
+		input := &ai.ModelRequest{Messages: []*ai.Message{
+			ai.NewUserTextMessage("alice@example.com and again alice@example.com"),
+		}}
+
+		_, err := mw(echo)(context.Background(), input, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "alice@example.com and again alice@example.com",
+			input.Messages[0].Text())
+		assert.Equal(t, "<pii:email:1> and again <pii:email:1>", sent.Messages[0].Text())
+	})
+
+	t.Run("custom detectors replace the defaults", func(t *testing.T) {
+		mw := PIIRedactionMiddleware(&PIIRedactionConfig{
+			Detectors: []PIIDetector{PhoneDetector()},
+		})
+		input := &ai.ModelRequest{Messages: []*ai.Message{
+			ai.NewUserTextMessage("email alice@example.com, phone +1 555 123 4567"),
+		}}
+
+		_, err := mw(echo)(context.Background(), input, nil)
+		require.NoError(t, err)
+		assert.Contains(t, sent.Messages[0].Text(), "alice@example.com")
+		assert.Contains(t, sent.Messages[0].Text(), "<pii:phone:1>")
+	})
+
+	t.Run("system messages pass through", func(t *testing.T) {
+		mw := PIIRedactionMiddleware(nil)
+		input := &ai.ModelRequest{Messages: []*ai.Message{
+			ai.NewSystemTextMessage("you may contact admin@example.com"),
+			ai.NewUserTextMessage("hello"),
+		}}
+
+		_, err := mw(echo)(context.Background(), input, nil)
+		require.NoError(t, err)
+		assert.Contains(t, sent.Messages[0].Text(), "admin@example.com")
+	})
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitInfo is the rate-limit state reported by the API in response
+// headers. It is surfaced in response metadata and through the plugin's
+// RateLimitObserver so schedulers can adapt concurrency dynamically.
+type RateLimitInfo struct {
+	// Limit is the total request budget of the current window, if reported.
+	Limit int `json:"limit,omitempty"`
+	// Remaining is how many requests are left in the current window.
+	Remaining int `json:"remaining"`
+	// Reset is when the current window ends, if reported.
+	Reset time.Time `json:"reset,omitempty"`
+	// RetryAfter is the server-requested wait before the next attempt,
+	// taken from the Retry-After header of a 429 response.
+	RetryAfter time.Duration `json:"retryAfter,omitempty"`
+}
+
+// parseRateLimitInfo extracts rate-limit state from response headers. It
+// returns nil when the response carries no rate-limit headers.
+func parseRateLimitInfo(h http.Header) *RateLimitInfo {
+	var info RateLimitInfo
+	found := false
+
+	if v := h.Get("X-RateLimit-Limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			info.Limit = n
+			found = true
+		}
+	}
+	if v := h.Get("X-RateLimit-Remaining"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			info.Remaining = n
+			found = true
+		}
+	}
+	if v := h.Get("X-RateLimit-Reset"); v != "" {
+		if epoch, err := strconv.ParseInt(v, 10, 64); err == nil {
+			info.Reset = time.Unix(epoch, 0)
+			found = true
+		}
+	}
+	if d := retryAfter(h); d > 0 {
+		info.RetryAfter = d
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return &info
+}
+
+// retryAfter parses the Retry-After header, which holds either a delay in
+// seconds or an HTTP date. It returns 0 when the header is absent or invalid.
+func retryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// rateLimitTransport records the rate-limit state of every response and
+// notifies the configured observer.
+type rateLimitTransport struct {
+	base     http.RoundTripper
+	state    *rateLimitState
+	observer func(RateLimitInfo)
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if info := parseRateLimitInfo(resp.Header); info != nil {
+		t.state.set(info)
+		if t.observer != nil {
+			t.observer(*info)
+		}
+	}
+	return resp, nil
+}
+
+// rateLimitState holds the most recently observed rate-limit info.
+type rateLimitState struct {
+	mu   sync.Mutex
+	info *RateLimitInfo
+	at   time.Time
+}
+
+func (s *rateLimitState) set(info *RateLimitInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = info
+	s.at = time.Now()
+}
+
+func (s *rateLimitState) get() *RateLimitInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.info
+}
+
+// getWithTime returns the most recent info along with when it was observed,
+// so consumers can discard stale signals.
+func (s *rateLimitState) getWithTime() (*RateLimitInfo, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.info, s.at
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"regexp"
+	"strings"
+)
+
+// thinkBlock matches the inline reasoning block reasoning models (DeepSeek
+// R1 distills, qwen3) emit before their answer.
+var thinkBlock = regexp.MustCompile(`(?s)<think>(.*?)</think>`)
+
+// extractReasoning splits model text into its reasoning and answer portions.
+// Reasoning arrives either in the response's dedicated reasoning_content
+// field or inline as a <think>...</think> block; both are normalized here so
+// callers never see raw think tags. An unterminated <think> block (output
+// truncated mid-thought) counts entirely as reasoning.
+func extractReasoning(text string) (reasoning, answer string) {
+	matches := thinkBlock.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		if i := strings.Index(text, "<think>"); i >= 0 {
+			return strings.TrimSpace(text[i+len("<think>"):]), strings.TrimSpace(text[:i])
+		}
+		return "", text
+	}
+	var thoughts []string
+	for _, m := range matches {
+		if t := strings.TrimSpace(m[1]); t != "" {
+			thoughts = append(thoughts, t)
+		}
+	}
+	return strings.Join(thoughts, "\n"), strings.TrimSpace(thinkBlock.ReplaceAllString(text, ""))
+}
@@ -0,0 +1,52 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractReasoning(t *testing.T) {
+	testCases := []struct {
+		name      string
+		text      string
+		reasoning string
+		answer    string
+	}{
+		{
+			name:   "no reasoning",
+			text:   "The answer is 4.",
+			answer: "The answer is 4.",
+		},
+		{
+			name:      "think block before the answer",
+			text:      "<think>2+2 is basic arithmetic.</think>The answer is 4.",
+			reasoning: "2+2 is basic arithmetic.",
+			answer:    "The answer is 4.",
+		},
+		{
+			name:      "multiple think blocks are joined",
+			text:      "<think>first</think>partial<think>second</think> answer",
+			reasoning: "first\nsecond",
+			answer:    "partial answer",
+		},
+		{
+			name:      "unterminated think block is all reasoning",
+			text:      "<think>this got cut off mid-",
+			reasoning: "this got cut off mid-",
+		},
+		{
+			name:   "empty think block",
+			text:   "<think></think>answer",
+			answer: "answer",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reasoning, answer := extractReasoning(tc.text)
+			assert.Equal(t, tc.reasoning, reasoning)
+			assert.Equal(t, tc.answer, answer)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/pkg/errors"
+)
+
+// Cloudflare adds Workers AI models frequently, and long-running servers
+// should not need a restart to pick them up. ReloadCatalog re-reads the live
+// model catalog from the API and registers what is new; ReloadOnSignal wires
+// that to a process signal (typically SIGHUP) for operational convenience.
+
+// CatalogEntry is one model from the live Workers AI catalog.
+type CatalogEntry struct {
+	// Name is the model identifier ("@cf/meta/llama-...").
+	Name string
+	// Task is the catalog task name, for example "Text Generation".
+	Task string
+	// Beta reports whether the catalog marks the model as beta.
+	Beta bool
+	// Properties are the catalog's per-model properties keyed by property
+	// ID, including pricing and deprecation markers where Cloudflare
+	// publishes them.
+	Properties map[string]any
+}
+
+// Deprecated reports whether the catalog marks the entry as deprecated.
+func (e *CatalogEntry) Deprecated() bool {
+	switch v := e.Properties["deprecated"].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// CatalogReloadStats summarizes one catalog reload.
+type CatalogReloadStats struct {
+	// Discovered is how many models the catalog returned.
+	Discovered int
+	// Added is how many previously unregistered text-generation models were
+	// defined.
+	Added []string
+	// Deprecated lists the catalog models marked deprecated, whether or not
+	// they are registered here, so operators can plan migrations.
+	Deprecated []string
+}
+
+// ReloadCatalog re-reads the live model catalog and defines every
+// text-generation model that is not registered yet, using the same
+// capability detection as dynamic resolution. Deprecated models are never
+// added; already registered ones are reported in the stats and warned about,
+// since flows may still reference them. The refreshed catalog is retained
+// and available through [WorkersAI.Catalog].
+func (w *WorkersAI) ReloadCatalog(ctx context.Context) (*CatalogReloadStats, error) {
+	w.mu.Lock()
+	if !w.initted || w.closed {
+		w.mu.Unlock()
+		return nil, errors.New("workersai: plugin not initialized")
+	}
+	w.mu.Unlock()
+
+	entries, err := w.fetchCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &CatalogReloadStats{Discovered: len(entries)}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.catalog = entries
+	for _, entry := range entries {
+		if entry.Deprecated() {
+			stats.Deprecated = append(stats.Deprecated, entry.Name)
+			slog.Warn("workersai: catalog marks model as deprecated", "model", entry.Name)
+			continue
+		}
+		if entry.Task != "Text Generation" {
+			continue
+		}
+		if genkit.LookupModel(w.genkit, provider, entry.Name) != nil {
+			continue
+		}
+		info := detectModelInfo(entry.Name)
+		if entry.Beta {
+			info.Stage = ai.ModelStageUnstable
+		}
+		w.defineModel(w.genkit, entry.Name, info)
+		stats.Added = append(stats.Added, entry.Name)
+	}
+	return stats, nil
+}
+
+// Catalog returns the entries from the most recent [WorkersAI.ReloadCatalog],
+// including per-model pricing and deprecation properties. Nil until the first
+// reload.
+func (w *WorkersAI) Catalog() []CatalogEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.catalog
+}
+
+// ReloadOnSignal reloads the catalog whenever one of the given signals
+// arrives (SIGHUP by convention), until the plugin is closed. Reload
+// failures are logged and the listener keeps running, so a transient API
+// error does not end hot reloading.
+func (w *WorkersAI) ReloadOnSignal(signals ...os.Signal) {
+	w.mu.Lock()
+	if w.reloadStop == nil {
+		w.reloadStop = make(chan struct{})
+	}
+	stop := w.reloadStop
+	w.mu.Unlock()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, signals...)
+	go func() {
+		defer signal.Stop(sigc)
+		for {
+			select {
+			case <-sigc:
+				if _, err := w.ReloadCatalog(context.Background()); err != nil {
+					slog.Warn("workersai: catalog reload failed", "error", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// catalogSearchEntry is the wire form of one catalog model; properties come
+// as a list of id/value pairs and are flattened into a map.
+type catalogSearchEntry struct {
+	Name string `json:"name"`
+	Task struct {
+		Name string `json:"name"`
+	} `json:"task"`
+	Beta       bool `json:"beta"`
+	Properties []struct {
+		ID    string `json:"property_id"`
+		Value any    `json:"value"`
+	} `json:"properties"`
+}
+
+// fetchCatalog pages through the models search endpoint. The vendored
+// client's ListModels is unusable, so the call is made directly against the
+// configured API base.
+func (w *WorkersAI) fetchCatalog(ctx context.Context) ([]CatalogEntry, error) {
+	const perPage = 100
+	var entries []CatalogEntry
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/accounts/%s/ai/models/search?per_page=%d&page=%d",
+			w.client.BaseURL, w.client.AccountID, perPage, page)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+w.client.APIToken)
+
+		resp, err := w.client.HTTPClient.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "catalog search failed")
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Errorf("catalog search returned status %d: %s", resp.StatusCode, body)
+		}
+
+		var envelope struct {
+			Success bool                 `json:"success"`
+			Errors  []any                `json:"errors"`
+			Result  []catalogSearchEntry `json:"result"`
+		}
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return nil, errors.Wrap(err, "failed to parse catalog search response")
+		}
+		if !envelope.Success {
+			return nil, errors.Errorf("catalog search failed: %v", envelope.Errors)
+		}
+
+		for _, raw := range envelope.Result {
+			entry := CatalogEntry{
+				Name:       raw.Name,
+				Task:       raw.Task.Name,
+				Beta:       raw.Beta,
+				Properties: map[string]any{},
+			}
+			for _, p := range raw.Properties {
+				entry.Properties[p.ID] = p.Value
+			}
+			entries = append(entries, entry)
+		}
+		if len(envelope.Result) < perPage {
+			return entries, nil
+		}
+	}
+}
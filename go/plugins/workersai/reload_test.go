@@ -0,0 +1,68 @@
+package workersai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadCatalog(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/accounts/acct/ai/models/search", r.URL.Path)
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"success":true,"errors":[],"result":[
+			{"name":"@cf/acme/brand-new-model","task":{"name":"Text Generation"},"beta":true,
+			 "properties":[{"property_id":"price","value":"0.10 per M input tokens"}]},
+			{"name":"@cf/meta/llama-2-7b-chat-int8","task":{"name":"Text Generation"},
+			 "properties":[{"property_id":"deprecated","value":"true"}]},
+			{"name":"@cf/baai/bge-small-en-v1.5","task":{"name":"Text Embeddings"},"properties":[]}
+		]}`))
+	}))
+	defer srv.Close()
+
+	g, err := genkit.Init(context.Background())
+	require.NoError(t, err)
+
+	w := &WorkersAI{initted: true}
+	w.client = client.NewClient("acct", "tok")
+	w.client.BaseURL = srv.URL
+	w.genkit = g
+
+	stats, err := w.ReloadCatalog(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, stats.Discovered)
+	assert.Equal(t, []string{"@cf/acme/brand-new-model"}, stats.Added)
+	assert.Equal(t, []string{"@cf/meta/llama-2-7b-chat-int8"}, stats.Deprecated)
+
+	// The discovered model is now registered.
+	assert.NotNil(t, genkit.LookupModel(g, provider, "@cf/acme/brand-new-model"))
+	// The embedding and deprecated models were not.
+	assert.Nil(t, genkit.LookupModel(g, provider, "@cf/baai/bge-small-en-v1.5"))
+	assert.Nil(t, genkit.LookupModel(g, provider, "@cf/meta/llama-2-7b-chat-int8"))
+
+	// Pricing properties are available through the retained catalog.
+	catalog := w.Catalog()
+	require.Len(t, catalog, 3)
+	assert.Equal(t, "0.10 per M input tokens", catalog[0].Properties["price"])
+
+	// A second reload registers nothing new.
+	stats, err = w.ReloadCatalog(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, stats.Added)
+	assert.Equal(t, 2, requests)
+}
+
+func TestReloadCatalogRequiresInit(t *testing.T) {
+	w := &WorkersAI{}
+	_, err := w.ReloadCatalog(context.Background())
+	require.ErrorContains(t, err, "not initialized")
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// RerankerBgeBase is the default Workers AI cross-encoder reranking model.
+const RerankerBgeBase = "@cf/baai/bge-reranker-base"
+
+// Rerank scores each text for relevance to query using a cross-encoder
+// reranking model and returns one score per input text, in input order. An
+// empty model applies [RerankerBgeBase]. The vendored client has no reranker
+// API, so requests go through the shared HTTP client directly.
+func (w *WorkersAI) Rerank(ctx context.Context, model, query string, texts []string) ([]float64, error) {
+	if model == "" {
+		model = RerankerBgeBase
+	}
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	contexts := make([]map[string]string, len(texts))
+	for i, t := range texts {
+		contexts[i] = map[string]string{"text": t}
+	}
+	c := w.client
+	body, err := json.Marshal(map[string]any{
+		"query":    query,
+		"contexts": contexts,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal rerank request")
+	}
+
+	url := fmt.Sprintf("%s/accounts/%s/ai/run/%s", c.BaseURL, c.AccountID, model)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create rerank request")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "rerank request failed")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read rerank response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rerank request returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var parsed struct {
+		Success bool     `json:"success"`
+		Errors  []string `json:"errors"`
+		Result  struct {
+			Response []struct {
+				ID    int     `json:"id"`
+				Score float64 `json:"score"`
+			} `json:"response"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, errors.Wrap(err, "failed to parse rerank response")
+	}
+	if !parsed.Success {
+		return nil, fmt.Errorf("rerank request returned an error: %v", parsed.Errors)
+	}
+
+	scores := make([]float64, len(texts))
+	for _, r := range parsed.Result.Response {
+		if r.ID < 0 || r.ID >= len(scores) {
+			return nil, fmt.Errorf("rerank response references unknown context %d", r.ID)
+		}
+		scores[r.ID] = r.Score
+	}
+	return scores, nil
+}
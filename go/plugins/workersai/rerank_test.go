@@ -0,0 +1,82 @@
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRerank(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/accounts/acct/ai/run/"+RerankerBgeBase, r.URL.Path)
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		var body struct {
+			Query    string              `json:"query"`
+			Contexts []map[string]string `json:"contexts"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "which doc", body.Query)
+		require.Len(t, body.Contexts, 3)
+		assert.Equal(t, "first", body.Contexts[0]["text"])
+
+		// The API may return scores in any order; IDs map them back.
+		rw.Write([]byte(`{"success":true,"errors":[],"result":{"response":[
+			{"id":2,"score":0.9},
+			{"id":0,"score":0.1},
+			{"id":1,"score":0.5}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	w := &WorkersAI{initted: true}
+	w.client = client.NewClient("acct", "tok")
+	w.client.BaseURL = srv.URL
+
+	scores, err := w.Rerank(context.Background(), "", "which doc", []string{"first", "second", "third"})
+	require.NoError(t, err)
+	// Scores come back in input order regardless of response order.
+	assert.Equal(t, []float64{0.1, 0.5, 0.9}, scores)
+}
+
+func TestRerankEmptyInput(t *testing.T) {
+	w := &WorkersAI{initted: true}
+	scores, err := w.Rerank(context.Background(), "", "query", nil)
+	require.NoError(t, err)
+	assert.Nil(t, scores)
+}
+
+func TestRerankRejectsUnknownContextIDs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"success":true,"errors":[],"result":{"response":[{"id":5,"score":0.9}]}}`))
+	}))
+	defer srv.Close()
+
+	w := &WorkersAI{initted: true}
+	w.client = client.NewClient("acct", "tok")
+	w.client.BaseURL = srv.URL
+
+	_, err := w.Rerank(context.Background(), "", "query", []string{"only"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown context 5")
+}
+
+func TestRerankAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"success":false,"errors":["no such model"]}`))
+	}))
+	defer srv.Close()
+
+	w := &WorkersAI{initted: true}
+	w.client = client.NewClient("acct", "tok")
+	w.client.BaseURL = srv.URL
+
+	_, err := w.Rerank(context.Background(), "", "query", []string{"only"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such model")
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// idempotencyKeyHeader carries the per-request idempotency key. The retry
+// layer reuses the same key across attempts so a retried POST through the AI
+// Gateway is not double-logged or double-billed when the first attempt
+// actually succeeded.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// RetryConfig controls automatic retries of failed requests.
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 1 are treated as 1 (no retries).
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry; it doubles for
+	// each subsequent retry. Defaults to 500ms. Ignored when Backoff is
+	// set.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries. Defaults to 10s.
+	MaxBackoff time.Duration
+	// Backoff replaces the default exponential schedule with a custom
+	// strategy, such as [ExponentialJitterBackoff] or
+	// [DecorrelatedJitterBackoff]. A server-sent Retry-After still takes
+	// precedence over the computed wait.
+	Backoff BackoffFunc
+	// ShouldRetry, when set, decides whether a transport-level error (as
+	// opposed to an error status) is worth retrying. By default all
+	// transport errors are retried; gateway deployments that surface
+	// permanent TLS or DNS failures this way can bail out early here.
+	ShouldRetry func(error) bool
+}
+
+// BackoffFunc computes the wait before retry attempt n (1-based). prev is
+// the wait used before the previous retry, or zero before the first, which
+// lets strategies like decorrelated jitter derive the next wait without
+// carrying state across calls.
+type BackoffFunc func(attempt int, prev time.Duration) time.Duration
+
+// ExponentialBackoff doubles the wait on each retry, starting at initial and
+// capped at max. Non-positive arguments fall back to the RetryConfig
+// defaults (500ms and 10s). This is the default strategy.
+func ExponentialBackoff(initial, max time.Duration) BackoffFunc {
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	return func(attempt int, prev time.Duration) time.Duration {
+		wait := initial << (attempt - 1)
+		if wait <= 0 { // shifted past the representable range
+			return max
+		}
+		return min(wait, max)
+	}
+}
+
+// ExponentialJitterBackoff draws a random wait between zero and the
+// exponential schedule ("full jitter"), which spreads out retry storms from
+// many clients failing at once.
+func ExponentialJitterBackoff(initial, max time.Duration) BackoffFunc {
+	exp := ExponentialBackoff(initial, max)
+	return func(attempt int, prev time.Duration) time.Duration {
+		return time.Duration(rand.Int64N(int64(exp(attempt, prev)) + 1))
+	}
+}
+
+// DecorrelatedJitterBackoff draws each wait uniformly from [base, prev*3),
+// capped at max, so consecutive waits are decorrelated from each other as
+// well as from other clients.
+func DecorrelatedJitterBackoff(base, max time.Duration) BackoffFunc {
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 10 * time.Second
+	}
+	return func(attempt int, prev time.Duration) time.Duration {
+		if prev < base {
+			prev = base
+		}
+		wait := base + time.Duration(rand.Int64N(int64(prev*3-base)+1))
+		return min(wait, max)
+	}
+}
+
+// FixedBackoff waits the same duration before every retry.
+func FixedBackoff(d time.Duration) BackoffFunc {
+	return func(attempt int, prev time.Duration) time.Duration {
+		return d
+	}
+}
+
+// retryTransport is an http.RoundTripper that retries transient failures
+// (connection errors, 408/429/5xx responses) with exponential backoff. Every
+// logical request is tagged with an idempotency key that is shared across all
+// of its attempts; the key is echoed into the response headers so callers can
+// correlate attempts in gateway logs.
+type retryTransport struct {
+	base http.RoundTripper
+	cfg  RetryConfig
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	key := req.Header.Get(idempotencyKeyHeader)
+	if key == "" {
+		key = uuid.NewString()
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	attempts := t.cfg.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	maxBackoff := t.cfg.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+	backoffFn := t.cfg.Backoff
+	if backoffFn == nil {
+		backoffFn = ExponentialBackoff(t.cfg.InitialBackoff, t.cfg.MaxBackoff)
+	}
+
+	var resp *http.Response
+	var err error
+	var prev, serverWait time.Duration
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			// Replaying the body requires GetBody, which the stdlib
+			// populates for the buffered bodies the client uses.
+			if req.Body != nil && req.GetBody == nil {
+				break
+			}
+			if req.GetBody != nil {
+				body, bodyErr := req.GetBody()
+				if bodyErr != nil {
+					break
+				}
+				req.Body = body
+			}
+
+			// A server-requested wait takes precedence over the
+			// configured strategy, capped at the maximum backoff.
+			wait := backoffFn(attempt, prev)
+			if serverWait > 0 {
+				wait = serverWait
+				serverWait = 0
+			}
+			select {
+			case <-time.After(wait):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+			prev = wait
+		}
+
+		resp, err = base.RoundTrip(req)
+		if err != nil {
+			resp = nil
+			if t.cfg.ShouldRetry != nil && !t.cfg.ShouldRetry(err) {
+				break
+			}
+			continue
+		}
+		if !retryableStatus(resp.StatusCode) {
+			break
+		}
+		if attempt < attempts-1 {
+			if ra := retryAfter(resp.Header); ra > 0 {
+				serverWait = min(ra, maxBackoff)
+			}
+			// Drain so the connection can be reused for the retry.
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	if resp != nil {
+		resp.Header.Set(idempotencyKeyHeader, key)
+	}
+	return resp, err
+}
+
+// retryableStatus reports whether a response status indicates a transient
+// failure worth retrying.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return status >= 500
+}
@@ -0,0 +1,140 @@
+package workersai
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceRoundTripper returns one canned status per request, in order.
+type sequenceRoundTripper struct {
+	statuses []int
+	requests []*http.Request
+}
+
+func (s *sequenceRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	status := s.statuses[len(s.requests)%len(s.statuses)]
+	s.requests = append(s.requests, req)
+	return (&fakeRoundTripper{status: status}).RoundTrip(req)
+}
+
+func TestRetryTransportReusesIdempotencyKey(t *testing.T) {
+	base := &sequenceRoundTripper{statuses: []int{http.StatusServiceUnavailable, http.StatusOK}}
+	tr := &retryTransport{
+		base: base,
+		cfg:  RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	}
+
+	req, err := http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Len(t, base.requests, 2, "expected one retry after the 503")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	key := base.requests[0].Header.Get(idempotencyKeyHeader)
+	require.NotEmpty(t, key)
+	assert.Equal(t, key, base.requests[1].Header.Get(idempotencyKeyHeader), "retries must reuse the idempotency key")
+	assert.Equal(t, key, resp.Header.Get(idempotencyKeyHeader), "key should be echoed into the response")
+}
+
+func TestRetryTransportStopsOnSuccess(t *testing.T) {
+	base := &sequenceRoundTripper{statuses: []int{http.StatusOK}}
+	tr := &retryTransport{base: base, cfg: RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond}}
+
+	req, err := http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Len(t, base.requests, 1)
+}
+
+func TestRetryTransportGivesUpAfterMaxAttempts(t *testing.T) {
+	base := &sequenceRoundTripper{statuses: []int{http.StatusInternalServerError}}
+	tr := &retryTransport{base: base, cfg: RetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond}}
+
+	req, err := http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Len(t, base.requests, 2)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
+
+// errRoundTripper fails every request with the same transport error.
+type errRoundTripper struct {
+	err      error
+	requests int
+}
+
+func (e *errRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	e.requests++
+	return nil, e.err
+}
+
+func TestRetryTransportShouldRetryPredicate(t *testing.T) {
+	base := &errRoundTripper{err: errors.New("certificate expired")}
+	tr := &retryTransport{
+		base: base,
+		cfg: RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			ShouldRetry:    func(error) bool { return false },
+		},
+	}
+
+	req, err := http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+
+	_, err = tr.RoundTrip(req)
+	require.Error(t, err)
+	assert.Equal(t, 1, base.requests, "a rejected error must not be retried")
+}
+
+func TestBackoffStrategies(t *testing.T) {
+	t.Run("exponential doubles and caps", func(t *testing.T) {
+		b := ExponentialBackoff(time.Second, 5*time.Second)
+		assert.Equal(t, time.Second, b(1, 0))
+		assert.Equal(t, 2*time.Second, b(2, time.Second))
+		assert.Equal(t, 5*time.Second, b(4, 4*time.Second))
+	})
+
+	t.Run("full jitter stays within the exponential envelope", func(t *testing.T) {
+		b := ExponentialJitterBackoff(time.Second, 10*time.Second)
+		for range 50 {
+			wait := b(2, time.Second)
+			assert.GreaterOrEqual(t, wait, time.Duration(0))
+			assert.LessOrEqual(t, wait, 2*time.Second)
+		}
+	})
+
+	t.Run("decorrelated jitter stays within bounds", func(t *testing.T) {
+		b := DecorrelatedJitterBackoff(time.Second, 10*time.Second)
+		prev := time.Duration(0)
+		for range 50 {
+			wait := b(1, prev)
+			assert.GreaterOrEqual(t, wait, time.Second)
+			assert.LessOrEqual(t, wait, 10*time.Second)
+			prev = wait
+		}
+	})
+
+	t.Run("fixed is constant", func(t *testing.T) {
+		b := FixedBackoff(250 * time.Millisecond)
+		assert.Equal(t, 250*time.Millisecond, b(1, 0))
+		assert.Equal(t, 250*time.Millisecond, b(7, time.Hour))
+	})
+}
@@ -0,0 +1,224 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/firebase/genkit/go/ai"
+)
+
+// llamaGuardModel is the Workers AI moderation model used for the optional
+// model-based safety check.
+const llamaGuardModel = "@cf/meta/llama-guard-3-8b"
+
+// SafetyAction is what the content-safety middleware does with a response
+// that violates the policy.
+type SafetyAction string
+
+const (
+	// SafetyActionBlock replaces the response with an empty one finishing
+	// with [ai.FinishReasonBlocked].
+	SafetyActionBlock SafetyAction = "block"
+	// SafetyActionRedact masks the matched content in place.
+	SafetyActionRedact SafetyAction = "redact"
+	// SafetyActionAnnotate passes the response through unchanged but records
+	// the matches in the response's custom metadata.
+	SafetyActionAnnotate SafetyAction = "annotate"
+)
+
+// ContentSafetyConfig configures [WorkersAI.ContentSafetyMiddleware].
+type ContentSafetyConfig struct {
+	// Keywords are matched case-insensitively against response text.
+	Keywords []string
+	// Patterns are regular expressions matched against response text.
+	Patterns []string
+	// LlamaGuard additionally sends the response text to the Llama Guard
+	// moderation model and treats an "unsafe" verdict as a violation.
+	LlamaGuard bool
+	// Action is what to do on a violation. The default is
+	// [SafetyActionBlock].
+	Action SafetyAction
+	// Redaction replaces matched content when Action is
+	// [SafetyActionRedact]. The default is "[REDACTED]".
+	Redaction string
+}
+
+// ContentSafetyMiddleware returns a model middleware that checks generated
+// responses against keyword lists, regular expressions and optionally the
+// Llama Guard moderation model, applying the configured action before the
+// response reaches the caller. Attach it with ai.WithMiddleware so output
+// policies are enforced centrally rather than per flow.
+func (w *WorkersAI) ContentSafetyMiddleware(cfg *ContentSafetyConfig) (ai.ModelMiddleware, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("workersai: content safety middleware requires a config")
+	}
+	action := cfg.Action
+	if action == "" {
+		action = SafetyActionBlock
+	}
+	redaction := cfg.Redaction
+	if redaction == "" {
+		redaction = "[REDACTED]"
+	}
+
+	filter, err := newSafetyFilter(cfg.Keywords, cfg.Patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			resp, err := next(ctx, input, cb)
+			if err != nil || resp == nil || resp.Message == nil {
+				return resp, err
+			}
+
+			matches := filter.findMatches(resp.Message)
+			if cfg.LlamaGuard {
+				unsafe, err := w.llamaGuardCheck(ctx, resp.Message)
+				if err != nil {
+					return nil, fmt.Errorf("workersai: llama guard check failed: %w", err)
+				}
+				if unsafe {
+					matches = append(matches, "llama-guard: unsafe")
+				}
+			}
+			if len(matches) == 0 {
+				return resp, nil
+			}
+
+			switch action {
+			case SafetyActionBlock:
+				resp.Message = &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{}}
+				resp.FinishReason = ai.FinishReasonBlocked
+				resp.FinishMessage = fmt.Sprintf("response blocked by content safety policy: %s", strings.Join(matches, ", "))
+			case SafetyActionRedact:
+				filter.redact(resp.Message, redaction)
+			}
+			annotateResponse(resp, "contentSafety", map[string]any{
+				"action":  string(action),
+				"matches": matches,
+			})
+			return resp, nil
+		}
+	}, nil
+}
+
+// safetyFilter holds the compiled policy of a content-safety middleware.
+type safetyFilter struct {
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+func newSafetyFilter(keywords, patterns []string) (*safetyFilter, error) {
+	f := &safetyFilter{}
+	for _, k := range keywords {
+		f.keywords = append(f.keywords, strings.ToLower(k))
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("workersai: invalid content safety pattern %q: %w", p, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// findMatches returns a description of every policy violation in the
+// message's text parts.
+func (f *safetyFilter) findMatches(msg *ai.Message) []string {
+	var matches []string
+	for _, part := range msg.Content {
+		if !part.IsText() {
+			continue
+		}
+		lower := strings.ToLower(part.Text)
+		for _, k := range f.keywords {
+			if strings.Contains(lower, k) {
+				matches = append(matches, fmt.Sprintf("keyword %q", k))
+			}
+		}
+		for _, re := range f.patterns {
+			if re.MatchString(part.Text) {
+				matches = append(matches, fmt.Sprintf("pattern %q", re.String()))
+			}
+		}
+	}
+	return matches
+}
+
+// redact masks all policy matches in the message's text parts in place.
+func (f *safetyFilter) redact(msg *ai.Message, redaction string) {
+	for i, part := range msg.Content {
+		if !part.IsText() {
+			continue
+		}
+		text := part.Text
+		for _, k := range f.keywords {
+			re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(k))
+			text = re.ReplaceAllString(text, redaction)
+		}
+		for _, re := range f.patterns {
+			text = re.ReplaceAllString(text, redaction)
+		}
+		if text != part.Text {
+			msg.Content[i] = ai.NewTextPart(text)
+		}
+	}
+}
+
+// llamaGuardCheck asks the Llama Guard model to classify the message text,
+// returning true when the verdict is unsafe.
+func (w *WorkersAI) llamaGuardCheck(ctx context.Context, msg *ai.Message) (bool, error) {
+	var text strings.Builder
+	for _, part := range msg.Content {
+		if part.IsText() {
+			text.WriteString(part.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return false, nil
+	}
+
+	resp, err := w.client.ChatWithTools(llamaGuardModel, []client.Message{
+		client.ChatMessage{Role: "user", Content: text.String()},
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+	if !resp.Success {
+		return false, fmt.Errorf("workersai API returned an error: %v", resp.Errors)
+	}
+	verdict := strings.TrimSpace(strings.ToLower(resp.GetContent()))
+	return strings.HasPrefix(verdict, "unsafe"), nil
+}
+
+// annotateResponse records middleware findings under the given key in the
+// response's custom metadata, preserving existing entries.
+func annotateResponse(resp *ai.ModelResponse, key string, value any) {
+	custom, ok := resp.Custom.(map[string]any)
+	if !ok || custom == nil {
+		custom = map[string]any{}
+	}
+	custom[key] = value
+	resp.Custom = custom
+}
@@ -0,0 +1,101 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentSafetyMiddleware(t *testing.T) {
+	w := &WorkersAI{}
+	next := func(text string) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return &ai.ModelResponse{
+				Message:      &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{ai.NewTextPart(text)}},
+				FinishReason: ai.FinishReasonStop,
+			}, nil
+		}
+	}
+
+	testCases := []struct {
+		name         string
+		cfg          *ContentSafetyConfig
+		text         string
+		wantText     string
+		wantFinish   ai.FinishReason
+		wantCustom   bool
+		wantInitErr  bool
+		initErrMatch string
+	}{
+		{
+			name:       "clean response passes through",
+			cfg:        &ContentSafetyConfig{Keywords: []string{"forbidden"}},
+			text:       "all good here",
+			wantText:   "all good here",
+			wantFinish: ai.FinishReasonStop,
+		},
+		{
+			name:       "keyword match blocks by default",
+			cfg:        &ContentSafetyConfig{Keywords: []string{"forbidden"}},
+			text:       "this is FORBIDDEN content",
+			wantText:   "",
+			wantFinish: ai.FinishReasonBlocked,
+			wantCustom: true,
+		},
+		{
+			name: "redact masks matched content",
+			cfg: &ContentSafetyConfig{
+				Patterns: []string{`\d{3}-\d{4}`},
+				Action:   SafetyActionRedact,
+			},
+			text:       "call 555-1234 now",
+			wantText:   "call [REDACTED] now",
+			wantFinish: ai.FinishReasonStop,
+			wantCustom: true,
+		},
+		{
+			name: "annotate leaves content untouched",
+			cfg: &ContentSafetyConfig{
+				Keywords: []string{"forbidden"},
+				Action:   SafetyActionAnnotate,
+			},
+			text:       "forbidden but annotated",
+			wantText:   "forbidden but annotated",
+			wantFinish: ai.FinishReasonStop,
+			wantCustom: true,
+		},
+		{
+			name:         "invalid pattern fails at construction",
+			cfg:          &ContentSafetyConfig{Patterns: []string{"("}},
+			wantInitErr:  true,
+			initErrMatch: "invalid content safety pattern",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mw, err := w.ContentSafetyMiddleware(tc.cfg)
+			if tc.wantInitErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.initErrMatch)
+				return
+			}
+			require.NoError(t, err)
+
+			resp, err := mw(next(tc.text))(context.Background(), &ai.ModelRequest{}, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantFinish, resp.FinishReason)
+			assert.Equal(t, tc.wantText, resp.Message.Text())
+			if tc.wantCustom {
+				custom, ok := resp.Custom.(map[string]any)
+				require.True(t, ok)
+				assert.Contains(t, custom, "contentSafety")
+			} else {
+				assert.Nil(t, resp.Custom)
+			}
+		})
+	}
+}
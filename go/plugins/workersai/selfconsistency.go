@@ -0,0 +1,168 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// SelfConsistencyOptions configures [SelfConsistent].
+type SelfConsistencyOptions struct {
+	// Model generates the samples. Required.
+	Model ai.Model
+
+	// Embedder clusters the sampled answers by meaning, typically the bge
+	// embedder. Required.
+	Embedder ai.Embedder
+
+	// Samples is how many answers to draw. The default is 5.
+	Samples int
+
+	// Config is the model config applied to every sample. Nil uses a
+	// [Config] with temperature 0.7 — sampling at temperature zero would
+	// draw the same answer N times.
+	Config any
+
+	// SimilarityThreshold is the cosine similarity at or above which two
+	// answers count as agreeing. The default is 0.9, looser than exact
+	// match so wording differences do not split a cluster.
+	SimilarityThreshold float64
+}
+
+// SelfConsistencyResult is the outcome of one self-consistency run.
+type SelfConsistencyResult struct {
+	// Answer is the most central answer of the largest cluster — the
+	// sampled answer that agrees most with its cluster, not a synthesis.
+	Answer string
+	// Confidence is the winning cluster's share of the samples, 0..1.
+	// Unanimous agreement yields 1; a result near 1/Samples means the
+	// answers scattered and Answer is close to arbitrary.
+	Confidence float64
+	// Samples holds every sampled answer, in sample order.
+	Samples []string
+}
+
+// SelfConsistent draws N answers to the same request in parallel at non-zero
+// temperature, clusters them by embedding similarity, and returns the most
+// central answer of the largest cluster with the cluster's share as a
+// confidence score. Majority voting over samples is a practical accuracy
+// boost on smaller Workers AI models, where a single greedy answer is often
+// a coin flip — at N times the inference cost.
+func SelfConsistent(ctx context.Context, messages []*ai.Message, opts *SelfConsistencyOptions) (*SelfConsistencyResult, error) {
+	if opts == nil || opts.Model == nil {
+		return nil, errors.New("workersai: SelfConsistent requires a model")
+	}
+	if opts.Embedder == nil {
+		return nil, errors.New("workersai: SelfConsistent requires an embedder")
+	}
+	cfg := *opts
+	if cfg.Samples <= 0 {
+		cfg.Samples = 5
+	}
+	if cfg.SimilarityThreshold == 0 {
+		cfg.SimilarityThreshold = 0.9
+	}
+	if cfg.Config == nil {
+		cfg.Config = &Config{Temperature: 0.7}
+	}
+
+	samples := make([]string, cfg.Samples)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for i := 0; i < cfg.Samples; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := cfg.Model.Generate(ctx, &ai.ModelRequest{
+				Messages: messages,
+				Config:   cfg.Config,
+			}, nil)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errors.Wrapf(err, "sample %d failed", i)
+					cancel()
+				}
+				return
+			}
+			samples[i] = resp.Message.Text()
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	docs := make([]*ai.Document, len(samples))
+	for i, s := range samples {
+		docs[i] = ai.DocumentFromText(s, nil)
+	}
+	eres, err := cfg.Embedder.Embed(ctx, &ai.EmbedRequest{Input: docs})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to embed samples for clustering")
+	}
+	if len(eres.Embeddings) != len(samples) {
+		return nil, errors.Errorf("embedder returned %d embeddings for %d samples", len(eres.Embeddings), len(samples))
+	}
+	vecs := make([][]float32, len(samples))
+	for i, e := range eres.Embeddings {
+		vecs[i] = e.Embedding
+	}
+
+	winner, size := centralAnswer(vecs, cfg.SimilarityThreshold)
+	return &SelfConsistencyResult{
+		Answer:     samples[winner],
+		Confidence: float64(size) / float64(len(samples)),
+		Samples:    samples,
+	}, nil
+}
+
+// centralAnswer treats each sample's set of agreeing samples (cosine
+// similarity at or above the threshold, including itself) as its cluster and
+// returns the index of the sample with the largest one, breaking ties by
+// total similarity to the agreeing samples — the most central answer of the
+// biggest cluster — along with that cluster's size.
+func centralAnswer(vecs [][]float32, threshold float64) (winner, size int) {
+	bestScore := -1.0
+	for i := range vecs {
+		agreeing, score := 0, 0.0
+		for j := range vecs {
+			if i == j {
+				agreeing++
+				continue
+			}
+			if sim := cosineSimilarity(vecs[i], vecs[j]); sim >= threshold {
+				agreeing++
+				score += sim
+			}
+		}
+		if agreeing > size || (agreeing == size && score > bestScore) {
+			winner, size, bestScore = i, agreeing, score
+		}
+	}
+	return winner, size
+}
@@ -0,0 +1,75 @@
+package workersai
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleModel hands out one answer per call, in order, regardless of which
+// goroutine gets there first.
+type sampleModel struct {
+	mu      sync.Mutex
+	answers []string
+	next    int
+}
+
+func (m *sampleModel) Name() string { return "sample" }
+
+func (m *sampleModel) Generate(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	m.mu.Lock()
+	answer := m.answers[m.next%len(m.answers)]
+	m.next++
+	m.mu.Unlock()
+	return &ai.ModelResponse{Message: ai.NewModelTextMessage(answer)}, nil
+}
+
+func TestSelfConsistent(t *testing.T) {
+	model := &sampleModel{answers: []string{"42:a", "42:b", "17:x", "42:c", "17:y"}}
+	// Answers sharing a prefix before ":" embed identically, so "42" forms
+	// a three-sample cluster and "17" a two-sample one.
+	embedder := axisEmbedder{axes: map[string]int{"42": 0, "17": 1}}
+
+	result, err := SelfConsistent(context.Background(),
+		[]*ai.Message{ai.NewUserTextMessage("What is the answer?")},
+		&SelfConsistencyOptions{Model: model, Embedder: embedder})
+	require.NoError(t, err)
+
+	assert.True(t, len(result.Answer) > 2 && result.Answer[:2] == "42", "answer %q not from the majority cluster", result.Answer)
+	assert.InDelta(t, 0.6, result.Confidence, 1e-9)
+	assert.Len(t, result.Samples, 5)
+}
+
+func TestSelfConsistentUnanimous(t *testing.T) {
+	model := &sampleModel{answers: []string{"same:1", "same:2", "same:3"}}
+	embedder := axisEmbedder{axes: map[string]int{"same": 0}}
+
+	result, err := SelfConsistent(context.Background(),
+		[]*ai.Message{ai.NewUserTextMessage("q")},
+		&SelfConsistencyOptions{Model: model, Embedder: embedder, Samples: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, result.Confidence)
+}
+
+func TestSelfConsistentRequiresModelAndEmbedder(t *testing.T) {
+	_, err := SelfConsistent(context.Background(), nil, nil)
+	require.Error(t, err)
+	_, err = SelfConsistent(context.Background(), nil, &SelfConsistencyOptions{Model: &sampleModel{answers: []string{"x"}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "embedder")
+}
+
+func TestCentralAnswer(t *testing.T) {
+	// Three agreeing vectors and one outlier: the winner comes from the
+	// cluster of three.
+	vecs := [][]float32{
+		{1, 0}, {0.99, 0.01}, {0, 1}, {0.98, 0.02},
+	}
+	winner, size := centralAnswer(vecs, 0.9)
+	assert.Equal(t, 3, size)
+	assert.NotEqual(t, 2, winner)
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// SessionMemory is what the summarizing memory keeps per session: a rolling
+// summary of everything older, plus the most recent turns verbatim. It is
+// JSON-serializable for session stores that persist it.
+type SessionMemory struct {
+	// Summary condenses the turns that no longer fit the recent window.
+	Summary string `json:"summary,omitempty"`
+	// Recent are the latest turns, kept word for word.
+	Recent []*ai.Message `json:"recent,omitempty"`
+}
+
+// SessionStore persists session memory between requests. Implementations
+// must be safe for concurrent use. Load returns nil for an unknown session.
+type SessionStore interface {
+	Load(ctx context.Context, sessionID string) (*SessionMemory, error)
+	Save(ctx context.Context, sessionID string, mem *SessionMemory) error
+}
+
+// SummarizingMemoryConfig configures [NewSummarizingMemory].
+type SummarizingMemoryConfig struct {
+	// Store persists per-session state. Required.
+	Store SessionStore
+	// Summarizer condenses turns that leave the recent window — typically
+	// a small, cheap Workers AI model. Required.
+	Summarizer ai.Model
+	// KeepRecentMessages is how many turns stay verbatim before older ones
+	// are folded into the summary. Defaults to 8.
+	KeepRecentMessages int
+}
+
+// SummarizingMemory keeps long-running chats within context limits without
+// forgetting. Unlike plain truncation, turns that fall out of the recent
+// window are folded into a rolling summary by a cheap model, so salient facts
+// survive across arbitrarily long sessions while the prompt stays bounded.
+type SummarizingMemory struct {
+	cfg SummarizingMemoryConfig
+}
+
+// NewSummarizingMemory creates the memory component.
+func NewSummarizingMemory(cfg SummarizingMemoryConfig) (*SummarizingMemory, error) {
+	if cfg.Store == nil || cfg.Summarizer == nil {
+		return nil, errors.New("workersai: summarizing memory requires a Store and a Summarizer")
+	}
+	if cfg.KeepRecentMessages <= 0 {
+		cfg.KeepRecentMessages = 8
+	}
+	return &SummarizingMemory{cfg: cfg}, nil
+}
+
+// Append records new turns for the session, folding overflow into the
+// summary. Summarization failures are non-fatal: the turns stay verbatim in
+// the store and folding is retried on the next append.
+func (m *SummarizingMemory) Append(ctx context.Context, sessionID string, msgs ...*ai.Message) error {
+	mem, err := m.load(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	mem.Recent = append(mem.Recent, msgs...)
+
+	if overflow := len(mem.Recent) - m.cfg.KeepRecentMessages; overflow > 0 {
+		old := mem.Recent[:overflow]
+		if summary, err := m.summarize(ctx, mem.Summary, old); err == nil {
+			mem.Summary = summary
+			mem.Recent = append([]*ai.Message(nil), mem.Recent[overflow:]...)
+		}
+	}
+	return m.cfg.Store.Save(ctx, sessionID, mem)
+}
+
+// Messages returns the session's history for prompting: the rolling summary
+// as a leading context message, then the recent turns verbatim.
+func (m *SummarizingMemory) Messages(ctx context.Context, sessionID string) ([]*ai.Message, error) {
+	mem, err := m.load(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	var out []*ai.Message
+	if mem.Summary != "" {
+		out = append(out, ai.NewUserTextMessage("Summary of the conversation so far: "+mem.Summary))
+	}
+	return append(out, mem.Recent...), nil
+}
+
+// Clear forgets the session.
+func (m *SummarizingMemory) Clear(ctx context.Context, sessionID string) error {
+	return m.cfg.Store.Save(ctx, sessionID, &SessionMemory{})
+}
+
+func (m *SummarizingMemory) load(ctx context.Context, sessionID string) (*SessionMemory, error) {
+	mem, err := m.cfg.Store.Load(ctx, sessionID)
+	if err != nil {
+		return nil, errors.Wrap(err, "workersai: failed to load session memory")
+	}
+	if mem == nil {
+		mem = &SessionMemory{}
+	}
+	return mem, nil
+}
+
+// summarize folds old turns into the existing summary with the summarizer
+// model, reusing the compression prompt so both paths condense the same way.
+func (m *SummarizingMemory) summarize(ctx context.Context, previous string, old []*ai.Message) (string, error) {
+	var transcript strings.Builder
+	if previous != "" {
+		transcript.WriteString("Summary of the conversation so far: ")
+		transcript.WriteString(previous)
+		transcript.WriteString("\n")
+	}
+	for _, msg := range old {
+		transcript.WriteString(string(msg.Role))
+		transcript.WriteString(": ")
+		transcript.WriteString(msg.Text())
+		transcript.WriteString("\n")
+	}
+	resp, err := m.cfg.Summarizer.Generate(ctx, &ai.ModelRequest{
+		Messages: []*ai.Message{
+			ai.NewSystemTextMessage(summarizePrompt),
+			ai.NewUserTextMessage(transcript.String()),
+		},
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	if resp == nil || resp.Message == nil {
+		return "", errors.New("summarizer returned no message")
+	}
+	return resp.Message.Text(), nil
+}
+
+// InMemorySessionStore is a [SessionStore] for development and tests; state
+// is lost when the process exits.
+type InMemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*SessionMemory
+}
+
+// Load implements [SessionStore].
+func (s *InMemorySessionStore) Load(ctx context.Context, sessionID string) (*SessionMemory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessions[sessionID], nil
+}
+
+// Save implements [SessionStore].
+func (s *InMemorySessionStore) Save(ctx context.Context, sessionID string, mem *SessionMemory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sessions == nil {
+		s.sessions = map[string]*SessionMemory{}
+	}
+	s.sessions[sessionID] = mem
+	return nil
+}
@@ -0,0 +1,96 @@
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizingMemory(t *testing.T) {
+	store := &InMemorySessionStore{}
+	summarizer := &fakeModel{name: "workersai/cheap", response: "They discussed capitals."}
+	mem, err := NewSummarizingMemory(SummarizingMemoryConfig{
+		Store:              store,
+		Summarizer:         summarizer,
+		KeepRecentMessages: 2,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Within the recent window nothing is summarized.
+	require.NoError(t, mem.Append(ctx, "s1",
+		ai.NewUserTextMessage("What is the capital of France?"),
+		ai.NewModelTextMessage("Paris."),
+	))
+	msgs, err := mem.Messages(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	assert.Empty(t, summarizer.requests)
+
+	// Overflowing the window folds the oldest turns into the summary.
+	require.NoError(t, mem.Append(ctx, "s1",
+		ai.NewUserTextMessage("And of Japan?"),
+		ai.NewModelTextMessage("Tokyo."),
+	))
+	msgs, err = mem.Messages(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, "Summary of the conversation so far: They discussed capitals.", msgs[0].Text())
+	assert.Equal(t, "And of Japan?", msgs[1].Text())
+	assert.Equal(t, "Tokyo.", msgs[2].Text())
+
+	// The summarizer saw the overflowing turns in transcript form.
+	require.Len(t, summarizer.requests, 1)
+	transcript := summarizer.requests[0].Messages[1].Text()
+	assert.Contains(t, transcript, "user: What is the capital of France?")
+	assert.Contains(t, transcript, "model: Paris.")
+
+	// The next fold carries the previous summary along.
+	require.NoError(t, mem.Append(ctx, "s1", ai.NewUserTextMessage("Thanks!")))
+	require.Len(t, summarizer.requests, 2)
+	assert.Contains(t, summarizer.requests[1].Messages[1].Text(),
+		"Summary of the conversation so far: They discussed capitals.")
+
+	// Sessions are independent, and Clear forgets one.
+	msgs, err = mem.Messages(ctx, "s2")
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+	require.NoError(t, mem.Clear(ctx, "s1"))
+	msgs, err = mem.Messages(ctx, "s1")
+	require.NoError(t, err)
+	assert.Empty(t, msgs)
+}
+
+// failingModel always errors, for exercising non-fatal summarization.
+type failingModel struct{}
+
+func (failingModel) Name() string { return "workersai/failing" }
+
+func (failingModel) Generate(context.Context, *ai.ModelRequest, ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	return nil, fmt.Errorf("summarizer down")
+}
+
+func TestSummarizingMemoryKeepsTurnsWhenSummarizerFails(t *testing.T) {
+	store := &InMemorySessionStore{}
+	mem, err := NewSummarizingMemory(SummarizingMemoryConfig{
+		Store:              store,
+		Summarizer:         failingModel{},
+		KeepRecentMessages: 1,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, mem.Append(ctx, "s1",
+		ai.NewUserTextMessage("first"),
+		ai.NewModelTextMessage("second"),
+	))
+	msgs, err := mem.Messages(ctx, "s1")
+	require.NoError(t, err)
+	// Nothing was lost: both turns remain verbatim until a summarizer call
+	// succeeds.
+	require.Len(t, msgs, 2)
+}
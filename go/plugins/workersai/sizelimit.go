@@ -0,0 +1,89 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// errResponseTooLarge is returned by a size-limited response body once more
+// than the configured number of bytes has been read.
+var errResponseTooLarge = errors.New("workersai: response exceeded the configured size limit")
+
+// sizeLimitTransport caps how many response-body bytes a request may
+// accumulate. It protects memory when a model goes into a repetition loop
+// and produces megabytes of output; the read aborts mid-body and generate
+// maps the failure to [ai.FinishReasonLength].
+type sizeLimitTransport struct {
+	base  http.RoundTripper
+	limit int64
+}
+
+func (t *sizeLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	// One extra byte distinguishes a body of exactly limit bytes (fine)
+	// from one that exceeds it.
+	resp.Body = &limitedBody{body: resp.Body, remaining: t.limit + 1}
+	return resp, nil
+}
+
+// limitedBody reads through to the underlying body until the limit is
+// consumed, then fails with errResponseTooLarge instead of io.EOF.
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, errResponseTooLarge
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// lengthLimitedResponse is the response returned when the size guard fired:
+// no content survived, but the caller gets a warning instead of an opaque
+// read error.
+func lengthLimitedResponse(input *ai.ModelRequest, limit int) *ai.ModelResponse {
+	return &ai.ModelResponse{
+		Request:       input,
+		FinishReason:  ai.FinishReasonLength,
+		FinishMessage: fmt.Sprintf("response aborted after exceeding the configured %d-byte limit", limit),
+		Message:       &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{}},
+		Usage:         &ai.GenerationUsage{},
+	}
+}
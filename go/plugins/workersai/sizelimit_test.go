@@ -0,0 +1,51 @@
+package workersai
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizeLimitTransport(t *testing.T) {
+	t.Run("body within the limit reads fully", func(t *testing.T) {
+		base := &fakeRoundTripper{body: strings.Repeat("a", 10)}
+		tr := &sizeLimitTransport{base: base, limit: 10}
+
+		req, err := http.NewRequest("POST", "https://example.com", nil)
+		require.NoError(t, err)
+		resp, err := tr.RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Len(t, body, 10)
+	})
+
+	t.Run("oversized body aborts the read", func(t *testing.T) {
+		base := &fakeRoundTripper{body: strings.Repeat("a", 100)}
+		tr := &sizeLimitTransport{base: base, limit: 10}
+
+		req, err := http.NewRequest("POST", "https://example.com", nil)
+		require.NoError(t, err)
+		resp, err := tr.RoundTrip(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		_, err = io.ReadAll(resp.Body)
+		assert.ErrorIs(t, err, errResponseTooLarge)
+	})
+}
+
+func TestLengthLimitedResponse(t *testing.T) {
+	input := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("hi")}}
+	resp := lengthLimitedResponse(input, 1024)
+	assert.Equal(t, ai.FinishReasonLength, resp.FinishReason)
+	assert.Contains(t, resp.FinishMessage, "1024")
+	assert.Empty(t, resp.Message.Content)
+}
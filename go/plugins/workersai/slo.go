@@ -0,0 +1,238 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SLOConfig configures threshold alerting on the plugin's own traffic, so
+// operators get early warning on latency, error-rate and spend regressions
+// without building the metric math themselves.
+type SLOConfig struct {
+	// P95Latency alerts when the rolling p95 request latency exceeds it.
+	// Zero disables the latency SLO.
+	P95Latency time.Duration
+	// ErrorRate alerts when the fraction of failed requests in the window
+	// exceeds it (0.05 means five percent). Zero disables the error SLO.
+	ErrorRate float64
+	// HourlyTokens alerts when token consumption over the last hour
+	// exceeds it, as an early spend warning. Zero disables the spend SLO.
+	HourlyTokens int
+
+	// Window is the rolling window for the latency and error SLOs. The
+	// default is five minutes; the spend SLO always looks at one hour.
+	Window time.Duration
+	// MinSamples is how many requests the window must hold before the
+	// latency and error SLOs are evaluated, so a single slow call on an
+	// idle service does not page anyone. The default is 20.
+	MinSamples int
+	// Cooldown is the minimum time between repeated alerts for the same
+	// SLO. The default is ten minutes.
+	Cooldown time.Duration
+
+	// Observers are notified of each breach. See [LogSLOObserver] and
+	// [WebhookSLOObserver] for built-in sinks.
+	Observers []SLOObserver
+}
+
+// SLOBreach describes one threshold breach.
+type SLOBreach struct {
+	// SLO identifies the breached objective: "p95Latency", "errorRate" or
+	// "hourlyTokens".
+	SLO string `json:"slo"`
+	// Value is the observed value and Threshold the configured limit, in
+	// the SLO's unit (milliseconds, fraction, tokens).
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	// At is when the breach was detected.
+	At time.Time `json:"at"`
+}
+
+// SLOObserver is notified of SLO breaches. Implementations must be safe for
+// concurrent use and should return quickly; slow sinks delay request
+// completion.
+type SLOObserver interface {
+	SLOBreach(b SLOBreach)
+}
+
+// LogSLOObserver writes each breach to slog at warning level.
+type LogSLOObserver struct{}
+
+func (LogSLOObserver) SLOBreach(b SLOBreach) {
+	slog.Warn("workersai: SLO breached",
+		"slo", b.SLO, "value", b.Value, "threshold", b.Threshold)
+}
+
+// WebhookSLOObserver posts each breach as JSON to a webhook URL, fire and
+// forget; delivery failures are logged, not retried.
+type WebhookSLOObserver struct {
+	// URL receives a POST with the [SLOBreach] as its JSON body. Required.
+	URL string
+	// Client, when set, overrides the HTTP client used for delivery.
+	Client *http.Client
+}
+
+func (o *WebhookSLOObserver) SLOBreach(b SLOBreach) {
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return
+	}
+	httpClient := o.Client
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Post(o.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		slog.Warn("workersai: SLO webhook delivery failed", "url", o.URL, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("workersai: SLO webhook delivery failed", "url", o.URL, "status", resp.StatusCode)
+	}
+}
+
+// sloSample is one request's contribution to the rolling SLO state.
+type sloSample struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+	tokens  int
+}
+
+// sloMonitor keeps a rolling sample window and evaluates the configured
+// objectives after each request.
+type sloMonitor struct {
+	cfg SLOConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	samples   []sloSample
+	lastAlert map[string]time.Time
+}
+
+func newSLOMonitor(cfg SLOConfig) *sloMonitor {
+	if cfg.Window <= 0 {
+		cfg.Window = 5 * time.Minute
+	}
+	if cfg.MinSamples <= 0 {
+		cfg.MinSamples = 20
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 10 * time.Minute
+	}
+	return &sloMonitor{
+		cfg:       cfg,
+		now:       time.Now,
+		lastAlert: map[string]time.Time{},
+	}
+}
+
+// record adds one finished request and evaluates the objectives.
+func (m *sloMonitor) record(latency time.Duration, failed bool, tokens int) {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.samples = append(m.samples, sloSample{at: now, latency: latency, failed: failed, tokens: tokens})
+	// The spend SLO needs an hour of history; everything older serves no
+	// objective.
+	cutoff := now.Add(-time.Hour)
+	for len(m.samples) > 0 && m.samples[0].at.Before(cutoff) {
+		m.samples = m.samples[1:]
+	}
+
+	m.evaluateLocked(now)
+}
+
+func (m *sloMonitor) evaluateLocked(now time.Time) {
+	var windowed []sloSample
+	windowStart := now.Add(-m.cfg.Window)
+	hourTokens := 0
+	for _, s := range m.samples {
+		hourTokens += s.tokens
+		if !s.at.Before(windowStart) {
+			windowed = append(windowed, s)
+		}
+	}
+
+	if len(windowed) >= m.cfg.MinSamples {
+		if m.cfg.P95Latency > 0 {
+			if p95 := latencyPercentile(windowed, 0.95); p95 > m.cfg.P95Latency {
+				m.alertLocked(now, "p95Latency",
+					float64(p95.Milliseconds()), float64(m.cfg.P95Latency.Milliseconds()))
+			}
+		}
+		if m.cfg.ErrorRate > 0 {
+			failed := 0
+			for _, s := range windowed {
+				if s.failed {
+					failed++
+				}
+			}
+			if rate := float64(failed) / float64(len(windowed)); rate > m.cfg.ErrorRate {
+				m.alertLocked(now, "errorRate", rate, m.cfg.ErrorRate)
+			}
+		}
+	}
+	if m.cfg.HourlyTokens > 0 && hourTokens > m.cfg.HourlyTokens {
+		m.alertLocked(now, "hourlyTokens", float64(hourTokens), float64(m.cfg.HourlyTokens))
+	}
+}
+
+// alertLocked notifies the observers of a breach unless the same SLO alerted
+// within the cooldown.
+func (m *sloMonitor) alertLocked(now time.Time, slo string, value, threshold float64) {
+	if last, ok := m.lastAlert[slo]; ok && now.Sub(last) < m.cfg.Cooldown {
+		return
+	}
+	m.lastAlert[slo] = now
+	breach := SLOBreach{SLO: slo, Value: value, Threshold: threshold, At: now}
+	for _, o := range m.cfg.Observers {
+		o.SLOBreach(breach)
+	}
+}
+
+// latencyPercentile returns the given latency percentile of the samples
+// using nearest-rank on a sorted copy.
+func latencyPercentile(samples []sloSample, p float64) time.Duration {
+	latencies := make([]time.Duration, len(samples))
+	for i, s := range samples {
+		latencies[i] = s.latency
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	rank := int(p*float64(len(latencies))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(latencies) {
+		rank = len(latencies) - 1
+	}
+	return latencies[rank]
+}
+
+// String renders the breach for log lines and error messages.
+func (b SLOBreach) String() string {
+	return fmt.Sprintf("%s: %.2f over threshold %.2f", b.SLO, b.Value, b.Threshold)
+}
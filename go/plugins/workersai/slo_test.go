@@ -0,0 +1,130 @@
+package workersai
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureObserver collects breaches for assertions.
+type captureObserver struct {
+	breaches []SLOBreach
+}
+
+func (o *captureObserver) SLOBreach(b SLOBreach) {
+	o.breaches = append(o.breaches, b)
+}
+
+func TestSLOMonitorP95Latency(t *testing.T) {
+	obs := &captureObserver{}
+	m := newSLOMonitor(SLOConfig{
+		P95Latency: 500 * time.Millisecond,
+		MinSamples: 20,
+		Observers:  []SLOObserver{obs},
+	})
+
+	// Nineteen fast requests plus one slow one: the window is too small
+	// to evaluate until the twentieth, and p95 of twenty samples is the
+	// second slowest, so one outlier alone does not breach.
+	for i := 0; i < 19; i++ {
+		m.record(100*time.Millisecond, false, 0)
+	}
+	m.record(2*time.Second, false, 0)
+	assert.Empty(t, obs.breaches)
+
+	// A second slow request pushes p95 over the threshold.
+	m.record(2*time.Second, false, 0)
+	require.Len(t, obs.breaches, 1)
+	assert.Equal(t, "p95Latency", obs.breaches[0].SLO)
+	assert.Equal(t, float64(2000), obs.breaches[0].Value)
+	assert.Equal(t, float64(500), obs.breaches[0].Threshold)
+}
+
+func TestSLOMonitorErrorRate(t *testing.T) {
+	obs := &captureObserver{}
+	m := newSLOMonitor(SLOConfig{
+		ErrorRate:  0.2,
+		MinSamples: 5,
+		Observers:  []SLOObserver{obs},
+	})
+
+	m.record(time.Millisecond, true, 0)
+	for i := 0; i < 4; i++ {
+		m.record(time.Millisecond, false, 0)
+	}
+	// One failure in five is exactly 20%, which does not exceed the
+	// threshold.
+	assert.Empty(t, obs.breaches)
+
+	m.record(time.Millisecond, true, 0)
+	require.Len(t, obs.breaches, 1)
+	assert.Equal(t, "errorRate", obs.breaches[0].SLO)
+	assert.InDelta(t, 2.0/6.0, obs.breaches[0].Value, 1e-9)
+}
+
+func TestSLOMonitorHourlyTokens(t *testing.T) {
+	obs := &captureObserver{}
+	m := newSLOMonitor(SLOConfig{
+		HourlyTokens: 1000,
+		Observers:    []SLOObserver{obs},
+	})
+
+	// The spend SLO needs no minimum sample count.
+	m.record(time.Millisecond, false, 600)
+	assert.Empty(t, obs.breaches)
+	m.record(time.Millisecond, false, 600)
+	require.Len(t, obs.breaches, 1)
+	assert.Equal(t, "hourlyTokens", obs.breaches[0].SLO)
+	assert.Equal(t, float64(1200), obs.breaches[0].Value)
+
+	// Tokens older than an hour age out of the spend window.
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	m.record(time.Millisecond, false, 600)
+	assert.Len(t, obs.breaches, 1)
+}
+
+func TestSLOMonitorCooldown(t *testing.T) {
+	obs := &captureObserver{}
+	m := newSLOMonitor(SLOConfig{
+		HourlyTokens: 100,
+		Cooldown:     10 * time.Minute,
+		Observers:    []SLOObserver{obs},
+	})
+
+	base := time.Now()
+	m.now = func() time.Time { return base }
+	m.record(time.Millisecond, false, 200)
+	m.record(time.Millisecond, false, 200)
+	// The second breach of the same SLO is suppressed by the cooldown.
+	require.Len(t, obs.breaches, 1)
+
+	m.now = func() time.Time { return base.Add(11 * time.Minute) }
+	m.record(time.Millisecond, false, 200)
+	assert.Len(t, obs.breaches, 2)
+}
+
+func TestWebhookSLOObserver(t *testing.T) {
+	var got SLOBreach
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer srv.Close()
+
+	obs := &WebhookSLOObserver{URL: srv.URL}
+	obs.SLOBreach(SLOBreach{SLO: "errorRate", Value: 0.5, Threshold: 0.1})
+	assert.Equal(t, "errorRate", got.SLO)
+	assert.Equal(t, 0.5, got.Value)
+	assert.Equal(t, 0.1, got.Threshold)
+}
+
+func TestSLOBreachString(t *testing.T) {
+	b := SLOBreach{SLO: "p95Latency", Value: 750, Threshold: 500}
+	assert.Equal(t, "p95Latency: 750.00 over threshold 500.00", b.String())
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"strings"
+	"unicode"
+)
+
+// FlushPolicy controls how much text accumulates before a streaming chunk is
+// delivered, so websocket and SSE frontends can trade latency for fewer
+// render updates without buffering themselves.
+type FlushPolicy string
+
+const (
+	// FlushPerPart delivers each response part as one chunk. The default.
+	FlushPerPart FlushPolicy = ""
+	// FlushPerToken delivers a chunk per token — or per N tokens with
+	// Config.StreamFlushTokens — where a token is a whitespace-delimited
+	// word, the closest client-side stand-in for model tokens.
+	FlushPerToken FlushPolicy = "token"
+	// FlushPerSentence delivers a chunk per sentence, flushing at
+	// sentence-ending punctuation followed by whitespace.
+	FlushPerSentence FlushPolicy = "sentence"
+)
+
+// flushUnits splits a part's text into the pieces one chunk carries under the
+// policy. Whitespace stays attached to the preceding piece, so concatenating
+// the units reproduces the text exactly. every only applies to
+// [FlushPerToken] and groups that many tokens per chunk; values below 1 mean
+// one token per chunk.
+func flushUnits(text string, policy FlushPolicy, every int) []string {
+	switch policy {
+	case FlushPerToken:
+		tokens := splitTokens(text)
+		if every <= 1 {
+			return tokens
+		}
+		var units []string
+		for len(tokens) > 0 {
+			n := min(every, len(tokens))
+			units = append(units, strings.Join(tokens[:n], ""))
+			tokens = tokens[n:]
+		}
+		return units
+	case FlushPerSentence:
+		return splitSentences(text)
+	default:
+		if text == "" {
+			return nil
+		}
+		return []string{text}
+	}
+}
+
+// splitTokens splits text into words, each carrying its trailing whitespace.
+func splitTokens(text string) []string {
+	var tokens []string
+	start := 0
+	inSpace := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if inSpace && !space {
+			tokens = append(tokens, text[start:i])
+			start = i
+		}
+		inSpace = space
+	}
+	if start < len(text) {
+		tokens = append(tokens, text[start:])
+	}
+	return tokens
+}
+
+// splitSentences splits text after runs of sentence-ending punctuation
+// (including any closing quotes or brackets) followed by whitespace, with the
+// whitespace kept on the finished sentence.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	afterEnd := false
+	inTrail := false
+	for i, r := range text {
+		switch {
+		case r == '.' || r == '!' || r == '?':
+			afterEnd = true
+		case afterEnd && (r == '"' || r == '\'' || r == ')' || r == ']'):
+			// Closing punctuation stays with the sentence it ends.
+		case unicode.IsSpace(r):
+			if afterEnd {
+				inTrail = true
+			}
+		default:
+			if inTrail {
+				sentences = append(sentences, text[start:i])
+				start = i
+			}
+			afterEnd = false
+			inTrail = false
+		}
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
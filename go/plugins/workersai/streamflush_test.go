@@ -0,0 +1,60 @@
+package workersai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlushUnits(t *testing.T) {
+	text := "Hello there. How are you? Fine!"
+
+	tests := []struct {
+		name   string
+		policy FlushPolicy
+		every  int
+		want   []string
+	}{
+		{
+			name:   "per part is the default",
+			policy: FlushPerPart,
+			want:   []string{text},
+		},
+		{
+			name:   "per token",
+			policy: FlushPerToken,
+			want:   []string{"Hello ", "there. ", "How ", "are ", "you? ", "Fine!"},
+		},
+		{
+			name:   "per three tokens",
+			policy: FlushPerToken,
+			every:  3,
+			want:   []string{"Hello there. How ", "are you? Fine!"},
+		},
+		{
+			name:   "per sentence",
+			policy: FlushPerSentence,
+			want:   []string{"Hello there. ", "How are you? ", "Fine!"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := flushUnits(text, tt.policy, tt.every)
+			assert.Equal(t, tt.want, got)
+			// Whatever the granularity, the units must reassemble the
+			// original text byte for byte.
+			assert.Equal(t, text, strings.Join(got, ""))
+		})
+	}
+
+	t.Run("empty text yields no chunks", func(t *testing.T) {
+		assert.Empty(t, flushUnits("", FlushPerToken, 0))
+		assert.Empty(t, flushUnits("", FlushPerPart, 0))
+	})
+
+	t.Run("sentence splitting keeps closing quotes", func(t *testing.T) {
+		got := flushUnits(`"Stop." He left. 3.14 is pi.`, FlushPerSentence, 0)
+		assert.Equal(t, []string{`"Stop." `, "He left. ", "3.14 is pi."}, got)
+	})
+}
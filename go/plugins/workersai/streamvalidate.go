@@ -0,0 +1,172 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// jsonStreamValidator checks the accumulating text of a structured-output
+// stream as chunks arrive, so clients rendering partial structured data get
+// an early error instead of displaying invalid intermediate states. It
+// performs two incremental checks: the accumulated text must remain a valid
+// prefix of a JSON document, and completed top-level keys must be declared
+// in the output schema when the schema closes off additional properties.
+type jsonStreamValidator struct {
+	buf strings.Builder
+
+	// allowedKeys is non-nil only when the schema declares properties and
+	// sets additionalProperties to false.
+	allowedKeys map[string]bool
+}
+
+// newJSONStreamValidator builds a validator for the given output schema,
+// which may be nil when the request asked for JSON without a schema.
+func newJSONStreamValidator(schema map[string]any) *jsonStreamValidator {
+	v := &jsonStreamValidator{}
+	if schema == nil {
+		return v
+	}
+	if extra, ok := schema["additionalProperties"].(bool); !ok || extra {
+		return v
+	}
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return v
+	}
+	v.allowedKeys = make(map[string]bool, len(props))
+	for name := range props {
+		v.allowedKeys[name] = true
+	}
+	return v
+}
+
+// feed appends the next chunk of model text and reports whether the
+// accumulated output can still become valid JSON for the schema.
+func (v *jsonStreamValidator) feed(text string) error {
+	v.buf.WriteString(text)
+	ok, keys := scanJSONPrefix(v.buf.String())
+	if !ok {
+		return fmt.Errorf("model output is not valid JSON for the requested output schema; got: %s", snippet(v.buf.String()))
+	}
+	if v.allowedKeys != nil {
+		for _, key := range keys {
+			if !v.allowedKeys[key] {
+				return fmt.Errorf("model output contains field %q not allowed by the output schema", key)
+			}
+		}
+	}
+	return nil
+}
+
+// scanJSONPrefix reports whether s is a prefix of a syntactically valid JSON
+// document, along with the completed top-level object keys seen so far.
+// Markdown fences are tolerated at the start since cleanJSONOutput strips
+// them from the final text.
+func scanJSONPrefix(s string) (bool, []string) {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	s = strings.TrimPrefix(s, "```json")
+	s = strings.TrimPrefix(s, "```")
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	if s == "" {
+		return true, nil
+	}
+
+	var (
+		stack    []byte
+		keys     []string
+		inString bool
+		escaped  bool
+		str      strings.Builder
+		// keyPos is true when the next string at depth 1 is an object key.
+		keyPos  bool
+		started bool
+		done    bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+				if keyPos && len(stack) == 1 {
+					keys = append(keys, str.String())
+				}
+			default:
+				str.WriteByte(c)
+			}
+			continue
+		}
+		if done {
+			// Trailing content after the top-level value closes; only
+			// whitespace and a closing fence are acceptable.
+			rest := strings.TrimRightFunc(strings.TrimSpace(s[i:]), func(r rune) bool { return r == '`' })
+			return strings.TrimSpace(rest) == "", keys
+		}
+		switch c {
+		case '{':
+			stack = append(stack, '}')
+			keyPos = true
+			started = true
+		case '[':
+			stack = append(stack, ']')
+			keyPos = false
+			started = true
+		case '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != c {
+				return false, keys
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				done = true
+			}
+		case '"':
+			inString = true
+			str.Reset()
+			started = true
+		case ':':
+			keyPos = false
+		case ',':
+			if len(stack) > 0 && stack[len(stack)-1] == '}' {
+				keyPos = true
+			}
+		default:
+			if !started && !unicode.IsSpace(rune(c)) {
+				// A bare scalar (number, true, false, null) is valid
+				// JSON, but structured output always requests an object
+				// or array; treat anything else as prose.
+				return false, keys
+			}
+		}
+	}
+	return true, keys
+}
+
+// snippet truncates text for inclusion in an error message.
+func snippet(text string) string {
+	const max = 200
+	text = strings.TrimSpace(text)
+	if len(text) > max {
+		return text[:max] + "..."
+	}
+	return text
+}
@@ -0,0 +1,61 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONStreamValidator(t *testing.T) {
+	schema := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"age":  map[string]any{"type": "integer"},
+		},
+	}
+
+	t.Run("valid document fed in pieces", func(t *testing.T) {
+		v := newJSONStreamValidator(schema)
+		for _, chunk := range []string{`{"na`, `me": "Ada`, `", "age": 3`, `6}`} {
+			require.NoError(t, v.feed(chunk))
+		}
+	})
+
+	t.Run("prose instead of JSON fails on the first chunk", func(t *testing.T) {
+		v := newJSONStreamValidator(schema)
+		assert.Error(t, v.feed("Sure! Here is the JSON you asked for"))
+	})
+
+	t.Run("mismatched brackets fail as soon as they close", func(t *testing.T) {
+		v := newJSONStreamValidator(schema)
+		require.NoError(t, v.feed(`{"name": ["Ada"`))
+		assert.Error(t, v.feed(`}}`))
+	})
+
+	t.Run("undeclared field fails once its key completes", func(t *testing.T) {
+		v := newJSONStreamValidator(schema)
+		require.NoError(t, v.feed(`{"name": "Ada", "hei`))
+		err := v.feed(`ght": 170}`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"height"`)
+	})
+
+	t.Run("trailing content after the document fails", func(t *testing.T) {
+		v := newJSONStreamValidator(schema)
+		require.NoError(t, v.feed(`{"name": "Ada"}`))
+		assert.Error(t, v.feed(` and that concludes my answer`))
+	})
+
+	t.Run("markdown fences are tolerated", func(t *testing.T) {
+		v := newJSONStreamValidator(nil)
+		require.NoError(t, v.feed("```json\n{\"name\": \"Ada\"}\n```"))
+	})
+
+	t.Run("nested keys are not checked against the top-level schema", func(t *testing.T) {
+		v := newJSONStreamValidator(schema)
+		require.NoError(t, v.feed(`{"name": {"anything": "goes"}`))
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"sort"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// strictToolsPayload renders tool definitions for strict schema adherence:
+// every declared property is required, additional properties are forbidden,
+// and the function carries the OpenAI-style "strict" marker for models that
+// honor it. The vendored client's tool types cannot express these fields, so
+// the payload replaces the serialized "tools" key at the transport layer,
+// the same way bodyParams are injected.
+func strictToolsPayload(defs []*ai.ToolDefinition, names *toolNameMap) []map[string]any {
+	tools := make([]map[string]any, 0, len(defs))
+	for _, def := range defs {
+		params := map[string]any{"type": "object"}
+		for k, v := range def.InputSchema {
+			params[k] = v
+		}
+		params["additionalProperties"] = false
+		if props, ok := params["properties"].(map[string]any); ok {
+			required := make([]string, 0, len(props))
+			for name := range props {
+				required = append(required, name)
+			}
+			sort.Strings(required)
+			params["required"] = required
+		}
+		tools = append(tools, map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        names.wire(def.Name),
+				"description": def.Description,
+				"parameters":  params,
+				"strict":      true,
+			},
+		})
+	}
+	return tools
+}
+
+// validateStrictToolCalls checks the model's tool calls against each tool's
+// input schema: arguments must not carry keys the schema does not declare,
+// and must carry every key the schema requires. Strict mode is a request the
+// model may not honor, so this is the enforcement half — a bad call is
+// rejected here, before the tool executes. Tools without an input schema are
+// not validated.
+func validateStrictToolCalls(parts []*ai.Part, defs []*ai.ToolDefinition) error {
+	schemas := make(map[string]map[string]any, len(defs))
+	for _, def := range defs {
+		schemas[def.Name] = def.InputSchema
+	}
+	for _, part := range parts {
+		req := part.ToolRequest
+		schema := schemas[req.Name]
+		if schema == nil {
+			continue
+		}
+		props, _ := schema["properties"].(map[string]any)
+		args, _ := req.Input.(map[string]any)
+		keys := make([]string, 0, len(args))
+		for key := range args {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := props[key]; !ok {
+				return errors.Errorf("strict tools: model called %q with argument %q, which its schema does not declare", req.Name, key)
+			}
+		}
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, ok := args[name]; !ok {
+					return errors.Errorf("strict tools: model called %q without required argument %q", req.Name, name)
+				}
+			}
+		}
+	}
+	return nil
+}
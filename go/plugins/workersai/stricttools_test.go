@@ -0,0 +1,76 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func weatherDef() *ai.ToolDefinition {
+	return &ai.ToolDefinition{
+		Name:        "getWeather",
+		Description: "current weather",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city": map[string]any{"type": "string"},
+				"unit": map[string]any{"type": "string"},
+			},
+			"required": []any{"city"},
+		},
+	}
+}
+
+func TestStrictToolsPayload(t *testing.T) {
+	defs := []*ai.ToolDefinition{weatherDef()}
+	tools := strictToolsPayload(defs, newToolNameMap(defs))
+	require.Len(t, tools, 1)
+
+	fn := tools[0]["function"].(map[string]any)
+	assert.Equal(t, "getWeather", fn["name"])
+	assert.Equal(t, true, fn["strict"])
+
+	params := fn["parameters"].(map[string]any)
+	assert.Equal(t, false, params["additionalProperties"])
+	// Strict mode requires every declared property, not just the schema's
+	// own required list.
+	assert.Equal(t, []string{"city", "unit"}, params["required"])
+
+	// The original definition is left untouched.
+	assert.Equal(t, []any{"city"}, defs[0].InputSchema["required"])
+	assert.NotContains(t, defs[0].InputSchema, "additionalProperties")
+}
+
+func TestValidateStrictToolCalls(t *testing.T) {
+	defs := []*ai.ToolDefinition{weatherDef()}
+	call := func(input map[string]any) []*ai.Part {
+		return []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+			Name:  "getWeather",
+			Input: input,
+		})}
+	}
+
+	t.Run("accepts a conforming call", func(t *testing.T) {
+		err := validateStrictToolCalls(call(map[string]any{"city": "Lisbon", "unit": "celsius"}), defs)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects unexpected keys", func(t *testing.T) {
+		err := validateStrictToolCalls(call(map[string]any{"city": "Lisbon", "country": "PT"}), defs)
+		require.ErrorContains(t, err, `argument "country"`)
+		assert.ErrorContains(t, err, "schema does not declare")
+	})
+
+	t.Run("rejects missing required keys", func(t *testing.T) {
+		err := validateStrictToolCalls(call(map[string]any{"unit": "celsius"}), defs)
+		require.ErrorContains(t, err, `without required argument "city"`)
+	})
+
+	t.Run("skips tools without a schema", func(t *testing.T) {
+		noSchema := []*ai.ToolDefinition{{Name: "getWeather"}}
+		err := validateStrictToolCalls(call(map[string]any{"anything": 1}), noSchema)
+		assert.NoError(t, err)
+	})
+}
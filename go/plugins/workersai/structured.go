@@ -0,0 +1,82 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/internal/base"
+)
+
+// guidedOutputParams translates a request's output config into the Workers AI
+// response_format body parameter, so genkit.GenerateData gets natively guided
+// JSON instead of relying on prompt instructions alone.
+func guidedOutputParams(output *ai.ModelOutputConfig) map[string]any {
+	if output == nil || output.Format != ai.OutputFormatJSON {
+		return nil
+	}
+	if len(output.Schema) > 0 {
+		return map[string]any{
+			"response_format": map[string]any{
+				"type":        "json_schema",
+				"json_schema": output.Schema,
+			},
+		}
+	}
+	return map[string]any{
+		"response_format": map[string]any{"type": "json_object"},
+	}
+}
+
+// guidedDecodingParams translates the config's guided_regex/guided_choice
+// constraints into body parameters, rejecting combinations that ask for more
+// than one kind of constrained decoding at once.
+func guidedDecodingParams(cfg *Config, output *ai.ModelOutputConfig) (map[string]any, error) {
+	hasRegex := cfg.GuidedRegex != ""
+	hasChoice := len(cfg.GuidedChoice) > 0
+	if !hasRegex && !hasChoice {
+		return nil, nil
+	}
+	if hasRegex && hasChoice {
+		return nil, fmt.Errorf("guidedRegex and guidedChoice are mutually exclusive")
+	}
+	if output != nil && output.Format == ai.OutputFormatJSON {
+		return nil, fmt.Errorf("guided decoding cannot be combined with JSON output")
+	}
+	if hasRegex {
+		if _, err := regexp.Compile(cfg.GuidedRegex); err != nil {
+			return nil, fmt.Errorf("invalid guidedRegex: %w", err)
+		}
+		return map[string]any{"guided_regex": cfg.GuidedRegex}, nil
+	}
+	return map[string]any{"guided_choice": cfg.GuidedChoice}, nil
+}
+
+// cleanJSONOutput normalizes model text for a JSON-output request, stripping
+// markdown fences, and returns an actionable error when the result still
+// isn't valid JSON so schema deviations don't surface as opaque unmarshal
+// failures downstream.
+func cleanJSONOutput(text string) (string, error) {
+	cleaned := strings.TrimSpace(base.ExtractJSONFromMarkdown(text))
+	if !json.Valid([]byte(cleaned)) {
+		return "", fmt.Errorf("model did not return valid JSON for the requested output schema; got: %s", snippet(cleaned))
+	}
+	return cleaned, nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// SynthesizeOptions configures [Synthesize].
+type SynthesizeOptions struct {
+	// Model generates the examples. Required.
+	Model ai.Model
+
+	// Count is how many examples to generate before deduplication. The
+	// default is 10.
+	Count int
+
+	// Variables holds per-variation template substitutions, cycled through
+	// as examples are generated. The template's {{index}} placeholder is
+	// always available and does not need an entry.
+	Variables []map[string]string
+
+	// Config is the model config applied to every generation, typically a
+	// [Config] with a high temperature so variations actually vary.
+	Config any
+
+	// Concurrency is how many generations are in flight at once. The
+	// default is 4.
+	Concurrency int
+
+	// Embedder, when set, deduplicates the generated examples: an example
+	// whose output embedding is too similar to an already-kept one is
+	// dropped. Nil keeps everything.
+	Embedder ai.Embedder
+
+	// SimilarityThreshold is the cosine similarity at or above which two
+	// outputs count as duplicates. The default is 0.95.
+	SimilarityThreshold float64
+}
+
+// SyntheticExample is one generated dataset entry.
+type SyntheticExample struct {
+	// Prompt is the rendered prompt the example was generated from.
+	Prompt string `json:"prompt"`
+	// Output is the model's response text.
+	Output string `json:"output"`
+}
+
+// Synthesize bulk-generates a synthetic dataset from a prompt template:
+// the template is rendered Count times (substituting {{index}} and any
+// configured variables), the prompts are run against the model with bounded
+// concurrency, and near-duplicate outputs are dropped via embedding
+// similarity. It is aimed at teams using Workers AI to bootstrap
+// fine-tuning or eval datasets.
+func Synthesize(ctx context.Context, template string, opts *SynthesizeOptions) ([]SyntheticExample, error) {
+	if opts == nil || opts.Model == nil {
+		return nil, errors.New("workersai: Synthesize requires a model")
+	}
+	cfg := *opts
+	if cfg.Count <= 0 {
+		cfg.Count = 10
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.SimilarityThreshold == 0 {
+		cfg.SimilarityThreshold = 0.95
+	}
+
+	examples := make([]SyntheticExample, cfg.Count)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sem := make(chan struct{}, cfg.Concurrency)
+
+	for i := 0; i < cfg.Count; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			prompt := renderTemplate(template, i, cfg.Variables)
+			resp, err := cfg.Model.Generate(ctx, &ai.ModelRequest{
+				Messages: []*ai.Message{ai.NewUserTextMessage(prompt)},
+				Config:   cfg.Config,
+			}, nil)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = errors.Wrapf(err, "generation %d failed", i)
+					cancel()
+				}
+				return
+			}
+			examples[i] = SyntheticExample{Prompt: prompt, Output: resp.Message.Text()}
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if cfg.Embedder == nil {
+		return examples, nil
+	}
+	return dedupExamples(ctx, cfg.Embedder, examples, cfg.SimilarityThreshold)
+}
+
+// renderTemplate substitutes {{index}} and the variation's variables into
+// the template. Variations are cycled when there are fewer than Count.
+func renderTemplate(template string, index int, variables []map[string]string) string {
+	pairs := []string{"{{index}}", strconv.Itoa(index)}
+	if len(variables) > 0 {
+		for k, v := range variables[index%len(variables)] {
+			pairs = append(pairs, "{{"+k+"}}", v)
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// dedupExamples embeds every output in one batch and greedily keeps examples
+// whose embedding stays below the similarity threshold against everything
+// already kept. Order is preserved, so earlier generations win ties.
+func dedupExamples(ctx context.Context, embedder ai.Embedder, examples []SyntheticExample, threshold float64) ([]SyntheticExample, error) {
+	docs := make([]*ai.Document, len(examples))
+	for i, ex := range examples {
+		docs[i] = ai.DocumentFromText(ex.Output, nil)
+	}
+	eres, err := embedder.Embed(ctx, &ai.EmbedRequest{Input: docs})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to embed outputs for deduplication")
+	}
+	if len(eres.Embeddings) != len(examples) {
+		return nil, errors.Errorf("embedder returned %d embeddings for %d outputs", len(eres.Embeddings), len(examples))
+	}
+
+	var kept []SyntheticExample
+	var keptVecs [][]float32
+	for i, ex := range examples {
+		vec := eres.Embeddings[i].Embedding
+		duplicate := false
+		for _, prev := range keptVecs {
+			if cosineSimilarity(vec, prev) >= threshold {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			kept = append(kept, ex)
+			keptVecs = append(keptVecs, vec)
+		}
+	}
+	return kept, nil
+}
+
+// cosineSimilarity returns the cosine similarity of two vectors, or 0 when
+// either is empty or their lengths differ.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,102 @@
+package workersai
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoModel answers every prompt with its own text, so outputs mirror the
+// rendered templates.
+type echoModel struct {
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (m *echoModel) Name() string { return "echo" }
+
+func (m *echoModel) Generate(_ context.Context, req *ai.ModelRequest, _ ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+	prompt := req.Messages[0].Text()
+	m.mu.Lock()
+	m.prompts = append(m.prompts, prompt)
+	m.mu.Unlock()
+	return &ai.ModelResponse{Message: ai.NewModelTextMessage("echo: " + prompt)}, nil
+}
+
+// axisEmbedder embeds texts onto fixed axes so similarity is fully
+// controlled: texts sharing a prefix before ":" get identical vectors.
+type axisEmbedder struct {
+	axes map[string]int
+}
+
+func (axisEmbedder) Name() string { return "axis" }
+
+func (e axisEmbedder) Embed(_ context.Context, req *ai.EmbedRequest) (*ai.EmbedResponse, error) {
+	resp := &ai.EmbedResponse{}
+	for _, doc := range req.Input {
+		vec := make([]float32, len(e.axes)+1)
+		key := strings.SplitN(doc.Content[0].Text, ":", 2)[0]
+		if axis, ok := e.axes[key]; ok {
+			vec[axis] = 1
+		} else {
+			vec[len(e.axes)] = 1
+		}
+		resp.Embeddings = append(resp.Embeddings, &ai.Embedding{Embedding: vec})
+	}
+	return resp, nil
+}
+
+func TestRenderTemplate(t *testing.T) {
+	vars := []map[string]string{{"topic": "cheese"}, {"topic": "wine"}}
+	assert.Equal(t, "Q0 about cheese", renderTemplate("Q{{index}} about {{topic}}", 0, vars))
+	assert.Equal(t, "Q1 about wine", renderTemplate("Q{{index}} about {{topic}}", 1, vars))
+	// Variations cycle.
+	assert.Equal(t, "Q2 about cheese", renderTemplate("Q{{index}} about {{topic}}", 2, vars))
+	// No variables configured.
+	assert.Equal(t, "Q3", renderTemplate("Q{{index}}", 3, nil))
+}
+
+func TestSynthesize(t *testing.T) {
+	model := &echoModel{}
+	examples, err := Synthesize(context.Background(), "question {{index}} about {{topic}}", &SynthesizeOptions{
+		Model:     model,
+		Count:     4,
+		Variables: []map[string]string{{"topic": "go"}, {"topic": "rust"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, examples, 4)
+	// Results keep generation order regardless of goroutine scheduling.
+	assert.Equal(t, "question 0 about go", examples[0].Prompt)
+	assert.Equal(t, "question 1 about rust", examples[1].Prompt)
+	assert.Equal(t, "echo: question 2 about go", examples[2].Output)
+}
+
+func TestSynthesizeRequiresModel(t *testing.T) {
+	_, err := Synthesize(context.Background(), "x", nil)
+	require.Error(t, err)
+}
+
+func TestSynthesizeDedup(t *testing.T) {
+	// All outputs start with "echo" so they all land on the same axis and
+	// collapse to one example.
+	model := &echoModel{}
+	examples, err := Synthesize(context.Background(), "same prompt", &SynthesizeOptions{
+		Model:    model,
+		Count:    3,
+		Embedder: axisEmbedder{axes: map[string]int{"echo": 0}},
+	})
+	require.NoError(t, err)
+	assert.Len(t, examples, 1)
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	assert.InDelta(t, 1.0, cosineSimilarity([]float32{1, 0}, []float32{2, 0}), 1e-9)
+	assert.InDelta(t, 0.0, cosineSimilarity([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.Zero(t, cosineSimilarity([]float32{1}, []float32{1, 2}))
+	assert.Zero(t, cosineSimilarity(nil, nil))
+}
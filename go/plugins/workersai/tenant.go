@@ -0,0 +1,216 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// tenantContextKey carries the tenant identifier through a request context.
+type tenantContextKey struct{}
+
+// WithTenant returns a context carrying the tenant identifier. Requests made
+// with it are authenticated and rate-limited with that tenant's credentials
+// when the plugin has a [TenantPool] configured.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant identifier set by [WithTenant], or ""
+// when the context carries none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
+
+// TenantCredentials are one tenant's Cloudflare credentials and budget.
+type TenantCredentials struct {
+	// AccountID is the tenant's Cloudflare account. Empty keeps the
+	// plugin's account.
+	AccountID string
+	// APIToken authenticates the tenant's requests. Required.
+	APIToken string
+	// RequestsPerMinute is the tenant's rate budget. Zero means unlimited.
+	RequestsPerMinute int
+}
+
+// TenantUsage is the running usage of one tenant, for billing and quota
+// decisions.
+type TenantUsage struct {
+	// Requests is how many API requests the tenant has made through this
+	// plugin instance.
+	Requests int64
+	// LastRequestAt is when the tenant last made a request.
+	LastRequestAt time.Time
+}
+
+// TenantPool maps tenant identifiers (carried in the request context via
+// [WithTenant]) to separate Cloudflare credentials, with an isolated rate
+// limiter and usage counter per tenant, so a multi-tenant SaaS can bill and
+// isolate tenants properly. Requests without a tenant use the plugin's own
+// credentials.
+type TenantPool struct {
+	resolve func(tenant string) (*TenantCredentials, error)
+
+	mu     sync.Mutex
+	states map[string]*tenantState
+}
+
+// tenantState is the cached per-tenant credentials, limiter and counters.
+type tenantState struct {
+	creds   *TenantCredentials
+	limiter *localBucket
+	usage   TenantUsage
+}
+
+// NewTenantPool creates a pool that resolves tenant credentials through the
+// given function. Credentials are resolved once per tenant and cached for
+// the life of the pool.
+func NewTenantPool(resolve func(tenant string) (*TenantCredentials, error)) *TenantPool {
+	return &TenantPool{resolve: resolve, states: map[string]*tenantState{}}
+}
+
+// Usage returns the running usage of a tenant.
+func (p *TenantPool) Usage(tenant string) TenantUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if state, ok := p.states[tenant]; ok {
+		return state.usage
+	}
+	return TenantUsage{}
+}
+
+// state returns the cached state for a tenant, resolving credentials on
+// first use.
+func (p *TenantPool) state(tenant string) (*tenantState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if state, ok := p.states[tenant]; ok {
+		return state, nil
+	}
+	creds, err := p.resolve(tenant)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve credentials for tenant %q", tenant)
+	}
+	if creds == nil || creds.APIToken == "" {
+		return nil, errors.Errorf("no credentials for tenant %q", tenant)
+	}
+	state := &tenantState{creds: creds}
+	if creds.RequestsPerMinute > 0 {
+		state.limiter = newLocalBucket(creds.RequestsPerMinute)
+	}
+	p.states[tenant] = state
+	return state, nil
+}
+
+// localBucket is an in-process token bucket refilled at a per-minute rate.
+// Unlike [KVRateLimiter]-style coordination it is local to this instance,
+// which is the right isolation unit for per-tenant budgets within one
+// process.
+type localBucket struct {
+	mu        sync.Mutex
+	capacity  float64
+	perSecond float64
+	tokens    float64
+	updated   time.Time
+}
+
+func newLocalBucket(perMinute int) *localBucket {
+	return &localBucket{
+		capacity:  float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		tokens:    float64(perMinute),
+		updated:   time.Now(),
+	}
+}
+
+// acquire blocks until a token is available or ctx is done.
+func (b *localBucket) acquire(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*b.perSecond)
+		b.updated = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.perSecond * float64(time.Second))
+		b.mu.Unlock()
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// tenantTransport swaps in per-tenant credentials on requests whose context
+// names a tenant. It sits at the bottom of the transport stack so its
+// Authorization header wins over the plugin-wide token layers above it.
+type tenantTransport struct {
+	base http.RoundTripper
+	pool *TenantPool
+	// accountID is the plugin's own account, replaced in request paths
+	// when the tenant has a separate account.
+	accountID string
+}
+
+func (t *tenantTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	tenant := TenantFromContext(req.Context())
+	// A failed-over request already carries the secondary account's
+	// credentials and path; it was rate-limited and counted on its first
+	// pass through this layer.
+	if tenant == "" || failedOver(req) {
+		return base.RoundTrip(req)
+	}
+
+	state, err := t.pool.state(tenant)
+	if err != nil {
+		return nil, err
+	}
+	if state.limiter != nil {
+		if err := state.limiter.acquire(req.Context()); err != nil {
+			return nil, errors.Wrapf(err, "rate limit wait aborted for tenant %q", tenant)
+		}
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+state.creds.APIToken)
+	if state.creds.AccountID != "" && t.accountID != "" {
+		req.URL.Path = strings.Replace(req.URL.Path,
+			"/accounts/"+t.accountID+"/", "/accounts/"+state.creds.AccountID+"/", 1)
+	}
+
+	t.pool.mu.Lock()
+	state.usage.Requests++
+	state.usage.LastRequestAt = time.Now()
+	t.pool.mu.Unlock()
+
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,110 @@
+package workersai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantTransport(t *testing.T) {
+	pool := NewTenantPool(func(tenant string) (*TenantCredentials, error) {
+		switch tenant {
+		case "acme":
+			return &TenantCredentials{AccountID: "acme-account", APIToken: "acme-token"}, nil
+		case "globex":
+			return &TenantCredentials{APIToken: "globex-token"}, nil
+		default:
+			return nil, errors.Errorf("unknown tenant %q", tenant)
+		}
+	})
+
+	base := &fakeRoundTripper{}
+	transport := &tenantTransport{
+		base:      base,
+		pool:      pool,
+		accountID: "plugin-account",
+	}
+	lastRequest := func() *http.Request {
+		require.NotEmpty(t, base.requests)
+		return base.requests[len(base.requests)-1]
+	}
+
+	newRequest := func(ctx context.Context) *http.Request {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+			"https://api.cloudflare.com/client/v4/accounts/plugin-account/ai/run/@cf/meta/llama-3.1-8b-instruct", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer plugin-token")
+		return req
+	}
+
+	t.Run("no tenant passes through", func(t *testing.T) {
+		_, err := transport.RoundTrip(newRequest(context.Background()))
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer plugin-token", lastRequest().Header.Get("Authorization"))
+		assert.Contains(t, lastRequest().URL.Path, "/accounts/plugin-account/")
+	})
+
+	t.Run("tenant credentials and account swapped in", func(t *testing.T) {
+		_, err := transport.RoundTrip(newRequest(WithTenant(context.Background(), "acme")))
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer acme-token", lastRequest().Header.Get("Authorization"))
+		assert.Contains(t, lastRequest().URL.Path, "/accounts/acme-account/")
+	})
+
+	t.Run("tenant without its own account keeps the plugin account", func(t *testing.T) {
+		_, err := transport.RoundTrip(newRequest(WithTenant(context.Background(), "globex")))
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer globex-token", lastRequest().Header.Get("Authorization"))
+		assert.Contains(t, lastRequest().URL.Path, "/accounts/plugin-account/")
+	})
+
+	t.Run("unknown tenant fails", func(t *testing.T) {
+		_, err := transport.RoundTrip(newRequest(WithTenant(context.Background(), "initech")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `unknown tenant "initech"`)
+	})
+
+	t.Run("usage counted per tenant", func(t *testing.T) {
+		usage := pool.Usage("acme")
+		assert.Equal(t, int64(1), usage.Requests)
+		assert.False(t, usage.LastRequestAt.IsZero())
+		assert.Equal(t, int64(1), pool.Usage("globex").Requests)
+		assert.Zero(t, pool.Usage("initech").Requests)
+	})
+}
+
+func TestTenantTransportRateLimit(t *testing.T) {
+	pool := NewTenantPool(func(string) (*TenantCredentials, error) {
+		return &TenantCredentials{APIToken: "tok", RequestsPerMinute: 1}, nil
+	})
+	transport := &tenantTransport{
+		base: &fakeRoundTripper{},
+		pool: pool,
+	}
+
+	ctx := WithTenant(context.Background(), "acme")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com/", nil)
+	require.NoError(t, err)
+
+	// The first request consumes the only token in the bucket; a second
+	// with an already-cancelled context must fail while waiting rather
+	// than going through.
+	_, err = transport.RoundTrip(req)
+	require.NoError(t, err)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	req2, err := http.NewRequestWithContext(cancelled, http.MethodGet, "https://example.com/", nil)
+	require.NoError(t, err)
+	_, err = transport.RoundTrip(req2)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestTenantFromContext(t *testing.T) {
+	assert.Empty(t, TenantFromContext(context.Background()))
+	assert.Equal(t, "acme", TenantFromContext(WithTenant(context.Background(), "acme")))
+}
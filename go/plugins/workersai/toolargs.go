@@ -0,0 +1,112 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"math"
+	"strconv"
+)
+
+// coerceToolArguments adapts argument values to the types the tool's input
+// schema declares. Smaller models frequently return stringly-typed numbers
+// ("2" instead of 2) or floats where the schema says integer; without
+// coercion those fail tool input validation downstream.
+func coerceToolArguments(args map[string]any, schema map[string]any) map[string]any {
+	if schema == nil {
+		return args
+	}
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return args
+	}
+	for key, val := range args {
+		propSchema, ok := props[key].(map[string]any)
+		if !ok {
+			continue
+		}
+		args[key] = coerceValue(val, propSchema)
+	}
+	return args
+}
+
+// coerceValue converts val toward the schema's declared type where the
+// conversion is lossless, returning val unchanged otherwise.
+func coerceValue(val any, schema map[string]any) any {
+	switch schemaType(schema) {
+	case "integer":
+		switch v := val.(type) {
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n
+			}
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v)
+			}
+		}
+	case "number":
+		if s, ok := val.(string); ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				return f
+			}
+		}
+	case "boolean":
+		if s, ok := val.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+	case "string":
+		switch v := val.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	case "object":
+		if m, ok := val.(map[string]any); ok {
+			return coerceToolArguments(m, schema)
+		}
+	case "array":
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return val
+		}
+		if list, ok := val.([]any); ok {
+			for i, item := range list {
+				list[i] = coerceValue(item, items)
+			}
+			return list
+		}
+	}
+	return val
+}
+
+// schemaType returns the declared type of a schema node, handling both the
+// single-string and type-list forms.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
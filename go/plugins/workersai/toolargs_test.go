@@ -0,0 +1,80 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoerceToolArguments(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"count":   map[string]any{"type": "integer"},
+			"ratio":   map[string]any{"type": "number"},
+			"enabled": map[string]any{"type": "boolean"},
+			"label":   map[string]any{"type": "string"},
+			"ids":     map[string]any{"type": "array", "items": map[string]any{"type": "integer"}},
+			"nested": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"depth": map[string]any{"type": "integer"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name string
+		args map[string]any
+		want map[string]any
+	}{
+		{
+			name: "stringly-typed values are converted",
+			args: map[string]any{"count": "2", "ratio": "0.5", "enabled": "true"},
+			want: map[string]any{"count": int64(2), "ratio": 0.5, "enabled": true},
+		},
+		{
+			name: "whole floats become integers where schema says integer",
+			args: map[string]any{"count": float64(3)},
+			want: map[string]any{"count": int64(3)},
+		},
+		{
+			name: "non-integral floats are left alone",
+			args: map[string]any{"count": 3.5},
+			want: map[string]any{"count": 3.5},
+		},
+		{
+			name: "numbers become strings where schema says string",
+			args: map[string]any{"label": float64(7)},
+			want: map[string]any{"label": "7"},
+		},
+		{
+			name: "arrays and nested objects coerce recursively",
+			args: map[string]any{
+				"ids":    []any{"1", float64(2)},
+				"nested": map[string]any{"depth": "4"},
+			},
+			want: map[string]any{
+				"ids":    []any{int64(1), int64(2)},
+				"nested": map[string]any{"depth": int64(4)},
+			},
+		},
+		{
+			name: "unknown keys pass through",
+			args: map[string]any{"other": "5"},
+			want: map[string]any{"other": "5"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, coerceToolArguments(tc.args, schema))
+		})
+	}
+
+	t.Run("nil schema passes arguments through", func(t *testing.T) {
+		args := map[string]any{"count": "2"}
+		assert.Equal(t, args, coerceToolArguments(args, nil))
+	})
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Some models return tool calls without IDs. Downstream those IDs are load
+// bearing: the next turn's tool responses reference them, and the API rejects
+// a ToolCallID it has never seen. synthesizeToolCallID fills the gap with an
+// ID derived from the call itself — its position in the turn, the tool name
+// and the raw arguments — so the same call always gets the same ID no matter
+// which process or retry computes it.
+func synthesizeToolCallID(index int, name, arguments string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s:%s", index, name, arguments)))
+	return "call_" + hex.EncodeToString(sum[:6])
+}
+
+// pendingToolRefs correlates tool responses with the assistant tool calls
+// that produced them when the Refs are missing on both sides. Refs are queued
+// per tool name in call order and consumed in the same order, which matches
+// how models pair responses to repeated calls of the same tool.
+type pendingToolRefs map[string][]string
+
+func (p pendingToolRefs) add(name, ref string) {
+	p[name] = append(p[name], ref)
+}
+
+func (p pendingToolRefs) take(name string) string {
+	refs := p[name]
+	if len(refs) == 0 {
+		return ""
+	}
+	p[name] = refs[1:]
+	return refs[0]
+}
@@ -0,0 +1,134 @@
+package workersai
+
+import (
+	"strings"
+	"testing"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSynthesizeToolCallID(t *testing.T) {
+	id := synthesizeToolCallID(0, "getWeather", `{"city":"Lisbon"}`)
+	assert.True(t, strings.HasPrefix(id, "call_"))
+
+	// Stable: the same call always gets the same ID.
+	assert.Equal(t, id, synthesizeToolCallID(0, "getWeather", `{"city":"Lisbon"}`))
+
+	// Distinct across position, name and arguments.
+	assert.NotEqual(t, id, synthesizeToolCallID(1, "getWeather", `{"city":"Lisbon"}`))
+	assert.NotEqual(t, id, synthesizeToolCallID(0, "getTime", `{"city":"Lisbon"}`))
+	assert.NotEqual(t, id, synthesizeToolCallID(0, "getWeather", `{"city":"Porto"}`))
+}
+
+func TestToGenkitToolRequestPartsSynthesizesMissingIDs(t *testing.T) {
+	calls := []client.ToolCall{
+		{
+			ID:   "",
+			Type: "function",
+			Function: client.FunctionToCall{
+				Name:      "getWeather",
+				Arguments: `{"city":"Lisbon"}`,
+			},
+		},
+		{
+			ID:   "call_abc",
+			Type: "function",
+			Function: client.FunctionToCall{
+				Name:      "getTime",
+				Arguments: `{}`,
+			},
+		},
+	}
+
+	parts, err := toGenkitToolRequestParts(calls, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, parts, 2)
+
+	assert.NotEmpty(t, parts[0].ToolRequest.Ref)
+	assert.Equal(t, "call_abc", parts[1].ToolRequest.Ref)
+
+	// The synthesized ref is deterministic across conversions.
+	again, err := toGenkitToolRequestParts(calls, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, parts[0].ToolRequest.Ref, again[0].ToolRequest.Ref)
+}
+
+func TestToClientMessagesCorrelatesReflessToolPairs(t *testing.T) {
+	msgs := []*ai.Message{
+		{
+			Role: ai.RoleModel,
+			Content: []*ai.Part{
+				ai.NewToolRequestPart(&ai.ToolRequest{
+					Name:  "getWeather",
+					Input: map[string]any{"city": "Lisbon"},
+				}),
+				ai.NewToolRequestPart(&ai.ToolRequest{
+					Name:  "getWeather",
+					Input: map[string]any{"city": "Porto"},
+				}),
+			},
+		},
+		{
+			Role: ai.RoleTool,
+			Content: []*ai.Part{
+				ai.NewToolResponsePart(&ai.ToolResponse{
+					Name:   "getWeather",
+					Output: map[string]any{"forecast": "sunny"},
+				}),
+				ai.NewToolResponsePart(&ai.ToolResponse{
+					Name:   "getWeather",
+					Output: map[string]any{"forecast": "rain"},
+				}),
+			},
+		},
+	}
+
+	got, err := toClientMessages(msgs, nil, nil, "")
+	require.NoError(t, err)
+	require.Len(t, got, 3)
+
+	assistant, ok := got[0].(client.ResponseMessage)
+	require.True(t, ok)
+	require.Len(t, assistant.ToolCalls, 2)
+	assert.NotEmpty(t, assistant.ToolCalls[0].ID)
+	assert.NotEmpty(t, assistant.ToolCalls[1].ID)
+	assert.NotEqual(t, assistant.ToolCalls[0].ID, assistant.ToolCalls[1].ID)
+
+	// Responses pair with calls in order, even without Refs on either side.
+	first, ok := got[1].(client.ToolMessage)
+	require.True(t, ok)
+	second, ok := got[2].(client.ToolMessage)
+	require.True(t, ok)
+	assert.Equal(t, assistant.ToolCalls[0].ID, first.ToolCallID)
+	assert.Equal(t, assistant.ToolCalls[1].ID, second.ToolCallID)
+}
+
+func TestToClientMessagesKeepsExplicitRefs(t *testing.T) {
+	msgs := []*ai.Message{
+		{
+			Role: ai.RoleModel,
+			Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+				Name:  "getWeather",
+				Ref:   "call_explicit",
+				Input: map[string]any{"city": "Lisbon"},
+			})},
+		},
+		{
+			Role: ai.RoleTool,
+			Content: []*ai.Part{ai.NewToolResponsePart(&ai.ToolResponse{
+				Name:   "getWeather",
+				Ref:    "call_explicit",
+				Output: map[string]any{"forecast": "sunny"},
+			})},
+		},
+	}
+
+	got, err := toClientMessages(msgs, nil, nil, "")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "call_explicit", got[0].(client.ResponseMessage).ToolCalls[0].ID)
+	assert.Equal(t, "call_explicit", got[1].(client.ToolMessage).ToolCallID)
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// toolCallLog tracks when each tool request left the plugin, keyed by call
+// ref, so the matching tool response can be logged with its round-trip
+// duration. Entries are removed as soon as the response is seen, so the map
+// only ever holds in-flight calls.
+type toolCallLog struct {
+	mu      sync.Mutex
+	started map[string]time.Time
+}
+
+// requestSent notes that a tool request with the given ref was handed to the
+// caller for execution.
+func (l *toolCallLog) requestSent(ref string) {
+	if ref == "" {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.started == nil {
+		l.started = map[string]time.Time{}
+	}
+	l.started[ref] = time.Now()
+}
+
+// responseSeen returns how long the call identified by ref was outstanding,
+// or zero when the request was not observed (for example after a restart).
+func (l *toolCallLog) responseSeen(ref string) time.Duration {
+	if ref == "" {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	start, ok := l.started[ref]
+	if !ok {
+		return 0
+	}
+	delete(l.started, ref)
+	return time.Since(start)
+}
+
+// logToolRequests records each outgoing tool request as a span event on the
+// model call, so traces show the complete tool-call timeline even when the
+// generate loop runs multiple turns.
+func (gen *generator) logToolRequests(ctx context.Context, parts []*ai.Part) {
+	span := trace.SpanFromContext(ctx)
+	for _, part := range parts {
+		if !part.IsToolRequest() {
+			continue
+		}
+		req := part.ToolRequest
+		if gen.plugin != nil {
+			gen.plugin.toolLog.requestSent(req.Ref)
+		}
+		span.AddEvent("workersai.tool.request", trace.WithAttributes(
+			attribute.String("tool.name", req.Name),
+			attribute.String("tool.ref", req.Ref),
+			attribute.String("tool.args", snippet(jsonString(req.Input))),
+		))
+	}
+}
+
+// logToolResponses records the tool responses the caller is replaying into
+// this model call: everything after the last model turn is new since the
+// previous call.
+func (gen *generator) logToolResponses(ctx context.Context, messages []*ai.Message) {
+	span := trace.SpanFromContext(ctx)
+	for _, msg := range trailingToolMessages(messages) {
+		for _, part := range msg.Content {
+			if !part.IsToolResponse() {
+				continue
+			}
+			resp := part.ToolResponse
+			attrs := []attribute.KeyValue{
+				attribute.String("tool.name", resp.Name),
+				attribute.String("tool.ref", resp.Ref),
+				attribute.String("tool.output", snippet(jsonString(resp.Output))),
+			}
+			if gen.plugin != nil {
+				if d := gen.plugin.toolLog.responseSeen(resp.Ref); d > 0 {
+					attrs = append(attrs, attribute.Int64("tool.durationMs", d.Milliseconds()))
+				}
+			}
+			span.AddEvent("workersai.tool.response", trace.WithAttributes(attrs...))
+		}
+	}
+}
+
+// trailingToolMessages returns the tool messages that follow the last model
+// turn, i.e. the responses produced since the previous generate call.
+func trailingToolMessages(messages []*ai.Message) []*ai.Message {
+	last := -1
+	for i, msg := range messages {
+		if msg.Role == ai.RoleModel {
+			last = i
+		}
+	}
+	var tools []*ai.Message
+	for _, msg := range messages[last+1:] {
+		if msg.Role == ai.RoleTool {
+			tools = append(tools, msg)
+		}
+	}
+	return tools
+}
+
+// jsonString renders a value compactly for span attributes.
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
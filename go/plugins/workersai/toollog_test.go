@@ -0,0 +1,44 @@
+package workersai
+
+import (
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolCallLogPairsRequestsWithResponses(t *testing.T) {
+	var l toolCallLog
+	l.requestSent("call_1")
+	time.Sleep(time.Millisecond)
+
+	assert.Greater(t, l.responseSeen("call_1"), time.Duration(0))
+	// A second response for the same ref no longer matches.
+	assert.Zero(t, l.responseSeen("call_1"))
+	// Unknown refs and empty refs are ignored.
+	assert.Zero(t, l.responseSeen("call_2"))
+	assert.Zero(t, l.responseSeen(""))
+}
+
+func TestTrailingToolMessages(t *testing.T) {
+	toolMsg := func(name string) *ai.Message {
+		return &ai.Message{
+			Role:    ai.RoleTool,
+			Content: []*ai.Part{ai.NewToolResponsePart(&ai.ToolResponse{Name: name})},
+		}
+	}
+	messages := []*ai.Message{
+		ai.NewUserTextMessage("hi"),
+		ai.NewModelTextMessage("calling a tool"),
+		toolMsg("old"),
+		ai.NewModelTextMessage("calling more tools"),
+		toolMsg("fresh1"),
+		toolMsg("fresh2"),
+	}
+
+	got := trailingToolMessages(messages)
+	assert.Len(t, got, 2)
+	assert.Equal(t, "fresh1", got[0].Content[0].ToolResponse.Name)
+	assert.Equal(t, "fresh2", got[1].Content[0].ToolResponse.Name)
+}
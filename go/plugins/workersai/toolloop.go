@@ -0,0 +1,158 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ToolFailurePolicy is what the tool-loop middleware does when the model
+// emits a tool call that doesn't match any declared tool.
+type ToolFailurePolicy string
+
+const (
+	// ToolFailureRetryWithHint re-prompts the model with a corrective hint
+	// listing the available tools. Retries happen within the current turn,
+	// so they don't burn the Generate loop's MaxTurns budget.
+	ToolFailureRetryWithHint ToolFailurePolicy = "retryWithHint"
+	// ToolFailureDrop strips the invalid tool calls from the response so the
+	// tool loop ends instead of erroring on an unknown tool.
+	ToolFailureDrop ToolFailurePolicy = "drop"
+	// ToolFailureAbort fails the request with a [*ToolLoopError].
+	ToolFailureAbort ToolFailurePolicy = "abort"
+)
+
+// ToolLoopError is the typed error returned under [ToolFailureAbort], so
+// callers can distinguish a misbehaving tool loop from transport failures.
+type ToolLoopError struct {
+	// Tool is the unknown tool name the model requested.
+	Tool string
+	// Attempts is how many model calls were made before giving up.
+	Attempts int
+}
+
+func (e *ToolLoopError) Error() string {
+	return fmt.Sprintf("workersai: model requested unknown tool %q after %d attempt(s)", e.Tool, e.Attempts)
+}
+
+// ToolFailureConfig configures [ToolLoopMiddleware].
+type ToolFailureConfig struct {
+	// Policy is applied when the model requests an unknown tool. The
+	// default is [ToolFailureRetryWithHint].
+	Policy ToolFailurePolicy
+	// MaxRetries caps in-turn retries under [ToolFailureRetryWithHint]; once
+	// exhausted the request fails with a [*ToolLoopError]. The default is 2.
+	MaxRetries int
+}
+
+// ToolLoopMiddleware returns a model middleware that applies a failure policy
+// when the model emits tool calls for tools that were never declared.
+// Without it, Genkit's tool loop surfaces an unknown-tool error (or burns
+// every turn up to MaxTurns) with no way to steer the model back on track.
+func ToolLoopMiddleware(cfg *ToolFailureConfig) ai.ModelMiddleware {
+	policy := ToolFailureRetryWithHint
+	maxRetries := 2
+	if cfg != nil {
+		if cfg.Policy != "" {
+			policy = cfg.Policy
+		}
+		if cfg.MaxRetries > 0 {
+			maxRetries = cfg.MaxRetries
+		}
+	}
+
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			known := map[string]bool{}
+			for _, tool := range input.Tools {
+				known[tool.Name] = true
+			}
+
+			attempts := 0
+			current := input
+			for {
+				attempts++
+				resp, err := next(ctx, current, cb)
+				if err != nil || resp == nil || resp.Message == nil {
+					return resp, err
+				}
+
+				unknown := unknownToolCalls(resp.Message, known)
+				if len(unknown) == 0 {
+					return resp, nil
+				}
+
+				switch policy {
+				case ToolFailureDrop:
+					dropToolCalls(resp.Message, known)
+					return resp, nil
+				case ToolFailureAbort:
+					return nil, &ToolLoopError{Tool: unknown[0], Attempts: attempts}
+				default: // ToolFailureRetryWithHint
+					if attempts > maxRetries {
+						return nil, &ToolLoopError{Tool: unknown[0], Attempts: attempts}
+					}
+					retry := *current
+					retry.Messages = append(append([]*ai.Message{}, current.Messages...),
+						resp.Message,
+						ai.NewUserTextMessage(toolHint(unknown, known)))
+					current = &retry
+				}
+			}
+		}
+	}
+}
+
+// unknownToolCalls returns the names of tool requests in msg that don't match
+// a declared tool.
+func unknownToolCalls(msg *ai.Message, known map[string]bool) []string {
+	var unknown []string
+	for _, part := range msg.Content {
+		if part.IsToolRequest() && !known[part.ToolRequest.Name] {
+			unknown = append(unknown, part.ToolRequest.Name)
+		}
+	}
+	return unknown
+}
+
+// dropToolCalls removes tool requests for undeclared tools from msg in place.
+func dropToolCalls(msg *ai.Message, known map[string]bool) {
+	kept := msg.Content[:0]
+	for _, part := range msg.Content {
+		if part.IsToolRequest() && !known[part.ToolRequest.Name] {
+			continue
+		}
+		kept = append(kept, part)
+	}
+	msg.Content = kept
+}
+
+// toolHint builds the corrective message sent back to the model on retry.
+func toolHint(unknown []string, known map[string]bool) string {
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("The tool %q does not exist and no tools are available. Answer directly without calling tools.", unknown[0])
+	}
+	return fmt.Sprintf("The tool %q does not exist. Only the following tools are available: %s. Call one of them or answer directly.",
+		unknown[0], strings.Join(names, ", "))
+}
@@ -0,0 +1,97 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolLoopMiddleware(t *testing.T) {
+	input := &ai.ModelRequest{
+		Tools:    []*ai.ToolDefinition{{Name: "lookup"}},
+		Messages: []*ai.Message{ai.NewUserTextMessage("hi")},
+	}
+	badCall := func() *ai.Message {
+		return &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{
+			ai.NewToolRequestPart(&ai.ToolRequest{Name: "nonexistent"}),
+		}}
+	}
+
+	t.Run("valid tool calls pass through", func(t *testing.T) {
+		calls := 0
+		next := func(ctx context.Context, in *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			calls++
+			return &ai.ModelResponse{Message: &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{
+				ai.NewToolRequestPart(&ai.ToolRequest{Name: "lookup"}),
+			}}}, nil
+		}
+
+		resp, err := ToolLoopMiddleware(nil)(next)(context.Background(), input, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, "lookup", resp.Message.Content[0].ToolRequest.Name)
+	})
+
+	t.Run("retry with hint recovers", func(t *testing.T) {
+		calls := 0
+		next := func(ctx context.Context, in *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			calls++
+			if calls == 1 {
+				return &ai.ModelResponse{Message: badCall()}, nil
+			}
+			// The retry carries the corrective hint.
+			hint := in.Messages[len(in.Messages)-1].Text()
+			assert.Contains(t, hint, "nonexistent")
+			assert.Contains(t, hint, "lookup")
+			return &ai.ModelResponse{Message: ai.NewModelTextMessage("done")}, nil
+		}
+
+		resp, err := ToolLoopMiddleware(nil)(next)(context.Background(), input, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, "done", resp.Message.Text())
+		// The caller's request is untouched.
+		assert.Len(t, input.Messages, 1)
+	})
+
+	t.Run("retry exhaustion returns typed error", func(t *testing.T) {
+		next := func(ctx context.Context, in *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			return &ai.ModelResponse{Message: badCall()}, nil
+		}
+
+		_, err := ToolLoopMiddleware(&ToolFailureConfig{MaxRetries: 1})(next)(context.Background(), input, nil)
+		var loopErr *ToolLoopError
+		require.ErrorAs(t, err, &loopErr)
+		assert.Equal(t, "nonexistent", loopErr.Tool)
+		assert.Equal(t, 2, loopErr.Attempts)
+	})
+
+	t.Run("drop strips invalid calls", func(t *testing.T) {
+		next := func(ctx context.Context, in *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			msg := badCall()
+			msg.Content = append(msg.Content, ai.NewTextPart("partial answer"))
+			return &ai.ModelResponse{Message: msg}, nil
+		}
+
+		resp, err := ToolLoopMiddleware(&ToolFailureConfig{Policy: ToolFailureDrop})(next)(context.Background(), input, nil)
+		require.NoError(t, err)
+		require.Len(t, resp.Message.Content, 1)
+		assert.Equal(t, "partial answer", resp.Message.Text())
+	})
+
+	t.Run("abort fails immediately", func(t *testing.T) {
+		calls := 0
+		next := func(ctx context.Context, in *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			calls++
+			return &ai.ModelResponse{Message: badCall()}, nil
+		}
+
+		_, err := ToolLoopMiddleware(&ToolFailureConfig{Policy: ToolFailureAbort})(next)(context.Background(), input, nil)
+		var loopErr *ToolLoopError
+		require.ErrorAs(t, err, &loopErr)
+		assert.Equal(t, 1, calls)
+	})
+}
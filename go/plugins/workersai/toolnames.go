@@ -0,0 +1,87 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// invalidToolNameChars matches characters Workers AI rejects in function
+// names. Genkit tool names sourced from MCP servers commonly contain slashes
+// or dots.
+var invalidToolNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// toolNameMap is a bidirectional mapping between Genkit tool names and the
+// sanitized names sent over the wire, so MCP and agent toolsets work
+// unchanged.
+type toolNameMap struct {
+	toWire   map[string]string
+	toGenkit map[string]string
+}
+
+// newToolNameMap builds the mapping for the request's declared tools,
+// de-duplicating sanitized names that collide.
+func newToolNameMap(defs []*ai.ToolDefinition) *toolNameMap {
+	m := &toolNameMap{
+		toWire:   make(map[string]string, len(defs)),
+		toGenkit: make(map[string]string, len(defs)),
+	}
+	for _, def := range defs {
+		wire := sanitizeToolName(def.Name)
+		if _, taken := m.toGenkit[wire]; taken {
+			for i := 2; ; i++ {
+				candidate := fmt.Sprintf("%s_%d", wire, i)
+				if _, taken := m.toGenkit[candidate]; !taken {
+					wire = candidate
+					break
+				}
+			}
+		}
+		m.toWire[def.Name] = wire
+		m.toGenkit[wire] = def.Name
+	}
+	return m
+}
+
+// wire returns the sanitized name to send for a Genkit tool name.
+func (m *toolNameMap) wire(name string) string {
+	if m == nil {
+		return name
+	}
+	if wire, ok := m.toWire[name]; ok {
+		return wire
+	}
+	return name
+}
+
+// genkit returns the Genkit tool name for a name received over the wire.
+func (m *toolNameMap) genkit(name string) string {
+	if m == nil {
+		return name
+	}
+	if original, ok := m.toGenkit[name]; ok {
+		return original
+	}
+	return name
+}
+
+// sanitizeToolName replaces characters Workers AI rejects with underscores.
+func sanitizeToolName(name string) string {
+	return invalidToolNameChars.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,40 @@
+package workersai
+
+import (
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolNameMap(t *testing.T) {
+	defs := []*ai.ToolDefinition{
+		{Name: "plain"},
+		{Name: "mcp/server.tool"},
+		{Name: "mcp/server_tool"}, // sanitizes to the same wire name
+	}
+	m := newToolNameMap(defs)
+
+	t.Run("plain names pass through", func(t *testing.T) {
+		assert.Equal(t, "plain", m.wire("plain"))
+		assert.Equal(t, "plain", m.genkit("plain"))
+	})
+
+	t.Run("invalid characters are sanitized and reversible", func(t *testing.T) {
+		wire := m.wire("mcp/server.tool")
+		assert.Equal(t, "mcp_server_tool", wire)
+		assert.Equal(t, "mcp/server.tool", m.genkit(wire))
+	})
+
+	t.Run("collisions are de-duplicated", func(t *testing.T) {
+		wire := m.wire("mcp/server_tool")
+		assert.Equal(t, "mcp_server_tool_2", wire)
+		assert.Equal(t, "mcp/server_tool", m.genkit(wire))
+	})
+
+	t.Run("nil map is a passthrough", func(t *testing.T) {
+		var nilMap *toolNameMap
+		assert.Equal(t, "a/b", nilMap.wire("a/b"))
+		assert.Equal(t, "a_b", nilMap.genkit("a_b"))
+	})
+}
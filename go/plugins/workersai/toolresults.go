@@ -0,0 +1,141 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// ToolResultConfig configures [ToolResultMiddleware].
+type ToolResultConfig struct {
+	// MaxResultChars is the serialized size above which a tool response is
+	// shrunk before it is fed back to the model. Defaults to 4000.
+	MaxResultChars int
+
+	// Summarizer, when set, condenses oversized tool responses with that
+	// model — typically a small, cheap Workers AI model — instead of
+	// truncating them. Summarization failures fall back to truncation.
+	Summarizer ai.Model
+}
+
+// toolResultSummaryPrompt instructs the summarizer model; the oversized tool
+// output follows as a user message.
+const toolResultSummaryPrompt = "The following is the oversized output of a " +
+	"tool call. Condense it to its essential content, keeping every fact, " +
+	"identifier, number and error message, so a model can act on it. Reply " +
+	"with only the condensed output."
+
+// ToolResultMiddleware returns a model middleware that bounds how much of
+// each tool response reaches the model. Tools that dump raw API responses or
+// scraped HTML can blow the context window on a single call; responses whose
+// serialized form exceeds the limit are either summarized by a configured
+// small model or truncated with a marker noting how much was cut. Responses
+// under the limit pass through untouched.
+func ToolResultMiddleware(cfg *ToolResultConfig) ai.ModelMiddleware {
+	maxChars := 4000
+	var summarizer ai.Model
+	if cfg != nil {
+		if cfg.MaxResultChars > 0 {
+			maxChars = cfg.MaxResultChars
+		}
+		summarizer = cfg.Summarizer
+	}
+
+	return func(next ai.ModelFunc) ai.ModelFunc {
+		return func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+			messages, changed := shrinkToolResults(ctx, input.Messages, maxChars, summarizer)
+			if !changed {
+				return next(ctx, input, cb)
+			}
+			shrunk := *input
+			shrunk.Messages = messages
+			return next(ctx, &shrunk, cb)
+		}
+	}
+}
+
+// shrinkToolResults returns the messages with every oversized tool response
+// replaced by its shrunk form, copying only what it modifies. The second
+// return value reports whether anything was shrunk.
+func shrinkToolResults(ctx context.Context, messages []*ai.Message, maxChars int, summarizer ai.Model) ([]*ai.Message, bool) {
+	out := messages
+	changed := false
+	for i, msg := range messages {
+		if msg.Role != ai.RoleTool {
+			continue
+		}
+		for j, part := range msg.Content {
+			if !part.IsToolResponse() {
+				continue
+			}
+			serialized, err := json.Marshal(part.ToolResponse.Output)
+			if err != nil || len(serialized) <= maxChars {
+				continue
+			}
+			if !changed {
+				out = append([]*ai.Message(nil), messages...)
+				changed = true
+			}
+			shrunk := *msg
+			shrunk.Content = append([]*ai.Part(nil), msg.Content...)
+			shrunk.Content[j] = ai.NewToolResponsePart(&ai.ToolResponse{
+				Name:   part.ToolResponse.Name,
+				Ref:    part.ToolResponse.Ref,
+				Output: shrinkToolResult(ctx, string(serialized), maxChars, summarizer),
+			})
+			out[i] = &shrunk
+			msg = &shrunk
+		}
+	}
+	return out, changed
+}
+
+// shrinkToolResult reduces one oversized serialized tool output to fit the
+// limit, preferring summarization when a summarizer is available. A failed or
+// still-oversized summary falls back to truncation, so the middleware never
+// passes the original through uncut.
+func shrinkToolResult(ctx context.Context, serialized string, maxChars int, summarizer ai.Model) string {
+	if summarizer != nil {
+		resp, err := summarizer.Generate(ctx, &ai.ModelRequest{
+			Messages: []*ai.Message{
+				ai.NewSystemTextMessage(toolResultSummaryPrompt),
+				ai.NewUserTextMessage(serialized),
+			},
+		}, nil)
+		if err == nil && resp != nil && resp.Message != nil {
+			if summary := resp.Message.Text(); summary != "" && len(summary) <= maxChars {
+				return fmt.Sprintf("[summarized from %d bytes of tool output] %s", len(serialized), summary)
+			}
+		}
+	}
+	return truncateToolResult(serialized, maxChars)
+}
+
+// truncateToolResult cuts the serialized output at the limit and appends a
+// marker so the model knows it is looking at a prefix, not the whole result.
+func truncateToolResult(serialized string, maxChars int) string {
+	cut := maxChars
+	// Don't split a UTF-8 sequence mid-rune.
+	for cut > 0 && !utf8.RuneStart(serialized[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s… [truncated %d of %d bytes]", serialized[:cut], len(serialized)-cut, len(serialized))
+}
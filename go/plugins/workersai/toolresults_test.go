@@ -0,0 +1,100 @@
+package workersai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolTranscript is a conversation whose tool turn carries the given output.
+func toolTranscript(output any) []*ai.Message {
+	return []*ai.Message{
+		ai.NewUserTextMessage("look it up"),
+		{
+			Role: ai.RoleModel,
+			Content: []*ai.Part{ai.NewToolRequestPart(&ai.ToolRequest{
+				Name: "fetch", Ref: "call_1", Input: map[string]any{},
+			})},
+		},
+		{
+			Role: ai.RoleTool,
+			Content: []*ai.Part{ai.NewToolResponsePart(&ai.ToolResponse{
+				Name: "fetch", Ref: "call_1", Output: output,
+			})},
+		},
+	}
+}
+
+func capturedToolOutput(m *fakeModel) any {
+	return m.requests[0].Messages[2].Content[0].ToolResponse.Output
+}
+
+func TestToolResultMiddleware(t *testing.T) {
+	run := func(t *testing.T, cfg *ToolResultConfig, output any) (*fakeModel, []*ai.Message) {
+		t.Helper()
+		model := &fakeModel{name: "workersai/main", response: "ok"}
+		messages := toolTranscript(output)
+		mw := ToolResultMiddleware(cfg)
+		_, err := mw(model.Generate)(context.Background(), &ai.ModelRequest{Messages: messages}, nil)
+		require.NoError(t, err)
+		require.Len(t, model.requests, 1)
+		return model, messages
+	}
+
+	t.Run("passes small results through untouched", func(t *testing.T) {
+		model, messages := run(t, &ToolResultConfig{MaxResultChars: 100}, map[string]any{"status": "ok"})
+		// The request was not even copied.
+		assert.Equal(t, messages[2], model.requests[0].Messages[2])
+	})
+
+	t.Run("truncates oversized results", func(t *testing.T) {
+		model, messages := run(t, &ToolResultConfig{MaxResultChars: 50},
+			map[string]any{"body": strings.Repeat("x", 500)})
+
+		output, ok := capturedToolOutput(model).(string)
+		require.True(t, ok)
+		assert.Contains(t, output, "truncated")
+		assert.Less(t, len(output), 120)
+
+		// The caller's messages were not mutated.
+		original := messages[2].Content[0].ToolResponse.Output.(map[string]any)
+		assert.Len(t, original["body"], 500)
+	})
+
+	t.Run("summarizes with the configured model", func(t *testing.T) {
+		summarizer := &fakeModel{name: "workersai/small", response: "500 x's"}
+		model, _ := run(t, &ToolResultConfig{MaxResultChars: 50, Summarizer: summarizer},
+			map[string]any{"body": strings.Repeat("x", 500)})
+
+		output, ok := capturedToolOutput(model).(string)
+		require.True(t, ok)
+		assert.Contains(t, output, "summarized from")
+		assert.Contains(t, output, "500 x's")
+
+		// The summarizer saw the serialized output.
+		require.Len(t, summarizer.requests, 1)
+		assert.Contains(t, summarizer.requests[0].Messages[1].Text(), "xxx")
+	})
+
+	t.Run("falls back to truncation when the summary is still too big", func(t *testing.T) {
+		summarizer := &fakeModel{name: "workersai/small", response: strings.Repeat("y", 200)}
+		model, _ := run(t, &ToolResultConfig{MaxResultChars: 50, Summarizer: summarizer},
+			map[string]any{"body": strings.Repeat("x", 500)})
+
+		output := capturedToolOutput(model).(string)
+		assert.Contains(t, output, "truncated")
+	})
+}
+
+func TestTruncateToolResult(t *testing.T) {
+	// Multi-byte runes are never split.
+	got := truncateToolResult("héllo wörld", 3)
+	assert.True(t, strings.HasPrefix(got, "hé…") || strings.HasPrefix(got, "h…"))
+	for _, r := range got {
+		assert.NotEqual(t, '�', r)
+	}
+}
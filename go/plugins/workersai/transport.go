@@ -0,0 +1,270 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+)
+
+// TransportConfig tunes the HTTP transport shared by all plugin requests.
+// The zero value keeps the defaults of http.DefaultTransport.
+type TransportConfig struct {
+	// MaxIdleConns caps the size of the idle connection pool.
+	MaxIdleConns int
+	// IdleConnTimeout is how long an idle connection is kept in the pool
+	// before being closed.
+	IdleConnTimeout time.Duration
+	// DisableHTTP2 forces HTTP/1.1 for deployments where intermediaries
+	// mishandle HTTP/2.
+	DisableHTTP2 bool
+	// KeepAliveInterval, when positive, sends a lightweight request to the
+	// API host on that interval so the first real request after an idle
+	// period doesn't pay TLS+TCP setup latency.
+	KeepAliveInterval time.Duration
+}
+
+// applyTransportConfig copies the configured tuning onto the transport.
+func applyTransportConfig(t *http.Transport, cfg *TransportConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.MaxIdleConns > 0 {
+		t.MaxIdleConns = cfg.MaxIdleConns
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConns
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.DisableHTTP2 {
+		t.ForceAttemptHTTP2 = false
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+}
+
+// paramsTransport merges extra generation parameters into the JSON body of
+// the outgoing request. The vendored client only serializes model, messages
+// and tools, so typed config fields are injected here. params fill in keys
+// the client did not set; overrides replace keys it did (for example
+// rewriting "model" to a gateway dynamic route).
+type paramsTransport struct {
+	base      http.RoundTripper
+	params    map[string]any
+	overrides map[string]any
+}
+
+func (t *paramsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if (len(t.params) == 0 && len(t.overrides) == 0) || req.Body == nil {
+		return base.RoundTrip(req)
+	}
+
+	orig, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(orig, &body); err != nil {
+		return nil, fmt.Errorf("failed to decode request body for parameter injection: %w", err)
+	}
+	for k, v := range t.params {
+		if _, exists := body[k]; !exists {
+			body[k] = v
+		}
+	}
+	for k, v := range t.overrides {
+		body[k] = v
+	}
+	merged, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-encode request body: %w", err)
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(merged))
+	req.ContentLength = int64(len(merged))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(merged)), nil
+	}
+	return base.RoundTrip(req)
+}
+
+// captureTransport records the headers of the most recent response so
+// gateway state (such as cache HIT/MISS) can be surfaced in response
+// metadata. It is used on per-request client copies only.
+type captureTransport struct {
+	base http.RoundTripper
+
+	mu     sync.Mutex
+	header http.Header
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	t.mu.Lock()
+	t.header = resp.Header.Clone()
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// lastHeader returns a header value from the most recent response.
+func (t *captureTransport) lastHeader(name string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.header == nil {
+		return ""
+	}
+	return t.header.Get(name)
+}
+
+// contextTransport reattaches the caller's context to outgoing requests. The
+// vendored client builds requests on context.Background, so without this layer
+// cancelling the Generate context would not abort the in-flight connection.
+type contextTransport struct {
+	base http.RoundTripper
+	ctx  context.Context
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req.Clone(t.ctx))
+}
+
+// clientFor returns a client to use for a single request. When the request
+// needs extra headers or body parameters, a shallow copy of the shared client
+// is returned with the request-specific layers stacked on top, leaving the
+// shared client untouched. The caller's context is attached so cancellation
+// closes the connection immediately.
+func (gen *generator) clientFor(ctx context.Context, headers map[string]string, params, overrides map[string]any, maxResponseBytes int) (*client.Client, *captureTransport) {
+	c := *gen.client
+	httpClient := *c.HTTPClient
+	transport := httpClient.Transport
+
+	if maxResponseBytes > 0 {
+		transport = &sizeLimitTransport{base: transport, limit: int64(maxResponseBytes)}
+	}
+	if len(params) > 0 || len(overrides) > 0 {
+		transport = &paramsTransport{base: transport, params: params, overrides: overrides}
+	}
+	if len(headers) > 0 {
+		transport = &headerTransport{base: transport, headers: headers}
+	}
+	capture := &captureTransport{base: transport}
+	httpClient.Transport = &contextTransport{base: capture, ctx: ctx}
+	c.HTTPClient = &httpClient
+	return &c, capture
+}
+
+// newBaseTransport returns the transport that all plugin requests go through.
+// It honors the standard HTTPS_PROXY/NO_PROXY environment variables by
+// default; an explicit ProxyURL on the plugin takes precedence for
+// deployments where all egress must traverse a corporate proxy.
+func (w *WorkersAI) newBaseTransport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if w.ProxyURL != "" {
+		proxyURL, err := url.Parse(w.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL %q: %w", w.ProxyURL, err)
+		}
+		t.Proxy = http.ProxyURL(proxyURL)
+	}
+	applyTransportConfig(t, w.Transport)
+	return t, nil
+}
+
+// startKeepAlive launches a background pinger that keeps the connection pool
+// warm by periodically issuing a cheap request to the API host.
+func (w *WorkersAI) startKeepAlive(interval time.Duration) {
+	w.keepAliveStop = make(chan struct{})
+	httpClient := w.client.HTTPClient
+	baseURL := w.client.BaseURL
+	stop := w.keepAliveStop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				pingHost(httpClient, baseURL)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// pingHost issues a HEAD request to the API host, ignoring the result; its
+// only purpose is to keep a TLS connection established in the pool.
+func pingHost(httpClient *http.Client, baseURL string) {
+	req, err := http.NewRequest(http.MethodHead, baseURL, nil)
+	if err != nil {
+		return
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// headerTransport is an http.RoundTripper that adds a fixed set of headers to
+// every outgoing request. The vendored client offers no header hooks, so
+// cross-cutting headers (such as Cloudflare Access service tokens) are
+// injected at the transport layer instead.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Per the RoundTripper contract the request must not be mutated, so
+	// clone it before setting headers.
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,170 @@
+package workersai
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRoundTripper records the requests it sees and returns a canned response.
+type fakeRoundTripper struct {
+	requests []*http.Request
+	status   int
+	body     string
+	header   http.Header
+}
+
+func (f *fakeRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, req)
+	status := f.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := f.header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(f.body)),
+		Request:    req,
+	}, nil
+}
+
+type fakeTokenSource struct {
+	tokens []*Token
+	calls  int
+	err    error
+}
+
+func (f *fakeTokenSource) Token() (*Token, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	token := f.tokens[f.calls%len(f.tokens)]
+	f.calls++
+	return token, nil
+}
+
+func TestHeaderTransport(t *testing.T) {
+	base := &fakeRoundTripper{}
+	tr := &headerTransport{
+		base: base,
+		headers: map[string]string{
+			"CF-Access-Client-Id":     "id",
+			"CF-Access-Client-Secret": "secret",
+		},
+	}
+
+	req, err := http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Len(t, base.requests, 1)
+	assert.Equal(t, "id", base.requests[0].Header.Get("CF-Access-Client-Id"))
+	assert.Equal(t, "secret", base.requests[0].Header.Get("CF-Access-Client-Secret"))
+	// The original request must not be mutated.
+	assert.Empty(t, req.Header.Get("CF-Access-Client-Id"))
+}
+
+func TestTokenTransportCachesToken(t *testing.T) {
+	base := &fakeRoundTripper{}
+	source := &fakeTokenSource{tokens: []*Token{{Value: "tok-1", Expiry: time.Now().Add(time.Hour)}}}
+	tr := &tokenTransport{base: base, source: source}
+
+	for range 3 {
+		req, err := http.NewRequest("POST", "https://example.com", nil)
+		require.NoError(t, err)
+		resp, err := tr.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, 1, source.calls, "unexpired token should be served from cache")
+	require.Len(t, base.requests, 3)
+	assert.Equal(t, "Bearer tok-1", base.requests[0].Header.Get("Authorization"))
+}
+
+func TestTokenTransportRefreshesExpiredToken(t *testing.T) {
+	base := &fakeRoundTripper{}
+	source := &fakeTokenSource{tokens: []*Token{
+		{Value: "tok-1", Expiry: time.Now().Add(-time.Minute)},
+		{Value: "tok-2", Expiry: time.Now().Add(time.Hour)},
+	}}
+	tr := &tokenTransport{base: base, source: source}
+
+	for range 2 {
+		req, err := http.NewRequest("POST", "https://example.com", nil)
+		require.NoError(t, err)
+		resp, err := tr.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, 2, source.calls, "expired token should trigger a refresh")
+	assert.Equal(t, "Bearer tok-2", base.requests[1].Header.Get("Authorization"))
+}
+
+func TestParamsTransportMergesAndOverrides(t *testing.T) {
+	base := &fakeRoundTripper{}
+	tr := &paramsTransport{
+		base:      base,
+		params:    map[string]any{"temperature": 0.2, "model": "ignored"},
+		overrides: map[string]any{"model": "dynamic/my-route"},
+	}
+
+	req, err := http.NewRequest("POST", "https://example.com",
+		strings.NewReader(`{"model":"@cf/meta/llama-3.1-8b-instruct","messages":[]}`))
+	require.NoError(t, err)
+
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Len(t, base.requests, 1)
+	sent, err := io.ReadAll(base.requests[0].Body)
+	require.NoError(t, err)
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(sent, &body))
+	// params only fill in missing keys; overrides always win.
+	assert.Equal(t, 0.2, body["temperature"])
+	assert.Equal(t, "dynamic/my-route", body["model"])
+}
+
+func TestUserAgentTransport(t *testing.T) {
+	base := &fakeRoundTripper{}
+	tr := &userAgentTransport{base: base, userAgent: userAgent("my-app/2.3")}
+
+	req, err := http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+
+	resp, err := tr.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Len(t, base.requests, 1)
+	ua := base.requests[0].Header.Get("User-Agent")
+	assert.Contains(t, ua, "genkit-go/")
+	assert.Contains(t, ua, "workersai/"+pluginVersion)
+	assert.True(t, strings.HasSuffix(ua, " my-app/2.3"))
+
+	// An explicit User-Agent is left alone.
+	req, err = http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "custom/1.0")
+	resp, err = tr.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "custom/1.0", base.requests[1].Header.Get("User-Agent"))
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// UnknownContentPolicy decides what happens to message content the wire
+// format cannot carry — data parts, custom parts, reasoning parts replayed
+// into history, and messages with roles the API does not know. Without a
+// policy such content used to vanish silently.
+type UnknownContentPolicy string
+
+const (
+	// UnknownContentWarn skips the content and logs a warning. The
+	// default.
+	UnknownContentWarn UnknownContentPolicy = "warn"
+	// UnknownContentError fails the request, so lost content is caught in
+	// development.
+	UnknownContentError UnknownContentPolicy = "error"
+	// UnknownContentStringify renders the content as text and sends it
+	// anyway, trading fidelity for completeness.
+	UnknownContentStringify UnknownContentPolicy = "stringify"
+)
+
+// handleUnknownPart applies the policy to one unsupported part. It returns
+// the text to send in the part's place ("" for skip) and, under
+// [UnknownContentError], an error describing what would have been lost.
+func handleUnknownPart(policy UnknownContentPolicy, role ai.Role, p *ai.Part) (string, error) {
+	kind := partKindName(p)
+	switch policy {
+	case UnknownContentError:
+		return "", fmt.Errorf("message with role %q carries a %s part the model cannot receive; remove it or relax the unknown-content policy", role, kind)
+	case UnknownContentStringify:
+		return stringifyPart(p), nil
+	default:
+		slog.Warn("workersai: dropping message part the wire format cannot carry",
+			"role", role, "kind", kind)
+		return "", nil
+	}
+}
+
+// partKindName names a part's kind for logs and error messages.
+func partKindName(p *ai.Part) string {
+	switch {
+	case p.IsData():
+		return "data"
+	case p.IsCustom():
+		return "custom"
+	case p.IsReasoning():
+		return "reasoning"
+	case p.IsMedia():
+		return "media"
+	case p.IsToolRequest():
+		return "tool request"
+	case p.IsToolResponse():
+		return "tool response"
+	default:
+		return "unknown"
+	}
+}
+
+// stringifyPart renders an unsupported part as plain text: data parts keep
+// their raw payload, custom parts are marshaled to JSON, and anything else
+// falls back to its text field.
+func stringifyPart(p *ai.Part) string {
+	switch {
+	case p.IsCustom():
+		b, err := json.Marshal(p.Custom)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	default:
+		return p.Text
+	}
+}
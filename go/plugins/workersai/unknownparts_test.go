@@ -0,0 +1,79 @@
+package workersai
+
+import (
+	"testing"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnknownContentPolicy(t *testing.T) {
+	dataMsg := []*ai.Message{{
+		Role: ai.RoleUser,
+		Content: []*ai.Part{
+			ai.NewTextPart("context:"),
+			ai.NewDataPart(`{"rows": 3}`),
+		},
+	}}
+
+	t.Run("default warns and skips", func(t *testing.T) {
+		got, err := toClientMessages(dataMsg, nil, nil, "")
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "context:", got[0].(client.ChatMessage).Content)
+	})
+
+	t.Run("error policy fails the request", func(t *testing.T) {
+		_, err := toClientMessages(dataMsg, nil, nil, UnknownContentError)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "data part")
+	})
+
+	t.Run("stringify keeps the payload in order", func(t *testing.T) {
+		got, err := toClientMessages(dataMsg, nil, nil, UnknownContentStringify)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, "context:\n{\"rows\": 3}", got[0].(client.ChatMessage).Content)
+	})
+
+	t.Run("custom parts are stringified as JSON", func(t *testing.T) {
+		msgs := []*ai.Message{{
+			Role:    ai.RoleUser,
+			Content: []*ai.Part{ai.NewCustomPart(map[string]any{"kind": "widget"})},
+		}}
+		got, err := toClientMessages(msgs, nil, nil, UnknownContentStringify)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.JSONEq(t, `{"kind":"widget"}`, got[0].(client.ChatMessage).Content)
+	})
+}
+
+func TestUnknownRolePolicy(t *testing.T) {
+	msgs := []*ai.Message{{
+		Role:    ai.Role("critic"),
+		Content: []*ai.Part{ai.NewTextPart("needs more cowbell")},
+	}}
+
+	t.Run("default warns and skips", func(t *testing.T) {
+		got, err := toClientMessages(msgs, nil, nil, "")
+		require.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run("error policy fails the request", func(t *testing.T) {
+		_, err := toClientMessages(msgs, nil, nil, UnknownContentError)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `"critic"`)
+	})
+
+	t.Run("stringify sends the text as a user message", func(t *testing.T) {
+		got, err := toClientMessages(msgs, nil, nil, UnknownContentStringify)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		msg := got[0].(client.ChatMessage)
+		assert.Equal(t, "user", msg.Role)
+		assert.Equal(t, "needs more cowbell", msg.Content)
+	})
+}
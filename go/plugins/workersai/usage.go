@@ -0,0 +1,136 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AccountUsage reports Workers AI consumption for an account over a window,
+// as returned by Cloudflare's account analytics. Neurons are Cloudflare's
+// billing unit for Workers AI inference.
+type AccountUsage struct {
+	// Neurons is the total neuron consumption in the window.
+	Neurons float64 `json:"neurons"`
+	// Requests is the number of inference requests in the window.
+	Requests int `json:"requests"`
+	// Start and End bound the window the numbers cover.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// usageQuery is the GraphQL query against Cloudflare's analytics API for
+// Workers AI inference consumption.
+const usageQuery = `query($accountTag: String!, $start: Time!, $end: Time!) {
+  viewer {
+    accounts(filter: {accountTag: $accountTag}) {
+      aiInferenceAdaptiveGroups(filter: {datetime_geq: $start, datetime_leq: $end}, limit: 1000) {
+        count
+        sum {
+          neurons
+        }
+      }
+    }
+  }
+}`
+
+// Usage queries Cloudflare's account analytics for Workers AI neuron
+// consumption between start and end, so operators can expose remaining
+// free-tier quota in dashboards and alerts.
+func (w *WorkersAI) Usage(ctx context.Context, start, end time.Time) (*AccountUsage, error) {
+	w.mu.Lock()
+	if !w.initted {
+		w.mu.Unlock()
+		return nil, errors.New("workersai plugin not initialized")
+	}
+	c := w.client
+	w.mu.Unlock()
+
+	payload := map[string]any{
+		"query": usageQuery,
+		"variables": map[string]any{
+			"accountTag": c.AccountID,
+			"start":      start.UTC().Format(time.RFC3339),
+			"end":        end.UTC().Format(time.RFC3339),
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal usage query")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/graphql", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create usage request")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "usage query failed")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read usage response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("usage query returned status %d: %s", resp.StatusCode, respBody)
+	}
+
+	var parsed struct {
+		Data struct {
+			Viewer struct {
+				Accounts []struct {
+					Groups []struct {
+						Count int `json:"count"`
+						Sum   struct {
+							Neurons float64 `json:"neurons"`
+						} `json:"sum"`
+					} `json:"aiInferenceAdaptiveGroups"`
+				} `json:"accounts"`
+			} `json:"viewer"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(respBody, &parsed); err != nil {
+		return nil, errors.Wrap(err, "failed to parse usage response")
+	}
+	if len(parsed.Errors) > 0 {
+		return nil, fmt.Errorf("usage query returned an error: %s", parsed.Errors[0].Message)
+	}
+
+	usage := &AccountUsage{Start: start, End: end}
+	for _, account := range parsed.Data.Viewer.Accounts {
+		for _, group := range account.Groups {
+			usage.Neurons += group.Sum.Neurons
+			usage.Requests += group.Count
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,93 @@
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageRequiresInit(t *testing.T) {
+	w := &WorkersAI{}
+	_, err := w.Usage(context.Background(), time.Now().Add(-time.Hour), time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not initialized")
+}
+
+func TestUsage(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, usageQuery, body.Query)
+		assert.Equal(t, "acct", body.Variables["accountTag"])
+		assert.Equal(t, start.Format(time.RFC3339), body.Variables["start"])
+		assert.Equal(t, end.Format(time.RFC3339), body.Variables["end"])
+
+		rw.Write([]byte(`{"data":{"viewer":{"accounts":[
+			{"aiInferenceAdaptiveGroups":[
+				{"count":3,"sum":{"neurons":12.5}},
+				{"count":1,"sum":{"neurons":2.5}}
+			]},
+			{"aiInferenceAdaptiveGroups":[
+				{"count":2,"sum":{"neurons":5}}
+			]}
+		]}}}`))
+	}))
+	defer srv.Close()
+
+	w := &WorkersAI{initted: true}
+	w.client = client.NewClient("acct", "tok")
+	w.client.BaseURL = srv.URL
+
+	usage, err := w.Usage(context.Background(), start, end)
+	require.NoError(t, err)
+	// Groups are summed across all returned accounts.
+	assert.Equal(t, 20.0, usage.Neurons)
+	assert.Equal(t, 6, usage.Requests)
+	assert.Equal(t, start, usage.Start)
+	assert.Equal(t, end, usage.End)
+}
+
+func TestUsageGraphQLError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(`{"errors":[{"message":"quota exceeded"}]}`))
+	}))
+	defer srv.Close()
+
+	w := &WorkersAI{initted: true}
+	w.client = client.NewClient("acct", "tok")
+	w.client.BaseURL = srv.URL
+
+	_, err := w.Usage(context.Background(), time.Now().Add(-time.Hour), time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "quota exceeded")
+}
+
+func TestUsageHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	w := &WorkersAI{initted: true}
+	w.client = client.NewClient("acct", "tok")
+	w.client.BaseURL = srv.URL
+
+	_, err := w.Usage(context.Background(), time.Now().Add(-time.Hour), time.Now())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 502")
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"net/http"
+
+	"github.com/firebase/genkit/go/internal"
+)
+
+// pluginVersion identifies this plugin in the User-Agent, independent of the
+// Genkit release it ships with.
+const pluginVersion = "1.0.0"
+
+// userAgent returns the User-Agent sent on every request:
+// "genkit-go/<version> workersai/<version>", optionally followed by an
+// application identifier, so support tickets and gateway logs can be
+// correlated to specific deployments.
+func userAgent(appID string) string {
+	ua := "genkit-go/" + internal.Version + " workersai/" + pluginVersion
+	if appID != "" {
+		ua += " " + appID
+	}
+	return ua
+}
+
+// userAgentTransport stamps the User-Agent onto every outgoing request,
+// without clobbering one a caller set explicitly.
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// verifyCredentials checks at startup that the configured token is usable:
+// first that Cloudflare considers it active at all, then that it can reach
+// the account's Workers AI endpoints. Each failure mode gets its own
+// actionable error, so a misconfigured deployment fails at Init instead of
+// on the first Generate with an opaque 403.
+func (w *WorkersAI) verifyCredentials(ctx context.Context) error {
+	status, err := w.tokenStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if status != "active" {
+		return errors.Errorf("credential check: API token is %s; issue a new token with the Account / Workers AI Read and Edit permissions", status)
+	}
+
+	// An active token may still be scoped to other products or accounts;
+	// probe the cheapest Workers AI read endpoint to find out now.
+	resp, err := w.verifyRequest(ctx, fmt.Sprintf("/accounts/%s/ai/models/search?per_page=1", w.client.AccountID))
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return errors.New("credential check: API token lacks the Account / Workers AI scope for this account; edit the token's permissions in the Cloudflare dashboard")
+	case http.StatusNotFound:
+		return errors.Errorf("credential check: account %q was not found; check CLOUDFLARE_ACCOUNT_ID", w.client.AccountID)
+	default:
+		return errors.Errorf("credential check: Workers AI probe returned unexpected status %d", resp.StatusCode)
+	}
+}
+
+// tokenStatus asks the token-verify endpoint about the configured token and
+// returns its reported status ("active", "expired", "disabled").
+func (w *WorkersAI) tokenStatus(ctx context.Context) (string, error) {
+	resp, err := w.verifyRequest(ctx, "/user/tokens/verify")
+	if err != nil {
+		return "", err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return "", err
+	}
+
+	var envelope struct {
+		Success bool `json:"success"`
+		Result  struct {
+			Status string `json:"status"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return "", errors.Wrap(err, "credential check: failed to parse token-verify response")
+	}
+	if !envelope.Success {
+		return "", errors.Errorf("credential check: API token is invalid (token-verify returned status %d); check CLOUDFLARE_API_TOKEN", resp.StatusCode)
+	}
+	return envelope.Result.Status, nil
+}
+
+// verifyRequest issues one GET against the configured API base through the
+// plugin's transport stack, so proxies, Access headers and token sources all
+// apply to the check exactly as they will to real requests.
+func (w *WorkersAI) verifyRequest(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.client.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if w.client.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.client.APIToken)
+	}
+	resp, err := w.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "credential check failed")
+	}
+	return resp, nil
+}
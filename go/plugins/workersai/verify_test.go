@@ -0,0 +1,80 @@
+package workersai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyCredentials(t *testing.T) {
+	newPlugin := func(handler http.HandlerFunc) (*WorkersAI, *httptest.Server) {
+		srv := httptest.NewServer(handler)
+		t.Cleanup(srv.Close)
+		w := &WorkersAI{}
+		w.client = client.NewClient("acct", "tok")
+		w.client.BaseURL = srv.URL
+		return w, srv
+	}
+
+	t.Run("active token with Workers AI access passes", func(t *testing.T) {
+		w, _ := newPlugin(func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+			switch r.URL.Path {
+			case "/user/tokens/verify":
+				rw.Write([]byte(`{"success":true,"result":{"status":"active"}}`))
+			case "/accounts/acct/ai/models/search":
+				rw.Write([]byte(`{"success":true,"result":[]}`))
+			default:
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+		})
+		require.NoError(t, w.verifyCredentials(context.Background()))
+	})
+
+	t.Run("expired token is reported as such", func(t *testing.T) {
+		w, _ := newPlugin(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Write([]byte(`{"success":true,"result":{"status":"expired"}}`))
+		})
+		err := w.verifyCredentials(context.Background())
+		require.ErrorContains(t, err, "API token is expired")
+	})
+
+	t.Run("invalid token is reported as such", func(t *testing.T) {
+		w, _ := newPlugin(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusUnauthorized)
+			rw.Write([]byte(`{"success":false,"errors":[{"code":1000}]}`))
+		})
+		err := w.verifyCredentials(context.Background())
+		require.ErrorContains(t, err, "API token is invalid")
+		assert.ErrorContains(t, err, "CLOUDFLARE_API_TOKEN")
+	})
+
+	t.Run("active token without the Workers AI scope names the scope", func(t *testing.T) {
+		w, _ := newPlugin(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/user/tokens/verify" {
+				rw.Write([]byte(`{"success":true,"result":{"status":"active"}}`))
+				return
+			}
+			rw.WriteHeader(http.StatusForbidden)
+		})
+		err := w.verifyCredentials(context.Background())
+		require.ErrorContains(t, err, "lacks the Account / Workers AI scope")
+	})
+
+	t.Run("wrong account ID is called out", func(t *testing.T) {
+		w, _ := newPlugin(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/user/tokens/verify" {
+				rw.Write([]byte(`{"success":true,"result":{"status":"active"}}`))
+				return
+			}
+			rw.WriteHeader(http.StatusNotFound)
+		})
+		err := w.verifyCredentials(context.Background())
+		require.ErrorContains(t, err, "CLOUDFLARE_ACCOUNT_ID")
+	})
+}
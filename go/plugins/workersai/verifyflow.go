@@ -0,0 +1,157 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+package workersai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/pkg/errors"
+)
+
+// ChainOfVerificationOptions configures [ChainOfVerification].
+type ChainOfVerificationOptions struct {
+	// Model drafts and revises the answer. Required.
+	Model ai.Model
+
+	// Verifier generates and answers the verification questions. Nil uses
+	// Model; a larger verifier checking a smaller drafting model is the
+	// cheap way to spend the extra calls.
+	Verifier ai.Model
+
+	// MaxQuestions bounds how many verification questions are asked. The
+	// default is 5.
+	MaxQuestions int
+
+	// Config is passed through as every request's config, for example a
+	// [Config].
+	Config any
+}
+
+// Verification is one verification question and its independent answer.
+type Verification struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+}
+
+// VerifiedAnswer is the outcome of a chain-of-verification run.
+type VerifiedAnswer struct {
+	// Answer is the revised final answer.
+	Answer string `json:"answer"`
+	// Draft is the initial answer the verification started from.
+	Draft string `json:"draft"`
+	// Verifications holds the questions asked and their answers.
+	Verifications []Verification `json:"verifications"`
+}
+
+const (
+	verifyQuestionsPrompt = "You fact-check draft answers. Given the " +
+		"question and draft below, write up to %d short verification " +
+		"questions that each check one factual claim the draft relies on. " +
+		"Reply with only the questions, one per line, no numbering.\n\n" +
+		"Question: %s\n\nDraft answer: %s"
+
+	verifyRevisePrompt = "Revise the draft answer below using the " +
+		"verification answers. Fix every claim a verification contradicts " +
+		"and keep everything they confirm. Reply with only the revised " +
+		"answer.\n\nQuestion: %s\n\nDraft answer: %s\n\nVerifications:\n%s"
+)
+
+// ChainOfVerification answers a question in four stages: draft an answer,
+// derive verification questions from it, answer each question independently
+// of the draft, and revise the draft against those answers. Answering the
+// checks in fresh context is what catches the draft's hallucinations — asked
+// alongside the draft, models tend to confirm it. The stages are
+// parameterized by model, so a cheap model can draft and a stronger one
+// verify. The whole run costs MaxQuestions plus three inferences.
+func ChainOfVerification(ctx context.Context, question string, opts *ChainOfVerificationOptions) (*VerifiedAnswer, error) {
+	if opts == nil || opts.Model == nil {
+		return nil, errors.New("workersai: ChainOfVerification requires a model")
+	}
+	cfg := *opts
+	if cfg.Verifier == nil {
+		cfg.Verifier = cfg.Model
+	}
+	if cfg.MaxQuestions <= 0 {
+		cfg.MaxQuestions = 5
+	}
+
+	ask := func(model ai.Model, prompt string) (string, error) {
+		resp, err := model.Generate(ctx, &ai.ModelRequest{
+			Messages: []*ai.Message{ai.NewUserTextMessage(prompt)},
+			Config:   cfg.Config,
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(resp.Message.Text()), nil
+	}
+
+	draft, err := ask(cfg.Model, question)
+	if err != nil {
+		return nil, errors.Wrap(err, "draft failed")
+	}
+
+	raw, err := ask(cfg.Verifier, fmt.Sprintf(verifyQuestionsPrompt, cfg.MaxQuestions, question, draft))
+	if err != nil {
+		return nil, errors.Wrap(err, "verification questions failed")
+	}
+	questions := parseQuestionList(raw, cfg.MaxQuestions)
+	if len(questions) == 0 {
+		// Nothing to verify; the draft stands.
+		return &VerifiedAnswer{Answer: draft, Draft: draft}, nil
+	}
+
+	verifications := make([]Verification, 0, len(questions))
+	for i, q := range questions {
+		answer, err := ask(cfg.Verifier, q)
+		if err != nil {
+			return nil, errors.Wrapf(err, "verification %d failed", i)
+		}
+		verifications = append(verifications, Verification{Question: q, Answer: answer})
+	}
+
+	var checks strings.Builder
+	for _, v := range verifications {
+		fmt.Fprintf(&checks, "Q: %s\nA: %s\n", v.Question, v.Answer)
+	}
+	revised, err := ask(cfg.Model, fmt.Sprintf(verifyRevisePrompt, question, draft, checks.String()))
+	if err != nil {
+		return nil, errors.Wrap(err, "revision failed")
+	}
+
+	return &VerifiedAnswer{Answer: revised, Draft: draft, Verifications: verifications}, nil
+}
+
+// parseQuestionList splits a model's question list into individual questions,
+// tolerating the numbering and bullets models add despite instructions.
+func parseQuestionList(raw string, max int) []string {
+	var questions []string
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*•0123456789.) ")
+		if line == "" {
+			continue
+		}
+		questions = append(questions, line)
+		if len(questions) == max {
+			break
+		}
+	}
+	return questions
+}
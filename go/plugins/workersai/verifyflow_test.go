@@ -0,0 +1,77 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func textResponse(text string) *ai.ModelResponse {
+	return &ai.ModelResponse{Message: ai.NewModelTextMessage(text)}
+}
+
+func TestChainOfVerification(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{
+		textResponse("The Nile is the longest river and is 5000 km long."),
+		textResponse("The Nile is the longest river; it is about 6650 km long."),
+	}}
+	verifier := &queueModel{responses: []*ai.ModelResponse{
+		textResponse("1. Is the Nile the longest river?\n2. How long is the Nile?"),
+		textResponse("Yes, by most measures."),
+		textResponse("About 6650 km."),
+	}}
+
+	result, err := ChainOfVerification(context.Background(), "Which river is longest?", &ChainOfVerificationOptions{
+		Model:    model,
+		Verifier: verifier,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "The Nile is the longest river and is 5000 km long.", result.Draft)
+	assert.Equal(t, "The Nile is the longest river; it is about 6650 km long.", result.Answer)
+	require.Len(t, result.Verifications, 2)
+	assert.Equal(t, "Is the Nile the longest river?", result.Verifications[0].Question)
+	assert.Equal(t, "About 6650 km.", result.Verifications[1].Answer)
+
+	// Verification questions are answered in fresh context: the request
+	// carries only the question, never the draft.
+	require.Len(t, verifier.requests, 3)
+	assert.Equal(t, "How long is the Nile?", verifier.requests[2].Messages[0].Text())
+
+	// The revision prompt carries the draft and the verification answers.
+	require.Len(t, model.requests, 2)
+	revise := model.requests[1].Messages[0].Text()
+	assert.Contains(t, revise, "5000 km")
+	assert.Contains(t, revise, "About 6650 km.")
+}
+
+func TestChainOfVerificationNoQuestions(t *testing.T) {
+	model := &queueModel{responses: []*ai.ModelResponse{textResponse("Paris.")}}
+	verifier := &queueModel{responses: []*ai.ModelResponse{textResponse("")}}
+
+	// A verifier with nothing to check leaves the draft standing, without
+	// a revision call.
+	result, err := ChainOfVerification(context.Background(), "Capital of France?", &ChainOfVerificationOptions{
+		Model:    model,
+		Verifier: verifier,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Paris.", result.Answer)
+	assert.Empty(t, result.Verifications)
+	assert.Len(t, model.requests, 1)
+}
+
+func TestChainOfVerificationRequiresModel(t *testing.T) {
+	_, err := ChainOfVerification(context.Background(), "q", nil)
+	require.Error(t, err)
+}
+
+func TestParseQuestionList(t *testing.T) {
+	raw := "1. First question?\n- Second question?\n\n  * Third question?\n4) Fourth?"
+	assert.Equal(t, []string{"First question?", "Second question?", "Third question?"},
+		parseQuestionList(raw, 3))
+	assert.Empty(t, parseQuestionList("  \n\n", 5))
+}
@@ -0,0 +1,74 @@
+package workersai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatermarkMiddleware(t *testing.T) {
+	fixed := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	watermarkNow = func() time.Time { return fixed }
+	defer func() { watermarkNow = time.Now }()
+
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage("The answer is 42.")}, nil
+	}
+	mw := WatermarkMiddleware(&WatermarkConfig{
+		Model:         "workersai/@cf/meta/llama-3.1-8b-instruct",
+		Timestamp:     true,
+		ContentHash:   true,
+		VisibleNotice: "Generated by AI.",
+	})
+
+	resp, err := mw(next)(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+
+	provenance, ok := resp.Message.Metadata["provenance"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "workersai/@cf/meta/llama-3.1-8b-instruct", provenance["model"])
+	assert.Equal(t, "2025-06-01T12:00:00Z", provenance["generatedAt"])
+
+	// The hash covers the model's own output, not the appended notice.
+	sum := sha256.Sum256([]byte("The answer is 42."))
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), provenance["contentHash"])
+
+	require.Len(t, resp.Message.Content, 2)
+	assert.Equal(t, "The answer is 42.", resp.Message.Content[0].Text)
+	assert.Equal(t, "Generated by AI.", resp.Message.Content[1].Text)
+}
+
+func TestWatermarkMiddlewareMetadataOnly(t *testing.T) {
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage("hello")}, nil
+	}
+
+	resp, err := WatermarkMiddleware(&WatermarkConfig{ContentHash: true})(next)(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+
+	// No visible notice configured: the text is untouched.
+	require.Len(t, resp.Message.Content, 1)
+	assert.Equal(t, "hello", resp.Message.Text())
+	provenance := resp.Message.Metadata["provenance"].(map[string]any)
+	assert.Contains(t, provenance, "contentHash")
+	assert.NotContains(t, provenance, "model")
+	assert.NotContains(t, provenance, "generatedAt")
+}
+
+func TestWatermarkMiddlewareEmptyConfig(t *testing.T) {
+	next := func(ctx context.Context, input *ai.ModelRequest, cb ai.ModelStreamCallback) (*ai.ModelResponse, error) {
+		return &ai.ModelResponse{Message: ai.NewModelTextMessage("hello")}, nil
+	}
+
+	// Nothing enabled: the response passes through without metadata.
+	resp, err := WatermarkMiddleware(nil)(next)(context.Background(), &ai.ModelRequest{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, resp.Message.Metadata)
+	require.Len(t, resp.Message.Content, 1)
+}
@@ -19,12 +19,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
+	"github.com/firebase/genkit/go/internal/base"
 	"github.com/pkg/errors"
 )
 
@@ -32,15 +37,134 @@ const provider = "workersai"
 
 // WorkersAI holds the shared client instance.
 type WorkersAI struct {
-	client  *client.Client
-	mu      sync.Mutex
-	initted bool
+	// AccessClientID and AccessClientSecret authenticate requests to
+	// gateways protected by Cloudflare Access (Zero Trust). When set, they
+	// are sent as the CF-Access-Client-Id and CF-Access-Client-Secret
+	// headers on every request. If unset, the CF_ACCESS_CLIENT_ID and
+	// CF_ACCESS_CLIENT_SECRET environment variables are used.
+	AccessClientID     string
+	AccessClientSecret string
+
+	// Gateway enables gateway provider mode: upstream BYOK models proxied
+	// by a Cloudflare AI Gateway (OpenAI, Anthropic, Groq, ...) are
+	// registered under this plugin via the gateway's unified endpoint.
+	Gateway *GatewayConfig
+
+	// ProxyURL, when set, routes all requests through the given HTTP(S)
+	// proxy. If unset, the standard HTTPS_PROXY/NO_PROXY environment
+	// variables are honored.
+	ProxyURL string
+
+	// RateLimitObserver, when set, is invoked with the rate-limit state of
+	// every response that reports it, so schedulers can adapt concurrency
+	// dynamically. It must be safe for concurrent use.
+	RateLimitObserver func(RateLimitInfo)
+
+	// Retry enables automatic retries of transient failures. Each logical
+	// request carries an idempotency key that is reused across retry
+	// attempts, so a retried POST through the AI Gateway is not
+	// double-billed when the first attempt actually succeeded.
+	Retry *RetryConfig
+
+	// Transport tunes the HTTP transport (idle connection pool, HTTP/2,
+	// optional keepalive pings). Nil keeps the defaults.
+	Transport *TransportConfig
+
+	// DefaultModel, when set, is used for generate calls that specify no
+	// model, in apps that configured no genkit-level default (see
+	// [genkit.WithDefaultModel], which takes precedence). The bare Workers
+	// AI model name is expected ("@cf/meta/llama-3.3-70b-instruct-fp8-fast");
+	// the provider prefix is added automatically.
+	DefaultModel string
+
+	// AllModels additionally registers the rest of the Workers AI
+	// text-generation catalog (gemma, phi, hermes, openchat, ...) with
+	// conservative default capabilities, so the whole catalog shows up in
+	// the Dev UI without each model being curated first.
+	AllModels bool
+
+	// AppID is appended to the User-Agent header, after the standard
+	// "genkit-go/<version> workersai/<version>" product tokens, so gateway
+	// logs and Cloudflare support tickets can be tied to a specific
+	// deployment. Use a product-token form like "my-app/2.3".
+	AppID string
+
+	// TokenSource, when set, is consulted per request for the API token
+	// instead of reading CLOUDFLARE_API_TOKEN once at initialization.
+	// Tokens are cached until they expire, so secret-manager rotations are
+	// picked up without re-initializing the plugin.
+	TokenSource TokenSource
+
+	// Tenants, when set, swaps in per-tenant credentials, rate limits and
+	// usage counters for requests whose context carries a tenant identifier
+	// (see [WithTenant]). Requests without one keep the plugin's own
+	// credentials.
+	Tenants *TenantPool
+
+	// Failover, when set, fails requests over to a secondary Cloudflare
+	// account when the primary hits quota or auth failures, with automatic
+	// failback once the primary recovers. Switchovers are reported through
+	// the config's OnSwitch callback.
+	Failover *FailoverConfig
+
+	// Downgrade, when set, automatically switches requests to a cheaper
+	// model while rate limiting or elevated error rates persist,
+	// annotating responses so callers can display a reduced-quality
+	// notice.
+	Downgrade *DowngradePolicy
+
+	// VerifyCredentials checks the token at Init: that Cloudflare considers
+	// it active, and that it can reach this account's Workers AI endpoints.
+	// Misconfigured deployments then fail at startup with a precise error
+	// instead of on the first Generate with a 403.
+	VerifyCredentials bool
+
+	// BaseURL overrides the Cloudflare API endpoint, primarily so tests
+	// can point the plugin at a fake such as the workersaitest server.
+	// Empty keeps the production endpoint.
+	BaseURL string
+
+	// SLO, when set, evaluates the configured service-level objectives
+	// (p95 latency, error rate, hourly token spend) over a rolling window
+	// of the plugin's own traffic and notifies the config's observers on
+	// breaches.
+	SLO *SLOConfig
+
+	// Deterministic, when set, pins every request to reproducible
+	// settings (temperature zero, a fixed seed, optionally pinned model
+	// snapshots) and rejects requests that would defeat determinism. For
+	// test environments backing golden-file assertions.
+	Deterministic *DeterministicConfig
+
+	client        *client.Client
+	genkit        *genkit.Genkit
+	baseTransport *http.Transport
+	keepAliveStop chan struct{}
+	reloadStop    chan struct{}
+	catalog       []CatalogEntry
+	inflight      sync.WaitGroup
+	closed        bool
+	rateLimit     rateLimitState
+	health        healthState
+	costs         costTracker
+	sloMon        *sloMonitor
+	toolLog       toolCallLog
+	mu            sync.Mutex
+	initted       bool
 }
 
 // generator is the internal struct that implements the model generation logic.
 type generator struct {
 	model  string
+	info   ai.ModelInfo
 	client *client.Client
+	plugin *WorkersAI
+}
+
+// RateLimit returns the most recently observed rate-limit state, or nil if
+// the API has not reported any yet.
+func (w *WorkersAI) RateLimit() *RateLimitInfo {
+	return w.rateLimit.get()
 }
 
 // Name returns the name of the plugin.
@@ -48,6 +172,16 @@ func (w *WorkersAI) Name() string {
 	return provider
 }
 
+// DefaultModelName implements [genkit.DefaultModelProvider]: when the plugin
+// is configured with a DefaultModel, generate calls that specify no model use
+// it, unless the app set a genkit-level default.
+func (w *WorkersAI) DefaultModelName() string {
+	if w.DefaultModel == "" {
+		return ""
+	}
+	return provider + "/" + w.DefaultModel
+}
+
 // Init initializes the Workers AI plugin and the shared client.
 func (w *WorkersAI) Init(ctx context.Context, g *genkit.Genkit) (err error) {
 	if w == nil {
@@ -67,7 +201,7 @@ func (w *WorkersAI) Init(ctx context.Context, g *genkit.Genkit) (err error) {
 	}()
 
 	apiToken := os.Getenv("CLOUDFLARE_API_TOKEN")
-	if apiToken == "" {
+	if apiToken == "" && w.TokenSource == nil {
 		return errors.New("Workers AI requires setting CLOUDFLARE_API_TOKEN in the environment")
 	}
 
@@ -78,6 +212,77 @@ func (w *WorkersAI) Init(ctx context.Context, g *genkit.Genkit) (err error) {
 
 	// Initialize the client from your library.
 	w.client = client.NewClient(accountID, apiToken)
+	if w.BaseURL != "" {
+		w.client.BaseURL = w.BaseURL
+	}
+	w.genkit = g
+
+	baseTransport, err := w.newBaseTransport()
+	if err != nil {
+		return err
+	}
+	w.baseTransport = baseTransport
+	w.client.HTTPClient.Transport = &userAgentTransport{
+		base:      baseTransport,
+		userAgent: userAgent(w.AppID),
+	}
+	if w.Tenants != nil {
+		// Innermost of the auth layers, so its Authorization header wins
+		// over the plugin-wide token set further up the stack.
+		w.client.HTTPClient.Transport = &tenantTransport{
+			base:      w.client.HTTPClient.Transport,
+			pool:      w.Tenants,
+			accountID: accountID,
+		}
+	}
+	w.client.HTTPClient.Transport = &healthTransport{
+		base:  w.client.HTTPClient.Transport,
+		state: &w.health,
+	}
+
+	if err := w.setupAccessAuth(); err != nil {
+		return err
+	}
+	if w.TokenSource != nil {
+		w.client.HTTPClient.Transport = &tokenTransport{
+			base:   w.client.HTTPClient.Transport,
+			source: w.TokenSource,
+		}
+	}
+	w.client.HTTPClient.Transport = &rateLimitTransport{
+		base:     w.client.HTTPClient.Transport,
+		state:    &w.rateLimit,
+		observer: w.RateLimitObserver,
+	}
+	if w.Retry != nil {
+		w.client.HTTPClient.Transport = &retryTransport{
+			base: w.client.HTTPClient.Transport,
+			cfg:  *w.Retry,
+		}
+	}
+	if w.Failover != nil {
+		if w.Failover.AccountID == "" || w.Failover.APIToken == "" {
+			return errors.New("Failover requires both an AccountID and an APIToken for the secondary account")
+		}
+		// Outermost, so a failover decision is based on each request's
+		// final outcome after any retries below.
+		w.client.HTTPClient.Transport = &failoverTransport{
+			base:      w.client.HTTPClient.Transport,
+			cfg:       *w.Failover,
+			accountID: accountID,
+		}
+	}
+	if w.VerifyCredentials {
+		if err := w.verifyCredentials(ctx); err != nil {
+			return err
+		}
+	}
+	if w.Transport != nil && w.Transport.KeepAliveInterval > 0 {
+		w.startKeepAlive(w.Transport.KeepAliveInterval)
+	}
+	if w.SLO != nil {
+		w.sloMon = newSLOMonitor(*w.SLO)
+	}
 	w.initted = true
 
 	// You can set debug mode for the client if needed.
@@ -85,29 +290,84 @@ func (w *WorkersAI) Init(ctx context.Context, g *genkit.Genkit) (err error) {
 		w.client.SetDebug(true)
 	}
 
-	// Register known models here.
+	// Register known models and embedders here.
 	for name, info := range supportedWorkersAIModels {
 		w.defineModel(g, name, info)
 	}
+	if w.AllModels {
+		w.registerCatalogModels(g)
+	}
+	for name, dimensions := range supportedWorkersAIEmbedders {
+		w.defineEmbedder(g, name, dimensions)
+	}
+
+	if w.Gateway != nil {
+		if err := w.setupGateway(g); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setupAccessAuth installs Cloudflare Access service-token headers on the
+// client when the credentials are configured, either on the plugin or in the
+// environment. Both halves of the token pair must be supplied.
+func (w *WorkersAI) setupAccessAuth() error {
+	clientID := w.AccessClientID
+	if clientID == "" {
+		clientID = os.Getenv("CF_ACCESS_CLIENT_ID")
+	}
+	clientSecret := w.AccessClientSecret
+	if clientSecret == "" {
+		clientSecret = os.Getenv("CF_ACCESS_CLIENT_SECRET")
+	}
+
+	if clientID == "" && clientSecret == "" {
+		return nil
+	}
+	if clientID == "" || clientSecret == "" {
+		return errors.New("Cloudflare Access requires both a client ID and a client secret")
+	}
 
+	w.client.HTTPClient.Transport = &headerTransport{
+		base: w.client.HTTPClient.Transport,
+		headers: map[string]string{
+			"CF-Access-Client-Id":     clientID,
+			"CF-Access-Client-Secret": clientSecret,
+		},
+	}
 	return nil
 }
 
 // defineModel is a helper to register a model with Genkit.
 func (w *WorkersAI) defineModel(g *genkit.Genkit, name string, info ai.ModelInfo) {
+	// Attach the schema of the supported generation options so the Dev UI
+	// renders proper parameter controls instead of a free-form JSON box.
+	if info.ConfigSchema == nil {
+		info.ConfigSchema = base.ToSchemaMap(&Config{})
+	}
 	gen := &generator{
 		model:  name,
+		info:   info,
 		client: w.client,
+		plugin: w,
 	}
 	genkit.DefineModel(g, provider, name, &info, gen.generate)
 }
 
-// DefineModel defines a Workers AI model for use in Genkit.
-func (w *WorkersAI) DefineModel(g *genkit.Genkit, name string, info *ai.ModelInfo) {
+// DefineModel defines a Workers AI model for use in Genkit. The name is
+// validated before registration so typos surface as actionable errors rather
+// than 404s at generation time.
+func (w *WorkersAI) DefineModel(g *genkit.Genkit, name string, info *ai.ModelInfo) error {
 	if !w.initted {
 		panic("Workers AI plugin not initialized")
 	}
 
+	if err := validateModelName(name); err != nil {
+		return err
+	}
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
@@ -126,29 +386,165 @@ func (w *WorkersAI) DefineModel(g *genkit.Genkit, name string, info *ai.ModelInf
 		}
 	}
 	w.defineModel(g, name, mi)
+	return nil
 }
 
 // generate is the core translation layer between Genkit and the Workers AI client.
 func (gen *generator) generate(ctx context.Context, input *ai.ModelRequest, cb func(context.Context, *ai.ModelResponseChunk) error) (*ai.ModelResponse, error) {
-	// 1. Convert Genkit Tools to the client library's Tool format.
-	clientTools, err := toClientTools(input.Tools)
+	if gen.plugin != nil {
+		// Count the request as in-flight so Close can drain before
+		// releasing the transports.
+		if err := gen.plugin.acquire(); err != nil {
+			return nil, err
+		}
+		defer gen.plugin.release()
+	}
+	// Record per-model latency, time-to-first-token and throughput around
+	// the call, noting when the first chunk reaches the stream callback.
+	start := time.Now()
+	var firstToken time.Duration
+	wrappedCb := cb
+	if cb != nil {
+		wrappedCb = func(ctx context.Context, chunk *ai.ModelResponseChunk) error {
+			if firstToken == 0 {
+				firstToken = time.Since(start)
+			}
+			return cb(ctx, chunk)
+		}
+	}
+	// Under sustained rate limiting or elevated error rates, the
+	// downgrade policy reroutes the request to a cheaper model.
+	target, downgradeReason := gen.downgradeTarget()
+
+	resp, err := target.doGenerate(ctx, input, wrappedCb)
+	if err == nil && target.plugin != nil && target.plugin.genkit != nil {
+		if cfg, cfgErr := configFromRequest(input); cfgErr == nil && cfg.EmbeddedToolCalling {
+			resp, err = target.resolveToolsEmbedded(ctx, input, wrappedCb, resp)
+		}
+	}
+	if downgradeReason != "" && err == nil {
+		annotateDowngrade(resp, gen.model, target.model, downgradeReason)
+	}
+	if gen.plugin != nil {
+		gen.plugin.costs.record(ctx, resp, err)
+		if gen.plugin.sloMon != nil {
+			tokens := 0
+			if err == nil && resp != nil && resp.Usage != nil {
+				tokens = resp.Usage.InputTokens + resp.Usage.OutputTokens
+			}
+			gen.plugin.sloMon.record(time.Since(start), err != nil, tokens)
+		}
+	}
+	writeGenerateMetrics(ctx, target.model, time.Since(start), firstToken, resp, err)
+	return resp, err
+}
+
+func (gen *generator) doGenerate(ctx context.Context, input *ai.ModelRequest, cb func(context.Context, *ai.ModelResponseChunk) error) (*ai.ModelResponse, error) {
+	// Fail fast when the request needs a feature the model lacks, rather
+	// than letting the API reject it opaquely.
+	if err := checkCapabilities(gen.model, gen.info.Supports, input); err != nil {
+		return nil, err
+	}
+
+	// Record the tool responses the caller is replaying into this turn as
+	// span events, pairing them with the requests of the previous turn.
+	gen.logToolResponses(ctx, input.Messages)
+
+	// 1. Convert Genkit Tools to the client library's Tool format, mapping
+	// tool names Workers AI would reject to sanitized wire names.
+	names := newToolNameMap(input.Tools)
+	clientTools, err := toClientTools(input.Tools, names)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert tools")
 	}
 
-	// 2. Convert Genkit Messages to the client library's Message format.
-	clientMessages, err := toClientMessages(input.Messages)
+	// 2. Parse the typed config first; the unknown-content policy it may
+	// carry governs the message conversion below.
+	cfg, err := configFromRequest(input)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to convert messages")
+		return nil, err
+	}
+	if err := validateJurisdiction(cfg.Jurisdiction); err != nil {
+		return nil, err
 	}
 
-	// 3. Call the client library. All HTTP and response format complexity is handled here.
-	resp, err := gen.client.ChatWithTools(gen.model, clientMessages, clientTools)
+	// 3. Convert Genkit Messages to the client library's Message format
+	// and call the client library with the request-specific headers and
+	// parameters applied. All HTTP and response format complexity is
+	// handled here.
+	clientMessages, err := toClientMessages(input.Messages, gen.info.Supports, names, cfg.UnknownContent)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert messages")
+	}
+	model := gen.model
+	if gen.plugin != nil && gen.plugin.Deterministic != nil {
+		if err := gen.plugin.Deterministic.apply(cfg); err != nil {
+			return nil, err
+		}
+		model = gen.plugin.Deterministic.pinned(model)
+	}
+	params := cfg.bodyParams()
+	for k, v := range guidedOutputParams(input.Output) {
+		params[k] = v
+	}
+	guided, err := guidedDecodingParams(cfg, input.Output)
 	if err != nil {
-		return nil, errors.Wrap(err, "workersai client failed")
+		return nil, err
+	}
+	for k, v := range guided {
+		params[k] = v
+	}
+	headers := cfg.requestHeaders()
+	if id := correlationIDFromContext(ctx); id != "" {
+		headers[correlationHeader] = id
+	}
+	overrides := cfg.bodyOverrides()
+	if cfg.StrictTools && len(input.Tools) > 0 {
+		overrides["tools"] = strictToolsPayload(input.Tools, names)
+	}
+	reqClient, capture := gen.clientFor(ctx, headers, params, overrides, cfg.MaxResponseBytes)
+
+	// The client call blocks without observing ctx itself, so run it in a
+	// goroutine. Cancellation closes the underlying connection (via the
+	// context attached in clientFor), which unblocks the call promptly; the
+	// buffered channel lets the goroutine exit either way rather than leak.
+	type chatResult struct {
+		resp *client.ChatResponse
+		err  error
+	}
+	resultc := make(chan chatResult, 1)
+	go func() {
+		resp, err := reqClient.ChatWithTools(model, clientMessages, clientTools)
+		resultc <- chatResult{resp, err}
+	}()
+
+	var resp *client.ChatResponse
+	select {
+	case <-ctx.Done():
+		return partialResult(ctx, input, cfg)
+	case result := <-resultc:
+		if result.err != nil {
+			if ctx.Err() != nil {
+				return partialResult(ctx, input, cfg)
+			}
+			if errors.Is(result.err, errResponseTooLarge) {
+				slog.Warn("workersai response exceeded the configured size limit",
+					"model", gen.model, "limit", cfg.MaxResponseBytes)
+				return lengthLimitedResponse(input, cfg.MaxResponseBytes), nil
+			}
+			if ray := recordCorrelation(ctx, capture); ray != "" {
+				return nil, errors.Wrapf(result.err, "workersai client failed (cf-ray %s)", ray)
+			}
+			return nil, errors.Wrap(result.err, "workersai client failed")
+		}
+		resp = result.resp
 	}
 
+	cfRay := recordCorrelation(ctx, capture)
 	if !resp.Success {
+		if cfRay != "" {
+			return nil, fmt.Errorf("workersai API returned an error (cf-ray %s): %v", cfRay, resp.Errors)
+		}
 		return nil, fmt.Errorf("workersai API returned an error: %v", resp.Errors)
 	}
 
@@ -158,6 +554,33 @@ func (gen *generator) generate(ctx context.Context, input *ai.ModelRequest, cb f
 		Usage:   &ai.GenerationUsage{}, // Usage will be populated below.
 	}
 
+	// Surface gateway and rate-limit state so callers can adapt without
+	// reaching into the plugin.
+	custom := map[string]any{}
+	if gen.plugin != nil {
+		if rl := gen.plugin.RateLimit(); rl != nil {
+			custom["rateLimit"] = rl
+		}
+	}
+	if cacheStatus := capture.lastHeader("cf-aig-cache-status"); cacheStatus != "" {
+		custom["cacheStatus"] = cacheStatus
+	}
+	if cfRay != "" {
+		custom["cfRay"] = cfRay
+		if colo := servingColo(cfRay); colo != "" {
+			custom["colo"] = colo
+		}
+	}
+	custom["historyFingerprint"] = recordFingerprint(ctx, input.Messages)
+	if cfg.Candidates > 1 {
+		if extras := extraCandidates(resp); len(extras) > 0 {
+			custom["candidates"] = extras
+		}
+	}
+	if len(custom) > 0 {
+		modelResponse.Custom = custom
+	}
+
 	// Populate usage data regardless of response format.
 	if resp.IsLegacyResult {
 		modelResponse.Usage.InputTokens = resp.LegacyResponse.Usage.PromptTokens
@@ -167,28 +590,123 @@ func (gen *generator) generate(ctx context.Context, input *ai.ModelRequest, cb f
 		modelResponse.Usage.OutputTokens = resp.ChatCompletionResponse.Usage.CompletionTokens
 	}
 
-	// Check if the response contains tool calls.
+	// Build the message parts in the order the model produced them: any
+	// explanatory text first, then the tool calls. Models often narrate
+	// before calling a tool, and keeping both lets UIs show "thinking,
+	// then calling tool X" instead of dropping the narration.
 	toolCalls := resp.GetToolCalls()
+	content := resp.GetContent()
+
+	// Reasoning models think before answering, either in the dedicated
+	// reasoning_content field or inline as a <think> block; normalize both
+	// so the answer never carries raw think tags.
+	reasoning, content := extractReasoning(content)
+	if reasoning == "" {
+		reasoning = resp.GetReasoningContent()
+	}
+
+	var parts []*ai.Part
+	if cfg.ShowReasoning && reasoning != "" {
+		parts = append(parts, ai.NewReasoningPart(reasoning, nil))
+	}
+	if content != "" || len(toolCalls) == 0 {
+		// For JSON-output requests the text is normalized and validated so
+		// structured helpers like genkit.GenerateData fail with an
+		// actionable error instead of an opaque unmarshal failure.
+		if len(toolCalls) == 0 && input.Output != nil && input.Output.Format == ai.OutputFormatJSON {
+			cleaned, err := cleanJSONOutput(content)
+			if err != nil {
+				return nil, err
+			}
+			content = cleaned
+		}
+		parts = append(parts, ai.NewTextPart(content))
+	}
 	if len(toolCalls) > 0 {
-		toolRequestParts, err := toGenkitToolRequestParts(toolCalls)
+		toolRequestParts, err := toGenkitToolRequestParts(toolCalls, input.Tools, names)
 		if err != nil {
 			return nil, err
 		}
+		if cfg.StrictTools {
+			if err := validateStrictToolCalls(toolRequestParts, input.Tools); err != nil {
+				return nil, err
+			}
+		}
+		parts = append(parts, toolRequestParts...)
+		gen.logToolRequests(ctx, toolRequestParts)
+	}
 
-		modelResponse.Message = &ai.Message{Role: ai.RoleModel, Content: toolRequestParts}
-		modelResponse.FinishReason = ai.FinishReasonStop
-	} else {
-		// Handle a standard text response.
-		modelResponse.Message = &ai.Message{
-			Role:    ai.RoleModel,
-			Content: []*ai.Part{ai.NewTextPart(resp.GetContent())},
+	// The client has no streaming support, so the parts arrive all at
+	// once; still emit them as ordered chunks so streaming callers see
+	// text and tool requests in sequence. The flush policy decides how
+	// much text each chunk carries. For structured output the text is
+	// validated incrementally before it is forwarded, so clients rendering
+	// partial JSON never display invalid intermediate states.
+	if cb != nil {
+		var validator *jsonStreamValidator
+		if input.Output != nil && input.Output.Format == ai.OutputFormatJSON {
+			validator = newJSONStreamValidator(input.Output.Schema)
+		}
+		emit := func(part *ai.Part) error {
+			return cb(ctx, &ai.ModelResponseChunk{
+				Role:    ai.RoleModel,
+				Content: []*ai.Part{part},
+			})
+		}
+		for _, part := range parts {
+			if !part.IsText() {
+				if err := emit(part); err != nil {
+					return nil, errors.Wrap(err, "stream callback failed")
+				}
+				continue
+			}
+			if validator != nil {
+				if err := validator.feed(part.Text); err != nil {
+					return nil, err
+				}
+			}
+			for _, unit := range flushUnits(part.Text, cfg.StreamFlush, cfg.StreamFlushTokens) {
+				if err := emit(ai.NewTextPart(unit)); err != nil {
+					return nil, errors.Wrap(err, "stream callback failed")
+				}
+			}
 		}
-		modelResponse.FinishReason = ai.FinishReasonStop
 	}
 
+	modelResponse.Message = &ai.Message{Role: ai.RoleModel, Content: parts}
+	modelResponse.FinishReason = ai.FinishReasonStop
+
 	return modelResponse, nil
 }
 
+// interruptedResponse builds the partial response returned when the Generate
+// context is cancelled mid-request. Once streaming lands, accumulated chunks
+// should be attached here instead of an empty message.
+func interruptedResponse(input *ai.ModelRequest) *ai.ModelResponse {
+	return &ai.ModelResponse{
+		Request:      input,
+		FinishReason: ai.FinishReasonInterrupted,
+		Message:      &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{}},
+		Usage:        &ai.GenerationUsage{},
+	}
+}
+
+// partialResult maps a context-expiry into the configured result shape: with
+// ReturnPartialOnDeadline set and the deadline exceeded, the caller gets the
+// accumulated partial response with truncation metadata instead of an error.
+func partialResult(ctx context.Context, input *ai.ModelRequest, cfg *Config) (*ai.ModelResponse, error) {
+	resp := interruptedResponse(input)
+	if cfg != nil && cfg.ReturnPartialOnDeadline && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		resp.FinishMessage = "request truncated: the context deadline expired before the model finished"
+		resp.Custom = map[string]any{
+			"truncated":        true,
+			"truncationReason": "deadline",
+		}
+		return resp, nil
+	}
+	return resp, ctx.Err()
+}
+
 // simplifyArguments adapts verbose model arguments into the simple format Genkit expects.
 func simplifyArguments(argsJSON string) (map[string]any, error) {
 	var rawArgs map[string]any
@@ -211,8 +729,14 @@ func simplifyArguments(argsJSON string) (map[string]any, error) {
 
 // toGenkitToolRequestParts adapts the tool calls from the client library's response
 // into a slice of *ai.Part suitable for Genkit. It handles both simple and verbose
-// argument formats from different models.
-func toGenkitToolRequestParts(calls []client.ToolCall) ([]*ai.Part, error) {
+// argument formats from different models, and coerces argument values toward
+// the types each tool's input schema declares.
+func toGenkitToolRequestParts(calls []client.ToolCall, defs []*ai.ToolDefinition, names *toolNameMap) ([]*ai.Part, error) {
+	schemas := make(map[string]map[string]any, len(defs))
+	for _, def := range defs {
+		schemas[def.Name] = def.InputSchema
+	}
+
 	var toolRequestParts []*ai.Part
 	for _, call := range calls {
 		// First, unmarshal the arguments string from the model into a raw map.
@@ -237,11 +761,19 @@ func toGenkitToolRequestParts(calls []client.ToolCall) ([]*ai.Part, error) {
 			simplifiedArgs[key] = val
 		}
 
-		// Create the ToolRequest struct that Genkit expects.
+		// Create the ToolRequest struct that Genkit expects, mapping the
+		// wire name back to the original Genkit tool name. Some models
+		// return tool calls without IDs; synthesize a stable one so the
+		// paired tool responses still correlate on the next turn.
+		name := names.genkit(call.Function.Name)
+		ref := call.ID
+		if ref == "" {
+			ref = synthesizeToolCallID(len(toolRequestParts), call.Function.Name, call.Function.Arguments)
+		}
 		tr := &ai.ToolRequest{
-			Ref:   call.ID,
-			Name:  call.Function.Name,
-			Input: simplifiedArgs,
+			Ref:   ref,
+			Name:  name,
+			Input: coerceToolArguments(simplifiedArgs, schemas[name]),
 		}
 
 		toolRequestParts = append(toolRequestParts, ai.NewToolRequestPart(tr))
@@ -250,7 +782,7 @@ func toGenkitToolRequestParts(calls []client.ToolCall) ([]*ai.Part, error) {
 }
 
 // toClientTools converts Genkit tool definitions to the client library's format.
-func toClientTools(defs []*ai.ToolDefinition) ([]client.Tool, error) {
+func toClientTools(defs []*ai.ToolDefinition, names *toolNameMap) ([]client.Tool, error) {
 	if len(defs) == 0 {
 		return nil, nil
 	}
@@ -270,7 +802,7 @@ func toClientTools(defs []*ai.ToolDefinition) ([]client.Tool, error) {
 		tools = append(tools, client.Tool{
 			Type: "function",
 			Function: client.FunctionDefinition{
-				Name:        def.Name,
+				Name:        names.wire(def.Name),
 				Description: def.Description,
 				Parameters:  params,
 			},
@@ -278,8 +810,12 @@ func toClientTools(defs []*ai.ToolDefinition) ([]client.Tool, error) {
 	}
 	return tools, nil
 }
-func toClientMessages(messages []*ai.Message) ([]client.Message, error) {
+func toClientMessages(messages []*ai.Message, supports *ai.ModelSupports, names *toolNameMap, policy UnknownContentPolicy) ([]client.Message, error) {
 	var clientMsgs []client.Message
+	// Refs of replayed assistant tool calls, queued per tool name so tool
+	// responses that arrive without a Ref can still be matched to the call
+	// that produced them.
+	pending := pendingToolRefs{}
 	for _, msg := range messages {
 		switch msg.Role {
 		case ai.RoleTool:
@@ -291,17 +827,37 @@ func toClientMessages(messages []*ai.Message) ([]client.Message, error) {
 						return nil, errors.Wrapf(err, "failed to marshal tool output for %s", part.ToolResponse.Name)
 					}
 
+					// Read the ID back from Ref; when the response carries
+					// none, fall back to the ref of the matching assistant
+					// tool call so the API can still correlate the pair.
+					ref := part.ToolResponse.Ref
+					if ref == "" {
+						ref = pending.take(part.ToolResponse.Name)
+					}
 					clientMsgs = append(clientMsgs, client.ToolMessage{
 						Role:       "tool",
 						Content:    string(outputBytes),
-						ToolCallID: part.ToolResponse.Ref, // Read the ID back from Ref
+						ToolCallID: ref,
 					})
 				}
 			}
 		case ai.RoleModel:
 			// Handle the assistant's previous message (the tool request).
 			var toolCalls []client.ToolCall
+			var policyPieces []string
 			for _, part := range msg.Content {
+				// Reasoning parts are the model's own scratchpad and are
+				// deliberately not replayed; data and custom parts go
+				// through the unknown-content policy.
+				if part.IsData() || part.IsCustom() {
+					piece, err := handleUnknownPart(policy, msg.Role, part)
+					if err != nil {
+						return nil, err
+					}
+					if piece != "" {
+						policyPieces = append(policyPieces, piece)
+					}
+				}
 				if part.IsToolRequest() {
 					// We must convert Genkit's request back to the client library's format.
 					// This is crucial for maintaining conversation history.
@@ -309,11 +865,19 @@ func toClientMessages(messages []*ai.Message) ([]client.Message, error) {
 					if err != nil {
 						return nil, errors.Wrapf(err, "failed to marshal tool input for %s", part.ToolRequest.Name)
 					}
+					// Pass the ID along, synthesizing one for history
+					// entries that never had it so the paired tool message
+					// below can reference something the API has seen.
+					ref := part.ToolRequest.Ref
+					if ref == "" {
+						ref = synthesizeToolCallID(len(toolCalls), part.ToolRequest.Name, string(argsBytes))
+					}
+					pending.add(part.ToolRequest.Name, ref)
 					toolCalls = append(toolCalls, client.ToolCall{
-						ID:   part.ToolRequest.Ref, // Pass the ID along
+						ID:   ref,
 						Type: "function",
 						Function: client.FunctionToCall{
-							Name:      part.ToolRequest.Name,
+							Name:      names.wire(part.ToolRequest.Name),
 							Arguments: string(argsBytes),
 						},
 					})
@@ -326,19 +890,49 @@ func toClientMessages(messages []*ai.Message) ([]client.Message, error) {
 					ToolCalls: toolCalls,
 					Content:   new(string),
 				})
-			} else if msg.Text() != "" {
-				clientMsgs = append(clientMsgs, client.ChatMessage{
-					Role:    "assistant",
-					Content: msg.Text(),
-				})
+			} else {
+				pieces := policyPieces
+				if text := joinTextParts(msg); text != "" {
+					pieces = append([]string{text}, policyPieces...)
+				}
+				if text := strings.Join(pieces, "\n"); text != "" {
+					clientMsgs = append(clientMsgs, client.ChatMessage{
+						Role:    "assistant",
+						Content: text,
+					})
+				}
 			}
 
 		case ai.RoleUser, ai.RoleSystem:
-			// Handle standard user or system messages.
+			// Handle standard user or system messages. Text parts are
+			// joined in order with separators preserved; audio media parts
+			// are inlined as data URIs for models that accept audio input.
+			content, err := toMessageContent(msg, supports, policy)
+			if err != nil {
+				return nil, err
+			}
 			clientMsgs = append(clientMsgs, client.ChatMessage{
 				Role:    convertRole(msg.Role),
-				Content: msg.Text(),
+				Content: content,
 			})
+
+		default:
+			// A role the wire format does not know. The policy decides
+			// whether that is fatal, logged or sent as user text.
+			switch policy {
+			case UnknownContentError:
+				return nil, fmt.Errorf("message role %q cannot be sent to the model; remove the message or relax the unknown-content policy", msg.Role)
+			case UnknownContentStringify:
+				if text := joinTextParts(msg); text != "" {
+					clientMsgs = append(clientMsgs, client.ChatMessage{
+						Role:    "user",
+						Content: text,
+					})
+				}
+			default:
+				slog.Warn("workersai: dropping message with a role the wire format cannot carry",
+					"role", msg.Role)
+			}
 		}
 	}
 	return clientMsgs, nil
@@ -370,7 +964,12 @@ func Model(g *genkit.Genkit, name string) ai.Model {
 	return genkit.LookupModel(g, provider, name)
 }
 
-// ModelRef creates a new ModelRef for a Workers AI model.
-func ModelRef(name string) ai.ModelRef {
-	return ai.NewModelRef("blarg"+provider+"/"+name, nil)
+// ModelRef creates a new ModelRef for a Workers AI model, carrying the given
+// default config. The name may be given with or without the provider prefix.
+func ModelRef(name string, config *Config) ai.ModelRef {
+	name = strings.TrimPrefix(name, provider+"/")
+	if config == nil {
+		return ai.NewModelRef(provider+"/"+name, nil)
+	}
+	return ai.NewModelRef(provider+"/"+name, config)
 }
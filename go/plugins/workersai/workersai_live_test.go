@@ -164,6 +164,9 @@ func TestToGenkitToolRequestParts(t *testing.T) {
 						"location": "Eindhoven, NL",
 						"unit":     "celsius",
 					},
+					// The call carries no ID, so one is synthesized.
+					Ref: synthesizeToolCallID(0, "get_weather_mixed",
+						`{"location": "Eindhoven, NL", "unit": {"type": "string", "value": "celsius"}}`),
 				}),
 			},
 			expectError: false,
@@ -194,7 +197,7 @@ func TestToGenkitToolRequestParts(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// Act: Call the function under test.
-			parts, err := toGenkitToolRequestParts(tc.inputCalls)
+			parts, err := toGenkitToolRequestParts(tc.inputCalls, nil, nil)
 
 			// Assert: Check the results.
 			if tc.expectError {
@@ -213,6 +216,88 @@ func TestToGenkitToolRequestParts(t *testing.T) {
 	}
 }
 
+func TestToClientMessagesAudio(t *testing.T) {
+	mediaSupports := &ai.ModelSupports{Multiturn: true, Media: true}
+	noMediaSupports := &ai.ModelSupports{Multiturn: true, Media: false}
+	wavDataURI := "data:audio/wav;base64,UklGRg=="
+
+	testCases := []struct {
+		name          string
+		input         []*ai.Message
+		supports      *ai.ModelSupports
+		expected      string
+		expectErr     bool
+		errorContains string
+	}{
+		{
+			name: "audio part is passed through as a data URI",
+			input: []*ai.Message{
+				ai.NewUserMessage(ai.NewMediaPart("audio/wav", wavDataURI)),
+			},
+			supports: mediaSupports,
+			expected: wavDataURI,
+		},
+		{
+			name: "text and audio parts are joined",
+			input: []*ai.Message{
+				ai.NewUserMessage(
+					ai.NewTextPart("transcribe this"),
+					ai.NewMediaPart("audio/wav", wavDataURI),
+				),
+			},
+			supports: mediaSupports,
+			expected: "transcribe this\n" + wavDataURI,
+		},
+		{
+			name: "audio MIME alias is normalized",
+			input: []*ai.Message{
+				ai.NewUserMessage(ai.NewMediaPart("audio/mp3", "data:audio/mp3;base64,UklGRg==")),
+			},
+			supports: mediaSupports,
+			expected: "data:audio/mpeg;base64,UklGRg==",
+		},
+		{
+			name: "capability error when the model does not support media",
+			input: []*ai.Message{
+				ai.NewUserMessage(ai.NewMediaPart("audio/wav", wavDataURI)),
+			},
+			supports:      noMediaSupports,
+			expectErr:     true,
+			errorContains: "does not accept audio input",
+		},
+		{
+			name: "unsupported audio content type",
+			input: []*ai.Message{
+				ai.NewUserMessage(ai.NewMediaPart("audio/midi", "data:audio/midi;base64,UklGRg==")),
+			},
+			supports:      mediaSupports,
+			expectErr:     true,
+			errorContains: "unsupported audio content type",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := require.New(t)
+
+			got, err := toClientMessages(tc.input, tc.supports, nil, "")
+			if tc.expectErr {
+				r.Error(err)
+				if tc.errorContains != "" {
+					assert.Contains(t, err.Error(), tc.errorContains)
+				}
+				return
+			}
+
+			r.NoError(err)
+			r.Len(got, 1)
+			msg, ok := got[0].(client.ChatMessage)
+			r.True(ok, "expected message type client.ChatMessage, but got %T", got[0])
+			r.Equal(tc.expected, msg.Content)
+		})
+	}
+}
+
 func TestToClientMessages(t *testing.T) {
 	// Define the structure for our test cases
 	testCases := []struct {
@@ -602,7 +687,7 @@ func TestToClientMessages(t *testing.T) {
 			// Use require for assertions, which stops the test on failure.
 			r := require.New(t)
 
-			got, err := toClientMessages(tc.input)
+			got, err := toClientMessages(tc.input, nil, nil, "")
 
 			if tc.expectErr {
 				r.Error(err)
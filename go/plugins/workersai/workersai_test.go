@@ -0,0 +1,94 @@
+package workersai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartialResult(t *testing.T) {
+	input := &ai.ModelRequest{Messages: []*ai.Message{ai.NewUserTextMessage("hi")}}
+
+	expiredCtx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	cancelledCtx, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+
+	t.Run("deadline with partials enabled returns truncated response", func(t *testing.T) {
+		resp, err := partialResult(expiredCtx, input, &Config{ReturnPartialOnDeadline: true})
+		require.NoError(t, err)
+		assert.Equal(t, ai.FinishReasonInterrupted, resp.FinishReason)
+		custom, ok := resp.Custom.(map[string]any)
+		require.True(t, ok)
+		assert.Equal(t, true, custom["truncated"])
+		assert.Equal(t, "deadline", custom["truncationReason"])
+		assert.NotEmpty(t, resp.FinishMessage)
+	})
+
+	t.Run("deadline without the option still errors", func(t *testing.T) {
+		resp, err := partialResult(expiredCtx, input, &Config{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, ai.FinishReasonInterrupted, resp.FinishReason)
+		assert.Nil(t, resp.Custom)
+	})
+
+	t.Run("plain cancellation is never converted to a partial", func(t *testing.T) {
+		resp, err := partialResult(cancelledCtx, input, &Config{ReturnPartialOnDeadline: true})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, resp.Custom)
+	})
+}
+
+func TestJoinTextParts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		msg      *ai.Message
+		expected string
+	}{
+		{
+			name:     "single part unchanged",
+			msg:      ai.NewUserMessage(ai.NewTextPart("hello world")),
+			expected: "hello world",
+		},
+		{
+			name: "adjacent parts get a separator",
+			msg: ai.NewUserMessage(
+				ai.NewTextPart("You are a helpful assistant."),
+				ai.NewTextPart("Answer briefly."),
+			),
+			expected: "You are a helpful assistant.\nAnswer briefly.",
+		},
+		{
+			name: "existing boundary whitespace is preserved",
+			msg: ai.NewUserMessage(
+				ai.NewTextPart("First line.\n\n"),
+				ai.NewTextPart("Second line."),
+			),
+			expected: "First line.\n\nSecond line.",
+		},
+		{
+			name: "non-text parts are skipped without breaking order",
+			msg: ai.NewUserMessage(
+				ai.NewTextPart("before"),
+				ai.NewMediaPart("image/png", "data:image/png;base64,aaaa"),
+				ai.NewTextPart("after"),
+			),
+			expected: "before\nafter",
+		},
+		{
+			name:     "empty parts are ignored",
+			msg:      ai.NewUserMessage(ai.NewTextPart(""), ai.NewTextPart("only")),
+			expected: "only",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, joinTextParts(tc.msg))
+		})
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package workersaitest provides an in-memory fake of the Workers AI API for
+// application tests, so flows built on the workersai plugin can run without
+// credentials or network access.
+//
+// The fake is an HTTP server speaking the same wire format as the real API.
+// Point the plugin at it through its BaseURL field and set dummy credentials
+// in the environment:
+//
+//	srv := workersaitest.NewServer()
+//	defer srv.Close()
+//	t.Setenv("CLOUDFLARE_API_TOKEN", "test-token")
+//	t.Setenv("CLOUDFLARE_ACCOUNT_ID", "test-account")
+//	w := &workersai.WorkersAI{BaseURL: srv.URL}
+//
+// Responses are scripted with [Server.Enqueue]: each queued [Reply] answers
+// one request, supporting plain text, tool-call sequences, injected errors
+// and artificial latency. When the queue is empty the server falls back to
+// the Default reply.
+package workersaitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ToolCall scripts one tool invocation the fake model requests.
+type ToolCall struct {
+	// ID identifies the call; generated when empty.
+	ID string
+	// Name is the tool to invoke.
+	Name string
+	// Arguments is the input the model passes to the tool.
+	Arguments map[string]any
+}
+
+// Reply scripts the response to one request.
+type Reply struct {
+	// Text is the assistant's message content.
+	Text string
+	// ReasoningContent is returned as the model's separate reasoning
+	// channel, as reasoning models do.
+	ReasoningContent string
+	// ToolCalls has the fake model request these tools instead of (or in
+	// addition to) answering.
+	ToolCalls []ToolCall
+
+	// Status, when non-zero and not 200, fails the request with that HTTP
+	// status. ErrorMessage, when set, fails it with a success:false
+	// envelope body (status 400 unless Status overrides it).
+	Status       int
+	ErrorMessage string
+
+	// Latency delays the response, for testing timeouts and deadline
+	// handling.
+	Latency time.Duration
+}
+
+// Request is one recorded request, for asserting on what the plugin sent.
+type Request struct {
+	// Model is the model name from the URL path.
+	Model string
+	// Body is the decoded JSON request body.
+	Body map[string]any
+}
+
+// Messages returns the request's messages as loosely-typed maps.
+func (r *Request) Messages() []map[string]any {
+	raw, _ := r.Body["messages"].([]any)
+	out := make([]map[string]any, 0, len(raw))
+	for _, m := range raw {
+		if msg, ok := m.(map[string]any); ok {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// Server is a fake Workers AI API server.
+type Server struct {
+	// URL is the base URL to point the plugin's BaseURL at.
+	URL string
+
+	// Default answers requests once the scripted queue is empty.
+	Default Reply
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	queue    []Reply
+	requests []Request
+	calls    int
+}
+
+// NewServer starts a fake server. Callers must Close it when done.
+func NewServer() *Server {
+	s := &Server{Default: Reply{Text: "ok"}}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() { s.srv.Close() }
+
+// Enqueue scripts the responses to the next requests, in order.
+func (s *Server) Enqueue(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, replies...)
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	var decoded map[string]any
+	json.Unmarshal(body, &decoded)
+
+	// The run endpoint is /accounts/{account}/ai/run/{model}; everything
+	// after "/ai/run/" is the model name.
+	model := ""
+	if _, after, ok := strings.Cut(r.URL.Path, "/ai/run/"); ok {
+		model = after
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Model: model, Body: decoded})
+	s.calls++
+	call := s.calls
+	reply := s.Default
+	if len(s.queue) > 0 {
+		reply = s.queue[0]
+		s.queue = s.queue[1:]
+	}
+	s.mu.Unlock()
+
+	if reply.Latency > 0 {
+		select {
+		case <-time.After(reply.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if reply.ErrorMessage != "" {
+		// The real API pairs error envelopes with a non-200 status,
+		// which is what the client keys its error handling off.
+		status := reply.Status
+		if status == 0 {
+			status = http.StatusBadRequest
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]any{
+			"success": false,
+			"errors":  []string{reply.ErrorMessage},
+		})
+		return
+	}
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		http.Error(w, http.StatusText(reply.Status), reply.Status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	message := map[string]any{"role": "assistant", "content": reply.Text}
+	if reply.ReasoningContent != "" {
+		message["reasoning_content"] = reply.ReasoningContent
+	}
+	if len(reply.ToolCalls) > 0 {
+		calls := make([]map[string]any, len(reply.ToolCalls))
+		for i, tc := range reply.ToolCalls {
+			id := tc.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d_%d", call, i)
+			}
+			args, _ := json.Marshal(tc.Arguments)
+			calls[i] = map[string]any{
+				"id":   id,
+				"type": "function",
+				"function": map[string]any{
+					"name":      tc.Name,
+					"arguments": string(args),
+				},
+			}
+		}
+		message["tool_calls"] = calls
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"success": true,
+		"errors":  []string{},
+		"result": map[string]any{
+			"choices": []map[string]any{{
+				"index":         0,
+				"message":       message,
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]any{
+				"prompt_tokens":     len(body) / 4,
+				"completion_tokens": len(reply.Text) / 4,
+			},
+		},
+	})
+}
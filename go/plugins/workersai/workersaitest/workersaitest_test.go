@@ -0,0 +1,94 @@
+package workersaitest
+
+import (
+	"testing"
+	"time"
+
+	client "github.com/ashishdatta/workers-ai-golang/workers-ai"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient points the vendored API client — the same one the plugin
+// drives — at the fake server.
+func newTestClient(srv *Server) *client.Client {
+	c := client.NewClient("test-account", "test-token")
+	c.BaseURL = srv.URL
+	return c
+}
+
+func TestServerScriptedText(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(Reply{Text: "scripted answer", ReasoningContent: "because"})
+
+	c := newTestClient(srv)
+	resp, err := c.ChatWithTools("@cf/meta/llama-3.1-8b-instruct", []client.Message{
+		client.ChatMessage{Role: "user", Content: "hello"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "scripted answer", resp.GetContent())
+	assert.Equal(t, "because", resp.GetReasoningContent())
+
+	// Queue exhausted: the default reply takes over.
+	resp, err = c.ChatWithTools("@cf/meta/llama-3.1-8b-instruct", []client.Message{
+		client.ChatMessage{Role: "user", Content: "again"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp.GetContent())
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "@cf/meta/llama-3.1-8b-instruct", requests[0].Model)
+	messages := requests[1].Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "again", messages[0]["content"])
+}
+
+func TestServerToolCalls(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(Reply{ToolCalls: []ToolCall{
+		{Name: "getWeather", Arguments: map[string]any{"city": "Lisbon"}},
+	}})
+
+	c := newTestClient(srv)
+	resp, err := c.ChatWithTools("@cf/meta/llama-3.1-8b-instruct", []client.Message{
+		client.ChatMessage{Role: "user", Content: "weather?"},
+	}, nil)
+	require.NoError(t, err)
+
+	calls := resp.GetToolCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "getWeather", calls[0].Function.Name)
+	assert.NotEmpty(t, calls[0].ID)
+	assert.JSONEq(t, `{"city":"Lisbon"}`, calls[0].Function.Arguments)
+}
+
+func TestServerErrorInjection(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(Reply{ErrorMessage: "model is overloaded"})
+
+	c := newTestClient(srv)
+	_, err := c.ChatWithTools("@cf/meta/llama-3.1-8b-instruct", []client.Message{
+		client.ChatMessage{Role: "user", Content: "hello"},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "model is overloaded")
+}
+
+func TestServerLatency(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Enqueue(Reply{Text: "slow", Latency: 50 * time.Millisecond})
+
+	c := newTestClient(srv)
+	start := time.Now()
+	resp, err := c.ChatWithTools("@cf/meta/llama-3.1-8b-instruct", []client.Message{
+		client.ChatMessage{Role: "user", Content: "hello"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "slow", resp.GetContent())
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+}